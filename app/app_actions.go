@@ -0,0 +1,27 @@
+package app
+
+import (
+	"go-chat/controller"
+	"go-chat/pkg/websocket"
+)
+
+// SetupActions registers the actions websocket clients can send.
+func SetupActions(chatActionController controller.ChatActionController) *websocket.Router {
+
+	router := websocket.NewRouter()
+	router.Use(websocket.Logger, websocket.RateLimit)
+
+	router.Handle("get_messages", chatActionController.GetMessages)
+	router.Handle("get_thread", chatActionController.GetThread)
+	router.Handle("get_mentions", chatActionController.GetMentions)
+	router.Handle("send_message", chatActionController.SendMessage)
+	router.Handle("edit_message", chatActionController.EditMessage)
+	router.Handle("delete_message", chatActionController.DeleteMessage)
+	router.Handle("add_reaction", chatActionController.AddReaction)
+	router.Handle("remove_reaction", chatActionController.RemoveReaction)
+	router.Handle("mark_read", chatActionController.MarkRead)
+	router.Handle("set_disappearing_timer", chatActionController.SetDisappearingTimer)
+	router.Handle("typing", chatActionController.Typing)
+
+	return router
+}
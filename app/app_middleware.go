@@ -0,0 +1,28 @@
+package app
+
+import (
+	"crypto/subtle"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/julienschmidt/httprouter"
+)
+
+// AdminOnly lets a request through only when it carries the ADMIN_TOKEN as a
+// bearer token. Admin routes are disabled while ADMIN_TOKEN is unset.
+func AdminOnly(next httprouter.Handle) httprouter.Handle {
+	return func(w http.ResponseWriter, r *http.Request, p httprouter.Params) {
+		token := os.Getenv("ADMIN_TOKEN")
+		given := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+
+		if token == "" || subtle.ConstantTimeCompare([]byte(token), []byte(given)) != 1 {
+			log.Printf("Rejected admin request to %s from %s", r.URL.Path, r.RemoteAddr)
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		next(w, r, p)
+	}
+}
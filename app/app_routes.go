@@ -3,13 +3,12 @@ package app
 import (
 	"go-chat/controller"
 	"go-chat/pkg/websocket"
-	"go-chat/repository"
 	"net/http"
 
 	"github.com/julienschmidt/httprouter"
 )
 
-func SetupRoutes(authController controller.AuthController, chatController controller.ChatController, userController controller.UserController, hub *websocket.Hub, chatRepository repository.ChatRepository) *httprouter.Router {
+func SetupRoutes(authController controller.AuthController, chatController controller.ChatController, scheduleController controller.ScheduleController, attachmentController controller.AttachmentController, uploadController controller.UploadController, userController controller.UserController, notificationController controller.NotificationController, adminController controller.AdminController, hub *websocket.Hub, actions *websocket.Router) *httprouter.Router {
 
 	router := httprouter.New()
 
@@ -18,14 +17,50 @@ func SetupRoutes(authController controller.AuthController, chatController contro
 
 	router.GET("/messages/:roomId", chatController.GetMessages)
 	router.POST("/messages/chatRoom", chatController.GetorCreateChatRoom)
+	router.POST("/messages/send", chatController.SendMessage)
+	router.PUT("/messages/:messageID", chatController.EditMessage)
+	router.DELETE("/messages/:messageID", chatController.DeleteMessage)
+	router.PUT("/messages/:messageID/reactions/:emoji", chatController.AddReaction)
+	router.DELETE("/messages/:messageID/reactions/:emoji", chatController.RemoveReaction)
+	router.GET("/threads/:messageID", chatController.GetThread)
+	router.GET("/mentions/:userID", chatController.GetMentions)
+	router.GET("/search", chatController.SearchMessages)
+	router.PUT("/chat-rooms/:roomID/disappearing-timer", chatController.SetDisappearingTimer)
+	router.PUT("/chat-rooms/:roomID/read", chatController.MarkRead)
+
+	router.POST("/scheduled-messages", scheduleController.ScheduleMessage)
+	router.GET("/scheduled-messages", scheduleController.GetScheduledMessages)
+	router.PUT("/scheduled-messages/:scheduledMessageID", scheduleController.EditScheduledMessage)
+	router.DELETE("/scheduled-messages/:scheduledMessageID", scheduleController.CancelScheduledMessage)
+
+	router.POST("/attachments", attachmentController.UploadAttachment)
+	router.GET("/attachments/:attachmentID", attachmentController.DownloadAttachment)
+
+	router.OPTIONS("/uploads", uploadController.GetOptions)
+	router.POST("/uploads", uploadController.CreateUpload)
+	router.HEAD("/uploads/:uploadID", uploadController.GetUpload)
+	router.PATCH("/uploads/:uploadID", uploadController.PatchUpload)
+	router.DELETE("/uploads/:uploadID", uploadController.DeleteUpload)
 
 	router.POST("/friends/add", userController.AddFriend)
 	router.GET("/friends/list/:userID", userController.GetFriendLists)
 	router.GET("/friend-request/:userID", userController.GetFriendRequests)
 	router.POST("/friend-request/respond", userController.UpdateFriendRequest)
 
+	router.GET("/notifications/:userID", notificationController.GetNotifications)
+	router.GET("/notifications/:userID/unread-count", notificationController.CountUnread)
+	router.PUT("/notifications/:userID/read/:notificationID", notificationController.MarkAsRead)
+	router.PUT("/notifications/:userID/read-all", notificationController.MarkAllAsRead)
+	router.DELETE("/notifications/:userID/:notificationID", notificationController.DeleteNotification)
+
+	router.GET("/admin/hub", AdminOnly(adminController.GetHubState))
+	router.POST("/admin/hub/kick", AdminOnly(adminController.KickConnections))
+
 	router.GET("/ws", func(w http.ResponseWriter, r *http.Request, p httprouter.Params) {
-		websocket.ServeWs(hub, w, r, chatRepository)
+		websocket.ServeWs(hub, actions, w, r)
+	})
+	router.GET("/sse", func(w http.ResponseWriter, r *http.Request, p httprouter.Params) {
+		websocket.ServeSSE(hub, w, r)
 	})
 	return router
 }
@@ -0,0 +1,17 @@
+package constant
+
+const (
+	ERROR_ATTACHMENT_NOT_EXIST  = "attachment doesn't exist"
+	ERROR_ATTACHMENT_EMPTY      = "attachment is empty"
+	ERROR_ATTACHMENT_TOO_LARGE  = "attachment is too large"
+	ERROR_ATTACHMENT_TYPE       = "attachment type isn't allowed"
+	ERROR_ATTACHMENT_OTHER_ROOM = "attachments must be uploaded by the sender to the same chat room"
+	ERROR_TOO_MANY_ATTACHMENTS  = "too many attachments"
+	ERROR_NOT_ROOM_MEMBER       = "user isn't a member of the chat room"
+	ERROR_INVALID_IMAGE         = "image can't be read"
+	ERROR_THUMBNAIL_NOT_EXIST   = "thumbnail doesn't exist"
+
+	PREVIEW_PENDING = "pending"
+	PREVIEW_READY   = "ready"
+	PREVIEW_FAILED  = "failed"
+)
@@ -0,0 +1,27 @@
+package constant
+
+const (
+	ERROR_MESSAGE_NOT_EXIST     = "message doesn't exist"
+	ERROR_NOT_MESSAGE_SENDER    = "only the sender can change this message"
+	ERROR_EDIT_WINDOW_EXPIRED   = "message can no longer be edited"
+	ERROR_MESSAGE_CONFLICT      = "message was changed by another request"
+	ERROR_MESSAGE_DELETED       = "message has been deleted"
+	ERROR_NOT_IN_CHAT           = "message isn't in one of your chats"
+	ERROR_DELETE_WINDOW_EXPIRED = "message can no longer be deleted"
+	ERROR_INVALID_DELETE_SCOPE  = "scope must be me or everyone"
+	ERROR_INVALID_EMOJI         = "reaction must be a single emoji"
+	ERROR_REPLY_OTHER_ROOM      = "replies must be in the same chat room"
+	ERROR_SEARCH_QUERY_TOO_LONG = "search query is too long"
+	ERROR_PAGE_ANCHORS          = "only one of before, after, around and a sequence range can be given"
+	ERROR_INVALID_SEQ_RANGE     = "invalid sequence range"
+	ERROR_INVALID_TIMER         = "timer must be off or between 5 seconds and a year, from sent or read"
+	ERROR_ROOM_NOTICE           = "room notices can't be changed"
+
+	DELETE_FOR_ME       = "me"
+	DELETE_FOR_EVERYONE = "everyone"
+
+	DISAPPEAR_FROM_SENT = "sent"
+	DISAPPEAR_FROM_READ = "read"
+
+	NOTICE_DISAPPEARING_TIMER = "disappearing_timer"
+)
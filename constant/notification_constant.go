@@ -0,0 +1,13 @@
+package constant
+
+const (
+	ERROR_NOTIFICATION_NOT_EXIST = "notification doesn't exist"
+	ERROR_INVALID_CURSOR         = "invalid cursor"
+
+	NOTIFICATION_FRIEND_REQUEST          = "friend_request"
+	NOTIFICATION_FRIEND_REQUEST_ACCEPTED = "friend_request_accepted"
+	NOTIFICATION_FRIEND_REQUEST_DENIED   = "friend_request_denied"
+	NOTIFICATION_MENTION                 = "mention"
+	NOTIFICATION_ROOM_INVITE             = "room_invite"
+	NOTIFICATION_SCHEDULED_FAILED        = "scheduled_message_failed"
+)
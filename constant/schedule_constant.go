@@ -0,0 +1,14 @@
+package constant
+
+const (
+	ERROR_SCHEDULED_MESSAGE_NOT_EXIST = "scheduled message doesn't exist"
+	ERROR_SCHEDULED_MESSAGE_SENDING   = "scheduled message is already being sent"
+	ERROR_SCHEDULED_MESSAGE_EMPTY     = "message_text or attachment_ids is required"
+	ERROR_SEND_AT_PAST                = "send_at must be in the future"
+	ERROR_SEND_AT_TOO_FAR             = "send_at is too far in the future"
+	ERROR_TOO_MANY_SCHEDULED          = "too many scheduled messages"
+
+	SCHEDULED_PENDING = "pending"
+	SCHEDULED_SENDING = "sending"
+	SCHEDULED_FAILED  = "failed"
+)
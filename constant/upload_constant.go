@@ -0,0 +1,10 @@
+package constant
+
+const (
+	ERROR_UPLOAD_NOT_EXIST       = "upload doesn't exist"
+	ERROR_UPLOAD_EXPIRED         = "upload has expired"
+	ERROR_UPLOAD_OFFSET          = "upload offset doesn't match"
+	ERROR_UPLOAD_LENGTH          = "content exceeds the upload length"
+	ERROR_UPLOAD_QUOTA           = "upload quota exceeded"
+	ERROR_UPLOAD_TOO_MANY_CHUNKS = "upload has too many chunks"
+)
@@ -0,0 +1,107 @@
+package controller
+
+import (
+	"encoding/json"
+	"go-chat/dto"
+	"go-chat/service"
+	"log"
+	"net/http"
+
+	"github.com/julienschmidt/httprouter"
+)
+
+type AdminController interface {
+	GetHubState(w http.ResponseWriter, r *http.Request, param httprouter.Params)
+	KickConnections(w http.ResponseWriter, r *http.Request, param httprouter.Params)
+}
+
+type AdminControllerImpl struct {
+	adminService service.AdminService
+}
+
+func NewAdminController(adminService service.AdminService) AdminController {
+	return &AdminControllerImpl{adminService: adminService}
+}
+
+// @Summary Inspect the hub
+// @Description List the connections attached to this instance's hub with their queue depths, grouped counts per room and per user
+// @Tags admin
+// @Produce json
+// @Param Authorization header string true "Bearer admin token"
+// @Success 200 {object} dto.HubStateResponse
+// @Failure 401 {object} error
+// @Router /admin/hub [get]
+func (a *AdminControllerImpl) GetHubState(w http.ResponseWriter, r *http.Request, param httprouter.Params) {
+	ctx := r.Context()
+
+	data, err := a.adminService.GetHubState(ctx)
+	if err != nil {
+		log.Println(err)
+		http.Error(w, "Failed to inspect hub", http.StatusInternalServerError)
+		return
+	}
+
+	resp := dto.Response{
+		Code:   200,
+		Status: "OK",
+		Data:   data,
+	}
+
+	w.Header().Add("Content-Type", "application/json")
+	encoder := json.NewEncoder(w)
+	if err := encoder.Encode(resp); err != nil {
+		log.Println(err)
+		http.Error(w, "Failed to encode response", http.StatusBadRequest)
+		return
+	}
+}
+
+// @Summary Kick connections
+// @Description Disconnect a single connection by connection_id, or every connection of user_id
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Bearer admin token"
+// @Param kick body dto.KickConnectionRequest true "Connections to kick"
+// @Success 200 {object} dto.KickConnectionResponse
+// @Failure 400 {object} error
+// @Failure 401 {object} error
+// @Router /admin/hub/kick [post]
+func (a *AdminControllerImpl) KickConnections(w http.ResponseWriter, r *http.Request, param httprouter.Params) {
+	kickRequest := dto.KickConnectionRequest{}
+
+	decoder := json.NewDecoder(r.Body)
+	if err := decoder.Decode(&kickRequest); err != nil {
+		log.Println(err)
+		http.Error(w, "Invalid request payload", http.StatusBadRequest)
+		return
+	}
+
+	if kickRequest.ConnectionID == "" && kickRequest.UserID == "" {
+		http.Error(w, "connection_id or user_id is required", http.StatusBadRequest)
+		return
+	}
+
+	ctx := r.Context()
+
+	data, err := a.adminService.KickConnections(ctx, kickRequest)
+	if err != nil {
+		log.Println(err)
+		http.Error(w, "Failed to kick connections", http.StatusInternalServerError)
+		return
+	}
+
+	resp := dto.Response{
+		Code:   200,
+		Status: "OK",
+		Data:   data,
+	}
+
+	w.Header().Add("Content-Type", "application/json")
+	encoder := json.NewEncoder(w)
+	if err := encoder.Encode(resp); err != nil {
+		log.Println(err)
+		http.Error(w, "Failed to encode response", http.StatusBadRequest)
+		return
+	}
+}
@@ -0,0 +1,182 @@
+package controller
+
+import (
+	"encoding/json"
+	"go-chat/constant"
+	"go-chat/dto"
+	"go-chat/service"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/julienschmidt/httprouter"
+)
+
+type AttachmentController interface {
+	UploadAttachment(w http.ResponseWriter, r *http.Request, param httprouter.Params)
+	DownloadAttachment(w http.ResponseWriter, r *http.Request, param httprouter.Params)
+}
+
+type AttachmentControllerImpl struct {
+	attachmentService service.AttachmentService
+}
+
+func NewAttachmentController(attachmentService service.AttachmentService) AttachmentController {
+	return &AttachmentControllerImpl{attachmentService: attachmentService}
+}
+
+// @Summary Upload an attachment
+// @Description Upload a file to a chat room, to be referenced by a message sent there afterwards. The type is sniffed from the content, and uploads of the same content share storage.
+// @Tags attachments
+// @Accept multipart/form-data
+// @Produce json
+// @Param chat_room_id query string true "Chat Room ID"
+// @Param user_id query string true "ID of the user uploading the file"
+// @Param file formData file true "File to upload"
+// @Success 200 {object} dto.AttachmentResponse
+// @Failure 400 {object} error
+// @Failure 403 {object} error
+// @Failure 413 {object} error
+// @Failure 415 {object} error
+// @Router /attachments [post]
+func (a *AttachmentControllerImpl) UploadAttachment(w http.ResponseWriter, r *http.Request, param httprouter.Params) {
+	uploadRequest := dto.UploadAttachmentRequest{
+		ChatRoomID: r.URL.Query().Get("chat_room_id"),
+		UploaderID: r.URL.Query().Get("user_id"),
+	}
+
+	if uploadRequest.ChatRoomID == "" || uploadRequest.UploaderID == "" {
+		http.Error(w, "chat_room_id and user_id are required", http.StatusBadRequest)
+		return
+	}
+
+	// the file part is streamed to the service rather than parsed into memory
+	reader, err := r.MultipartReader()
+	if err != nil {
+		log.Println(err)
+		http.Error(w, "Invalid multipart payload", http.StatusBadRequest)
+		return
+	}
+
+	for {
+		part, err := reader.NextPart()
+		if err != nil {
+			log.Println(err)
+			http.Error(w, "file is required", http.StatusBadRequest)
+			return
+		}
+		if part.FormName() == "file" {
+			uploadRequest.FileName = part.FileName()
+			uploadRequest.Content = part
+			break
+		}
+	}
+
+	ctx := r.Context()
+
+	data, err := a.attachmentService.UploadAttachment(ctx, uploadRequest)
+	if err != nil {
+		log.Println(err)
+		switch err.Error() {
+		case constant.ERROR_ATTACHMENT_EMPTY, constant.ERROR_INVALID_IMAGE:
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		case constant.ERROR_NOT_ROOM_MEMBER:
+			http.Error(w, err.Error(), http.StatusForbidden)
+		case constant.ERROR_ATTACHMENT_TOO_LARGE:
+			http.Error(w, err.Error(), http.StatusRequestEntityTooLarge)
+		case constant.ERROR_ATTACHMENT_TYPE:
+			http.Error(w, err.Error(), http.StatusUnsupportedMediaType)
+		default:
+			http.Error(w, "Failed to upload attachment", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	resp := dto.Response{
+		Code:   200,
+		Status: "OK",
+		Data:   data,
+	}
+
+	w.Header().Add("Content-Type", "application/json")
+	encoder := json.NewEncoder(w)
+	if err := encoder.Encode(resp); err != nil {
+		log.Println(err)
+		http.Error(w, "Failed to encode response", http.StatusBadRequest)
+		return
+	}
+}
+
+// @Summary Download an attachment
+// @Description Download the content of an attachment, or one of an image's thumbnails. Members of the chat room it was uploaded to may download it while a message they can see carries it, its uploader may also before sending it.
+// @Tags attachments
+// @Produce octet-stream
+// @Param attachmentID path string true "Attachment ID"
+// @Param user_id query string true "ID of the user downloading the file"
+// @Param thumbnail_size query int false "Size of the image's thumbnail to download instead, as listed in its preview"
+// @Success 200 {file} file
+// @Failure 400 {object} error
+// @Failure 403 {object} error
+// @Failure 404 {object} error
+// @Router /attachments/{attachmentID} [get]
+func (a *AttachmentControllerImpl) DownloadAttachment(w http.ResponseWriter, r *http.Request, param httprouter.Params) {
+	downloadRequest := dto.DownloadAttachmentRequest{
+		AttachmentID: param.ByName("attachmentID"),
+		UserID:       r.URL.Query().Get("user_id"),
+	}
+
+	if downloadRequest.UserID == "" {
+		http.Error(w, "user_id is required", http.StatusBadRequest)
+		return
+	}
+
+	if size := r.URL.Query().Get("thumbnail_size"); size != "" {
+		thumbnailSize, err := strconv.Atoi(size)
+		if err != nil || thumbnailSize <= 0 {
+			http.Error(w, "Invalid thumbnail_size", http.StatusBadRequest)
+			return
+		}
+		downloadRequest.ThumbnailSize = thumbnailSize
+	}
+
+	ctx := r.Context()
+
+	attachment, content, err := a.attachmentService.DownloadAttachment(ctx, downloadRequest)
+	if err != nil {
+		log.Println(err)
+		switch err.Error() {
+		case constant.ERROR_ATTACHMENT_NOT_EXIST:
+			http.Error(w, "Attachment not found", http.StatusNotFound)
+		case constant.ERROR_THUMBNAIL_NOT_EXIST:
+			http.Error(w, "Thumbnail not found", http.StatusNotFound)
+		case constant.ERROR_NOT_ROOM_MEMBER:
+			http.Error(w, err.Error(), http.StatusForbidden)
+		default:
+			http.Error(w, "Failed to download attachment", http.StatusInternalServerError)
+		}
+		return
+	}
+	defer content.Close()
+
+	// only images are shown inline, anything else is saved by the browser
+	disposition := "attachment"
+	if strings.HasPrefix(attachment.ContentType, "image/") {
+		disposition = "inline"
+	}
+	if value := mime.FormatMediaType(disposition, map[string]string{"filename": attachment.FileName}); value != "" {
+		disposition = value
+	}
+
+	w.Header().Set("Content-Type", attachment.ContentType)
+	w.Header().Set("Content-Length", strconv.FormatInt(attachment.Size, 10))
+	w.Header().Set("Content-Disposition", disposition)
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.Header().Set("Cache-Control", "private")
+
+	if _, err := io.Copy(w, content); err != nil {
+		log.Println("Failed to send attachment: ", err)
+	}
+}
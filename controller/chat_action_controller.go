@@ -0,0 +1,297 @@
+package controller
+
+import (
+	"context"
+	"go-chat/constant"
+	"go-chat/dto"
+	"go-chat/pkg/websocket"
+	"go-chat/service"
+	"log"
+)
+
+// ChatActionController handles the chat actions websocket clients send.
+type ChatActionController interface {
+	GetMessages(ctx *websocket.Context) error
+	GetThread(ctx *websocket.Context) error
+	GetMentions(ctx *websocket.Context) error
+	SendMessage(ctx *websocket.Context) error
+	EditMessage(ctx *websocket.Context) error
+	DeleteMessage(ctx *websocket.Context) error
+	AddReaction(ctx *websocket.Context) error
+	RemoveReaction(ctx *websocket.Context) error
+	MarkRead(ctx *websocket.Context) error
+	SetDisappearingTimer(ctx *websocket.Context) error
+	Typing(ctx *websocket.Context) error
+}
+
+type ChatActionControllerImpl struct {
+	chatService service.ChatService
+}
+
+func NewChatActionController(chatService service.ChatService) ChatActionController {
+	return &ChatActionControllerImpl{chatService: chatService}
+}
+
+func (c *ChatActionControllerImpl) GetMessages(ctx *websocket.Context) error {
+	roomID, ok := ctx.String("room_id")
+	if !ok {
+		log.Println("room_id is not a string")
+		ctx.Error("invalid_request", 0)
+		return nil
+	}
+
+	limit, _ := ctx.Int("limit")
+	before, _ := ctx.String("before")
+	after, _ := ctx.String("after")
+	around, _ := ctx.String("around")
+	fromSeq, _ := ctx.Int("from_seq")
+	toSeq, _ := ctx.Int("to_seq")
+
+	page, err := c.chatService.GetMessages(ctx, dto.GetMessagesRequest{
+		RoomID:          roomID,
+		Limit:           limit,
+		Before:          before,
+		After:           after,
+		Around:          around,
+		FromSeq:         fromSeq,
+		ToSeq:           toSeq,
+		ViewerID:        ctx.UserID,
+		CollapseThreads: ctx.Data["collapse_threads"] == true,
+	})
+	if err != nil {
+		return replyError(ctx, err)
+	}
+
+	return ctx.Reply(map[string]interface{}{
+		"action": "messages",
+		"data":   page,
+	})
+}
+
+// GetMentions replies with the messages mentioning the caller in any room.
+func (c *ChatActionControllerImpl) GetMentions(ctx *websocket.Context) error {
+	limit, _ := ctx.Int("limit")
+	offset, _ := ctx.Int("offset")
+
+	messages, err := c.chatService.GetMentions(ctx, dto.GetMentionsRequest{
+		UserID: ctx.UserID,
+		Limit:  limit,
+		Offset: offset,
+	})
+	if err != nil {
+		return err
+	}
+
+	return ctx.Reply(map[string]interface{}{
+		"action": "mentions",
+		"data":   messages,
+	})
+}
+
+// SendMessage saves the message, the chat service broadcasts it to the room.
+// Messages with attachments may leave out the text.
+func (c *ChatActionControllerImpl) SendMessage(ctx *websocket.Context) error {
+	attachmentIDs, ok := ctx.Strings("attachment_ids")
+	if !ok && ctx.Data["attachment_ids"] != nil {
+		log.Println("attachment_ids is not a list of strings")
+		ctx.Error("invalid_request", 0)
+		return nil
+	}
+
+	messageText, ok := ctx.String("message_text")
+	if !ok && len(attachmentIDs) == 0 {
+		log.Println("message_text is not a string")
+		ctx.Error("invalid_request", 0)
+		return nil
+	}
+
+	replyTo, _ := ctx.String("reply_to_message_id")
+
+	_, err := c.chatService.SendMessage(ctx, dto.SendMessageRequest{
+		RoomID:           ctx.RoomID,
+		SenderID:         ctx.UserID,
+		ReceiverID:       ctx.ReceiverID,
+		MessageText:      messageText,
+		ReplyToMessageID: replyTo,
+		AttachmentIDs:    attachmentIDs,
+	})
+	return replyError(ctx, err)
+}
+
+// GetThread replies with a thread's root and a page of its replies.
+func (c *ChatActionControllerImpl) GetThread(ctx *websocket.Context) error {
+	messageID, ok := ctx.String("message_id")
+	if !ok {
+		log.Println("message_id is not a string")
+		ctx.Error("invalid_request", 0)
+		return nil
+	}
+
+	limit, _ := ctx.Int("limit")
+	offset, _ := ctx.Int("offset")
+
+	thread, err := c.chatService.GetThread(ctx, dto.GetThreadRequest{
+		MessageID: messageID,
+		ViewerID:  ctx.UserID,
+		Limit:     limit,
+		Offset:    offset,
+	})
+	if err != nil {
+		return replyError(ctx, err)
+	}
+
+	return ctx.Reply(map[string]interface{}{
+		"action": "thread",
+		"data":   thread,
+	})
+}
+
+// EditMessage edits one of the caller's messages, the chat service
+// broadcasts the change to the room.
+func (c *ChatActionControllerImpl) EditMessage(ctx *websocket.Context) error {
+	messageID, ok := ctx.String("message_id")
+	if !ok {
+		log.Println("message_id is not a string")
+		ctx.Error("invalid_request", 0)
+		return nil
+	}
+
+	messageText, ok := ctx.String("message_text")
+	if !ok || messageText == "" {
+		log.Println("message_text is not a string")
+		ctx.Error("invalid_request", 0)
+		return nil
+	}
+
+	_, err := c.chatService.EditMessage(ctx, dto.EditMessageRequest{
+		MessageID:   messageID,
+		SenderID:    ctx.UserID,
+		MessageText: messageText,
+	})
+	return replyError(ctx, err)
+}
+
+// DeleteMessage deletes a message for the caller only or for everyone, as
+// given by "scope".
+func (c *ChatActionControllerImpl) DeleteMessage(ctx *websocket.Context) error {
+	messageID, ok := ctx.String("message_id")
+	if !ok {
+		log.Println("message_id is not a string")
+		ctx.Error("invalid_request", 0)
+		return nil
+	}
+
+	scope, _ := ctx.String("scope")
+
+	_, err := c.chatService.DeleteMessage(ctx, dto.DeleteMessageRequest{
+		MessageID: messageID,
+		UserID:    ctx.UserID,
+		Scope:     scope,
+	})
+	return replyError(ctx, err)
+}
+
+func (c *ChatActionControllerImpl) AddReaction(ctx *websocket.Context) error {
+	return c.react(ctx, c.chatService.AddReaction)
+}
+
+func (c *ChatActionControllerImpl) RemoveReaction(ctx *websocket.Context) error {
+	return c.react(ctx, c.chatService.RemoveReaction)
+}
+
+// MarkRead tells the room the caller read it up to "seq".
+func (c *ChatActionControllerImpl) MarkRead(ctx *websocket.Context) error {
+	seq, ok := ctx.Int("seq")
+	if !ok {
+		log.Println("seq is not a number")
+		ctx.Error("invalid_request", 0)
+		return nil
+	}
+
+	_, err := c.chatService.MarkRead(ctx, dto.MarkReadRequest{
+		RoomID: ctx.RoomID,
+		UserID: ctx.UserID,
+		Seq:    seq,
+	})
+	return replyError(ctx, err)
+}
+
+// SetDisappearingTimer changes the room's timer, the chat service posts the
+// notice of the change to the room.
+func (c *ChatActionControllerImpl) SetDisappearingTimer(ctx *websocket.Context) error {
+	disappearAfter, ok := ctx.Int("disappear_after")
+	if !ok {
+		log.Println("disappear_after is not a number")
+		ctx.Error("invalid_request", 0)
+		return nil
+	}
+
+	disappearFrom, _ := ctx.String("disappear_from")
+
+	_, err := c.chatService.SetDisappearingTimer(ctx, dto.SetDisappearingTimerRequest{
+		RoomID:         ctx.RoomID,
+		UserID:         ctx.UserID,
+		DisappearAfter: disappearAfter,
+		DisappearFrom:  disappearFrom,
+	})
+	return replyError(ctx, err)
+}
+
+func (c *ChatActionControllerImpl) react(ctx *websocket.Context, react func(context.Context, dto.ReactionRequest) (dto.ReactionResponse, error)) error {
+	messageID, ok := ctx.String("message_id")
+	if !ok {
+		log.Println("message_id is not a string")
+		ctx.Error("invalid_request", 0)
+		return nil
+	}
+
+	emoji, _ := ctx.String("emoji")
+
+	_, err := react(ctx, dto.ReactionRequest{
+		MessageID: messageID,
+		UserID:    ctx.UserID,
+		Emoji:     emoji,
+	})
+	return replyError(ctx, err)
+}
+
+// Typing relays to the room that the caller is typing.
+func (c *ChatActionControllerImpl) Typing(ctx *websocket.Context) error {
+	err := c.chatService.Typing(ctx, dto.TypingRequest{
+		RoomID: ctx.RoomID,
+		UserID: ctx.UserID,
+	})
+	return replyError(ctx, err)
+}
+
+// replyError sends the client an error frame for the chat errors it can act
+// on, anything else is returned for the router to report.
+func replyError(ctx *websocket.Context, err error) error {
+	if err == nil {
+		return nil
+	}
+
+	switch err.Error() {
+	case constant.ERROR_MESSAGE_NOT_EXIST, constant.ERROR_ATTACHMENT_NOT_EXIST:
+		ctx.Error("not_found", 0)
+	case constant.ERROR_NOT_MESSAGE_SENDER, constant.ERROR_NOT_IN_CHAT, constant.ERROR_NOT_ROOM_MEMBER,
+		constant.ERROR_ROOM_NOTICE:
+		ctx.Error("forbidden", 0)
+	case constant.ERROR_EDIT_WINDOW_EXPIRED:
+		ctx.Error("edit_window_expired", 0)
+	case constant.ERROR_DELETE_WINDOW_EXPIRED:
+		ctx.Error("delete_window_expired", 0)
+	case constant.ERROR_MESSAGE_CONFLICT:
+		ctx.Error("conflict", 0)
+	case constant.ERROR_MESSAGE_DELETED:
+		ctx.Error("deleted", 0)
+	case constant.ERROR_INVALID_DELETE_SCOPE, constant.ERROR_INVALID_EMOJI, constant.ERROR_REPLY_OTHER_ROOM,
+		constant.ERROR_ATTACHMENT_OTHER_ROOM, constant.ERROR_TOO_MANY_ATTACHMENTS,
+		constant.ERROR_INVALID_CURSOR, constant.ERROR_PAGE_ANCHORS, constant.ERROR_INVALID_SEQ_RANGE,
+		constant.ERROR_INVALID_TIMER:
+		ctx.Error("invalid_request", 0)
+	default:
+		return err
+	}
+	return nil
+}
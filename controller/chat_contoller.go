@@ -3,18 +3,30 @@ package controller
 import (
 	"context"
 	"encoding/json"
+	"go-chat/constant"
 	"go-chat/dto"
 	"go-chat/service"
 	"log"
 	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/julienschmidt/httprouter"
 )
 
 type ChatController interface {
 	GetMessages(w http.ResponseWriter, r *http.Request, param httprouter.Params)
+	GetThread(w http.ResponseWriter, r *http.Request, param httprouter.Params)
+	GetMentions(w http.ResponseWriter, r *http.Request, param httprouter.Params)
+	SearchMessages(w http.ResponseWriter, r *http.Request, param httprouter.Params)
 	GetorCreateChatRoom(w http.ResponseWriter, r *http.Request, param httprouter.Params)
+	SendMessage(w http.ResponseWriter, r *http.Request, param httprouter.Params)
+	EditMessage(w http.ResponseWriter, r *http.Request, param httprouter.Params)
+	DeleteMessage(w http.ResponseWriter, r *http.Request, param httprouter.Params)
+	AddReaction(w http.ResponseWriter, r *http.Request, param httprouter.Params)
+	RemoveReaction(w http.ResponseWriter, r *http.Request, param httprouter.Params)
+	SetDisappearingTimer(w http.ResponseWriter, r *http.Request, param httprouter.Params)
+	MarkRead(w http.ResponseWriter, r *http.Request, param httprouter.Params)
 }
 
 type ChatControllerImpl struct {
@@ -26,52 +38,309 @@ func NewChatController(chatService service.ChatService) ChatController {
 }
 
 // @Summary Get messages by room ID
-// @Description Retrieve a list of messages for a specific chat room
+// @Description Retrieve a page of messages of a specific chat room, newest first. Pages are walked with the before and after cursors of the previous page.
 // @Tags messages
 // @Accept json
 // @Produce json
 // @Param roomId path string true "Chat Room ID"
-// @Param limit query int false "Limit the number of messages returned"
-// @Success 200 {object} dto.GetMessagesResponse
+// @Param limit query int false "Maximum number of messages, 20 by default"
+// @Param before query string false "Cursor of a page, returns the messages sent before it"
+// @Param after query string false "Cursor of a page, returns the messages sent after it"
+// @Param around query string false "Message ID, returns the message with those sent just before and after it"
+// @Param from_seq query int false "First sequence number of a range of messages to return, given with to_seq"
+// @Param to_seq query int false "Last sequence number of the range, at most 100 messages after from_seq"
+// @Param viewer_id query string false "Hide the messages this user deleted for themselves"
+// @Param collapse_threads query bool false "Leave out thread replies, their roots carry the reply count"
+// @Success 200 {object} dto.GetMessagesPageResponse
 // @Failure 400 {object} error
+// @Failure 404 {object} error
 // @Router /messages/{roomID} [get]
 func (c *ChatControllerImpl) GetMessages(w http.ResponseWriter, r *http.Request, param httprouter.Params) {
-	roomID := param.ByName("roomId")
-	limitStr := r.URL.Query().Get("limit")
-	offsetStr := r.URL.Query().Get("offset")
+	query := r.URL.Query()
 
-	limit, err := strconv.ParseInt(limitStr, 10, 64)
+	messagesRequest := dto.GetMessagesRequest{
+		RoomID:          param.ByName("roomId"),
+		Before:          query.Get("before"),
+		After:           query.Get("after"),
+		Around:          query.Get("around"),
+		ViewerID:        query.Get("viewer_id"),
+		CollapseThreads: query.Get("collapse_threads") == "true",
+	}
+
+	if limitStr := query.Get("limit"); limitStr != "" {
+		limit, err := strconv.ParseInt(limitStr, 10, 64)
+		if err != nil {
+			log.Println(err)
+			http.Error(w, "Invalid limit parameter", http.StatusBadRequest)
+			return
+		}
+		messagesRequest.Limit = limit
+	}
+
+	if fromSeqStr := query.Get("from_seq"); fromSeqStr != "" {
+		fromSeq, err := strconv.ParseInt(fromSeqStr, 10, 64)
+		if err != nil {
+			log.Println(err)
+			http.Error(w, "Invalid from_seq parameter", http.StatusBadRequest)
+			return
+		}
+		messagesRequest.FromSeq = fromSeq
+	}
+
+	if toSeqStr := query.Get("to_seq"); toSeqStr != "" {
+		toSeq, err := strconv.ParseInt(toSeqStr, 10, 64)
+		if err != nil {
+			log.Println(err)
+			http.Error(w, "Invalid to_seq parameter", http.StatusBadRequest)
+			return
+		}
+		messagesRequest.ToSeq = toSeq
+	}
+
+	ctx := r.Context()
+
+	data, err := c.chatService.GetMessages(ctx, messagesRequest)
 	if err != nil {
 		log.Println(err)
-		http.Error(w, "Invalid limit parameter", http.StatusBadRequest)
+		switch err.Error() {
+		case constant.ERROR_INVALID_CURSOR, constant.ERROR_PAGE_ANCHORS, constant.ERROR_INVALID_SEQ_RANGE:
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		case constant.ERROR_MESSAGE_NOT_EXIST:
+			http.Error(w, "Message not found", http.StatusNotFound)
+		default:
+			http.Error(w, "Failed to get messages", http.StatusInternalServerError)
+		}
 		return
 	}
 
-	offset, err := strconv.ParseInt(offsetStr, 10, 64)
+	resp := dto.Response{
+		Code:   200,
+		Status: "OK",
+		Data:   data,
+	}
+
+	w.Header().Add("Content-Type", "application/json")
+	encoder := json.NewEncoder(w)
+	if err := encoder.Encode(resp); err != nil {
+		log.Println(err)
+		http.Error(w, "Failed to encode response", http.StatusBadRequest)
+		return
+	}
+}
+
+// @Summary Get a thread
+// @Description Retrieve the first message of a thread and a page of its replies, oldest first. Any message of the thread can be given.
+// @Tags messages
+// @Produce json
+// @Param messageID path string true "ID of a message in the thread"
+// @Param viewer_id query string false "Hide the replies this user deleted for themselves"
+// @Param limit query int false "Maximum number of replies, 20 by default"
+// @Param offset query int false "Number of replies to skip"
+// @Success 200 {object} dto.GetThreadResponse
+// @Failure 400 {object} error
+// @Failure 404 {object} error
+// @Router /threads/{messageID} [get]
+func (c *ChatControllerImpl) GetThread(w http.ResponseWriter, r *http.Request, param httprouter.Params) {
+	query := r.URL.Query()
+
+	threadRequest := dto.GetThreadRequest{
+		MessageID: param.ByName("messageID"),
+		ViewerID:  query.Get("viewer_id"),
+	}
+
+	if limitStr := query.Get("limit"); limitStr != "" {
+		limit, err := strconv.ParseInt(limitStr, 10, 64)
+		if err != nil {
+			log.Println(err)
+			http.Error(w, "Invalid limit parameter", http.StatusBadRequest)
+			return
+		}
+		threadRequest.Limit = limit
+	}
+
+	if offsetStr := query.Get("offset"); offsetStr != "" {
+		offset, err := strconv.ParseInt(offsetStr, 10, 64)
+		if err != nil || offset < 0 {
+			log.Println(err)
+			http.Error(w, "Invalid offset parameter", http.StatusBadRequest)
+			return
+		}
+		threadRequest.Offset = offset
+	}
+
+	ctx := r.Context()
+
+	data, err := c.chatService.GetThread(ctx, threadRequest)
 	if err != nil {
 		log.Println(err)
-		http.Error(w, "Invalid offset parameter", http.StatusBadRequest)
+		if err.Error() == constant.ERROR_MESSAGE_NOT_EXIST {
+			http.Error(w, "Message not found", http.StatusNotFound)
+			return
+		}
+		http.Error(w, "Failed to get thread", http.StatusInternalServerError)
+		return
 	}
 
-	messagesRequest := dto.GetMessagesRequest{
-		RoomID: roomID,
-		Limit:  limit,
-		Offset: offset,
+	resp := dto.Response{
+		Code:   200,
+		Status: "OK",
+		Data:   data,
 	}
 
-	decoder := json.NewDecoder(r.Body)
-	if err := decoder.Decode(&messagesRequest); err != nil {
+	w.Header().Add("Content-Type", "application/json")
+	encoder := json.NewEncoder(w)
+	if err := encoder.Encode(resp); err != nil {
 		log.Println(err)
-		http.Error(w, "Invalid request payload", http.StatusBadRequest)
+		http.Error(w, "Failed to encode response", http.StatusBadRequest)
 		return
 	}
+}
 
-	ctx := context.Background()
+// @Summary Get messages mentioning a user
+// @Description Retrieve the messages that mention the user in any chat room, newest first
+// @Tags messages
+// @Produce json
+// @Param userID path string true "User ID"
+// @Param limit query int false "Maximum number of messages, 20 by default"
+// @Param offset query int false "Number of messages to skip"
+// @Success 200 {array} dto.GetMessagesResponse
+// @Failure 400 {object} error
+// @Router /mentions/{userID} [get]
+func (c *ChatControllerImpl) GetMentions(w http.ResponseWriter, r *http.Request, param httprouter.Params) {
+	query := r.URL.Query()
 
-	data, err := c.chatService.GetMessages(ctx, messagesRequest)
+	mentionsRequest := dto.GetMentionsRequest{
+		UserID: param.ByName("userID"),
+	}
+
+	if limitStr := query.Get("limit"); limitStr != "" {
+		limit, err := strconv.ParseInt(limitStr, 10, 64)
+		if err != nil {
+			log.Println(err)
+			http.Error(w, "Invalid limit parameter", http.StatusBadRequest)
+			return
+		}
+		mentionsRequest.Limit = limit
+	}
+
+	if offsetStr := query.Get("offset"); offsetStr != "" {
+		offset, err := strconv.ParseInt(offsetStr, 10, 64)
+		if err != nil || offset < 0 {
+			log.Println(err)
+			http.Error(w, "Invalid offset parameter", http.StatusBadRequest)
+			return
+		}
+		mentionsRequest.Offset = offset
+	}
+
+	ctx := r.Context()
+
+	data, err := c.chatService.GetMentions(ctx, mentionsRequest)
 	if err != nil {
 		log.Println(err)
-		http.Error(w, "Failed to get messages", http.StatusInternalServerError)
+		http.Error(w, "Failed to get mentions", http.StatusInternalServerError)
+		return
+	}
+
+	resp := dto.Response{
+		Code:   200,
+		Status: "OK",
+		Data:   data,
+	}
+
+	w.Header().Add("Content-Type", "application/json")
+	encoder := json.NewEncoder(w)
+	if err := encoder.Encode(resp); err != nil {
+		log.Println(err)
+		http.Error(w, "Failed to encode response", http.StatusBadRequest)
+		return
+	}
+}
+
+// @Summary Search messages
+// @Description Search the messages of every chat room the user is a member of, best matches first, or newest first without a text query. Messages the user deleted for themselves are left out.
+// @Tags messages
+// @Produce json
+// @Param user_id query string true "ID of the user searching"
+// @Param q query string false "Words to match, a quoted phrase must match as a whole and -word excludes messages containing the word"
+// @Param sender_id query string false "Only messages sent by this user"
+// @Param chat_room_id query string false "Only messages in this chat room"
+// @Param from query string false "Only messages sent at or after this RFC 3339 time"
+// @Param to query string false "Only messages sent before this RFC 3339 time"
+// @Param limit query int false "Maximum number of messages, 20 by default"
+// @Param offset query int false "Number of messages to skip"
+// @Success 200 {array} dto.SearchMessagesResponse
+// @Failure 400 {object} error
+// @Failure 403 {object} error
+// @Router /search [get]
+func (c *ChatControllerImpl) SearchMessages(w http.ResponseWriter, r *http.Request, param httprouter.Params) {
+	query := r.URL.Query()
+
+	searchRequest := dto.SearchMessagesRequest{
+		UserID:     query.Get("user_id"),
+		Query:      query.Get("q"),
+		SenderID:   query.Get("sender_id"),
+		ChatRoomID: query.Get("chat_room_id"),
+	}
+
+	if searchRequest.UserID == "" {
+		http.Error(w, "user_id is required", http.StatusBadRequest)
+		return
+	}
+
+	if fromStr := query.Get("from"); fromStr != "" {
+		from, err := time.Parse(time.RFC3339, fromStr)
+		if err != nil {
+			log.Println(err)
+			http.Error(w, "Invalid from parameter", http.StatusBadRequest)
+			return
+		}
+		searchRequest.From = from
+	}
+
+	if toStr := query.Get("to"); toStr != "" {
+		to, err := time.Parse(time.RFC3339, toStr)
+		if err != nil {
+			log.Println(err)
+			http.Error(w, "Invalid to parameter", http.StatusBadRequest)
+			return
+		}
+		searchRequest.To = to
+	}
+
+	if limitStr := query.Get("limit"); limitStr != "" {
+		limit, err := strconv.ParseInt(limitStr, 10, 64)
+		if err != nil {
+			log.Println(err)
+			http.Error(w, "Invalid limit parameter", http.StatusBadRequest)
+			return
+		}
+		searchRequest.Limit = limit
+	}
+
+	if offsetStr := query.Get("offset"); offsetStr != "" {
+		offset, err := strconv.ParseInt(offsetStr, 10, 64)
+		if err != nil || offset < 0 {
+			log.Println(err)
+			http.Error(w, "Invalid offset parameter", http.StatusBadRequest)
+			return
+		}
+		searchRequest.Offset = offset
+	}
+
+	ctx := r.Context()
+
+	data, err := c.chatService.SearchMessages(ctx, searchRequest)
+	if err != nil {
+		log.Println(err)
+		switch err.Error() {
+		case constant.ERROR_SEARCH_QUERY_TOO_LONG:
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		case constant.ERROR_NOT_ROOM_MEMBER:
+			http.Error(w, err.Error(), http.StatusForbidden)
+		default:
+			http.Error(w, "Failed to search messages", http.StatusInternalServerError)
+		}
 		return
 	}
 
@@ -131,3 +400,381 @@ func (c *ChatControllerImpl) GetorCreateChatRoom(w http.ResponseWriter, r *http.
 		return
 	}
 }
+
+// @Summary Send a message
+// @Description Store a message and deliver it to every subscriber of the chat room. Used by clients on the SSE transport. Attachments are uploaded to /attachments first and referenced by ID.
+// @Tags messages
+// @Accept json
+// @Produce json
+// @Param message body dto.SendMessageRequest true "Message Data"
+// @Success 200 {object} dto.SendMessageResponse
+// @Failure 400 {object} error
+// @Router /messages/send [post]
+func (c *ChatControllerImpl) SendMessage(w http.ResponseWriter, r *http.Request, param httprouter.Params) {
+	sendRequest := dto.SendMessageRequest{}
+
+	decoder := json.NewDecoder(r.Body)
+	if err := decoder.Decode(&sendRequest); err != nil {
+		log.Println(err)
+		http.Error(w, "Invalid request payload", http.StatusBadRequest)
+		return
+	}
+
+	if sendRequest.RoomID == "" || sendRequest.SenderID == "" {
+		http.Error(w, "chat_room_id and sender_id are required", http.StatusBadRequest)
+		return
+	}
+
+	if sendRequest.MessageText == "" && len(sendRequest.AttachmentIDs) == 0 {
+		http.Error(w, "message_text or attachment_ids is required", http.StatusBadRequest)
+		return
+	}
+
+	ctx := r.Context()
+
+	data, err := c.chatService.SendMessage(ctx, sendRequest)
+	if err != nil {
+		log.Println(err)
+		switch err.Error() {
+		case constant.ERROR_MESSAGE_NOT_EXIST, constant.ERROR_MESSAGE_DELETED:
+			http.Error(w, "Replied message not found", http.StatusNotFound)
+		case constant.ERROR_ATTACHMENT_NOT_EXIST:
+			http.Error(w, "Attachment not found", http.StatusNotFound)
+		case constant.ERROR_REPLY_OTHER_ROOM, constant.ERROR_ATTACHMENT_OTHER_ROOM, constant.ERROR_TOO_MANY_ATTACHMENTS:
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		default:
+			http.Error(w, "Failed to send message", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	resp := dto.Response{
+		Code:   200,
+		Status: "OK",
+		Data:   data,
+	}
+
+	w.Header().Add("Content-Type", "application/json")
+	encoder := json.NewEncoder(w)
+	if err := encoder.Encode(resp); err != nil {
+		log.Println(err)
+		http.Error(w, "Failed to encode response", http.StatusBadRequest)
+		return
+	}
+}
+
+// @Summary Edit a message
+// @Description Replace the text of a message. Only its sender may edit it, within the configured edit window. The previous text is kept in the message's edit history.
+// @Tags messages
+// @Accept json
+// @Produce json
+// @Param messageID path string true "Message ID"
+// @Param message body dto.EditMessageRequest true "Sender and new text"
+// @Success 200 {object} dto.EditMessageResponse
+// @Failure 400 {object} error
+// @Failure 403 {object} error
+// @Failure 404 {object} error
+// @Failure 409 {object} error
+// @Router /messages/{messageID} [put]
+func (c *ChatControllerImpl) EditMessage(w http.ResponseWriter, r *http.Request, param httprouter.Params) {
+	editRequest := dto.EditMessageRequest{}
+
+	decoder := json.NewDecoder(r.Body)
+	if err := decoder.Decode(&editRequest); err != nil {
+		log.Println(err)
+		http.Error(w, "Invalid request payload", http.StatusBadRequest)
+		return
+	}
+	editRequest.MessageID = param.ByName("messageID")
+
+	if editRequest.SenderID == "" || editRequest.MessageText == "" {
+		http.Error(w, "sender_id and message_text are required", http.StatusBadRequest)
+		return
+	}
+
+	ctx := r.Context()
+
+	data, err := c.chatService.EditMessage(ctx, editRequest)
+	if err != nil {
+		log.Println(err)
+		switch err.Error() {
+		case constant.ERROR_MESSAGE_NOT_EXIST:
+			http.Error(w, "Message not found", http.StatusNotFound)
+		case constant.ERROR_NOT_MESSAGE_SENDER, constant.ERROR_EDIT_WINDOW_EXPIRED, constant.ERROR_ROOM_NOTICE:
+			http.Error(w, err.Error(), http.StatusForbidden)
+		case constant.ERROR_MESSAGE_CONFLICT, constant.ERROR_MESSAGE_DELETED:
+			http.Error(w, err.Error(), http.StatusConflict)
+		default:
+			http.Error(w, "Failed to edit message", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	resp := dto.Response{
+		Code:   200,
+		Status: "OK",
+		Data:   data,
+	}
+
+	w.Header().Add("Content-Type", "application/json")
+	encoder := json.NewEncoder(w)
+	if err := encoder.Encode(resp); err != nil {
+		log.Println(err)
+		http.Error(w, "Failed to encode response", http.StatusBadRequest)
+		return
+	}
+}
+
+// @Summary Delete a message
+// @Description Delete a message for the user only (scope "me"), or replace it with a tombstone for everyone in the room (scope "everyone"). Deleting for everyone is limited to the sender, moderators may delete any message at any time.
+// @Tags messages
+// @Produce json
+// @Param messageID path string true "Message ID"
+// @Param user_id query string true "ID of the user deleting the message"
+// @Param scope query string true "me or everyone"
+// @Success 200 {object} dto.DeleteMessageResponse
+// @Failure 400 {object} error
+// @Failure 403 {object} error
+// @Failure 404 {object} error
+// @Failure 409 {object} error
+// @Router /messages/{messageID} [delete]
+func (c *ChatControllerImpl) DeleteMessage(w http.ResponseWriter, r *http.Request, param httprouter.Params) {
+	deleteRequest := dto.DeleteMessageRequest{
+		MessageID: param.ByName("messageID"),
+		UserID:    r.URL.Query().Get("user_id"),
+		Scope:     r.URL.Query().Get("scope"),
+	}
+
+	if deleteRequest.UserID == "" {
+		http.Error(w, "user_id is required", http.StatusBadRequest)
+		return
+	}
+
+	ctx := r.Context()
+
+	data, err := c.chatService.DeleteMessage(ctx, deleteRequest)
+	if err != nil {
+		log.Println(err)
+		switch err.Error() {
+		case constant.ERROR_INVALID_DELETE_SCOPE:
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		case constant.ERROR_MESSAGE_NOT_EXIST:
+			http.Error(w, "Message not found", http.StatusNotFound)
+		case constant.ERROR_NOT_MESSAGE_SENDER, constant.ERROR_NOT_IN_CHAT, constant.ERROR_DELETE_WINDOW_EXPIRED,
+			constant.ERROR_ROOM_NOTICE:
+			http.Error(w, err.Error(), http.StatusForbidden)
+		case constant.ERROR_MESSAGE_DELETED:
+			http.Error(w, err.Error(), http.StatusConflict)
+		default:
+			http.Error(w, "Failed to delete message", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	resp := dto.Response{
+		Code:   200,
+		Status: "OK",
+		Data:   data,
+	}
+
+	w.Header().Add("Content-Type", "application/json")
+	encoder := json.NewEncoder(w)
+	if err := encoder.Encode(resp); err != nil {
+		log.Println(err)
+		http.Error(w, "Failed to encode response", http.StatusBadRequest)
+		return
+	}
+}
+
+// @Summary React to a message
+// @Description Add the user's reaction with an emoji to a message. Reacting twice with the same emoji has no effect.
+// @Tags messages
+// @Produce json
+// @Param messageID path string true "Message ID"
+// @Param emoji path string true "Emoji, URL encoded"
+// @Param user_id query string true "ID of the user reacting"
+// @Success 200 {object} dto.ReactionResponse
+// @Failure 400 {object} error
+// @Failure 403 {object} error
+// @Failure 404 {object} error
+// @Router /messages/{messageID}/reactions/{emoji} [put]
+func (c *ChatControllerImpl) AddReaction(w http.ResponseWriter, r *http.Request, param httprouter.Params) {
+	c.react(w, r, param, c.chatService.AddReaction)
+}
+
+// @Summary Remove a reaction
+// @Description Take back the user's reaction with an emoji to a message
+// @Tags messages
+// @Produce json
+// @Param messageID path string true "Message ID"
+// @Param emoji path string true "Emoji, URL encoded"
+// @Param user_id query string true "ID of the user who reacted"
+// @Success 200 {object} dto.ReactionResponse
+// @Failure 400 {object} error
+// @Failure 403 {object} error
+// @Failure 404 {object} error
+// @Router /messages/{messageID}/reactions/{emoji} [delete]
+func (c *ChatControllerImpl) RemoveReaction(w http.ResponseWriter, r *http.Request, param httprouter.Params) {
+	c.react(w, r, param, c.chatService.RemoveReaction)
+}
+
+func (c *ChatControllerImpl) react(w http.ResponseWriter, r *http.Request, param httprouter.Params, react func(context.Context, dto.ReactionRequest) (dto.ReactionResponse, error)) {
+	reactionRequest := dto.ReactionRequest{
+		MessageID: param.ByName("messageID"),
+		UserID:    r.URL.Query().Get("user_id"),
+		Emoji:     param.ByName("emoji"),
+	}
+
+	if reactionRequest.UserID == "" {
+		http.Error(w, "user_id is required", http.StatusBadRequest)
+		return
+	}
+
+	ctx := r.Context()
+
+	data, err := react(ctx, reactionRequest)
+	if err != nil {
+		log.Println(err)
+		switch err.Error() {
+		case constant.ERROR_INVALID_EMOJI:
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		case constant.ERROR_MESSAGE_NOT_EXIST, constant.ERROR_MESSAGE_DELETED:
+			http.Error(w, "Message not found", http.StatusNotFound)
+		case constant.ERROR_NOT_IN_CHAT, constant.ERROR_ROOM_NOTICE:
+			http.Error(w, err.Error(), http.StatusForbidden)
+		default:
+			http.Error(w, "Failed to update reaction", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	resp := dto.Response{
+		Code:   200,
+		Status: "OK",
+		Data:   data,
+	}
+
+	w.Header().Add("Content-Type", "application/json")
+	encoder := json.NewEncoder(w)
+	if err := encoder.Encode(resp); err != nil {
+		log.Println(err)
+		http.Error(w, "Failed to encode response", http.StatusBadRequest)
+		return
+	}
+}
+
+// @Summary Set a chat room's disappearing message timer
+// @Description Make the room's new messages expire disappear_after seconds after they're sent, or read by another member when disappear_from is read. 0 turns the timer off. A notice of the change is posted in the room.
+// @Tags chat rooms
+// @Accept json
+// @Produce json
+// @Param roomID path string true "Chat Room ID"
+// @Param timer body dto.SetDisappearingTimerRequest true "Member and timer"
+// @Success 200 {object} dto.DisappearingTimerResponse
+// @Failure 400 {object} error
+// @Failure 403 {object} error
+// @Router /chat-rooms/{roomID}/disappearing-timer [put]
+func (c *ChatControllerImpl) SetDisappearingTimer(w http.ResponseWriter, r *http.Request, param httprouter.Params) {
+	timerRequest := dto.SetDisappearingTimerRequest{}
+
+	decoder := json.NewDecoder(r.Body)
+	if err := decoder.Decode(&timerRequest); err != nil {
+		log.Println(err)
+		http.Error(w, "Invalid request payload", http.StatusBadRequest)
+		return
+	}
+	timerRequest.RoomID = param.ByName("roomID")
+
+	if timerRequest.UserID == "" {
+		http.Error(w, "user_id is required", http.StatusBadRequest)
+		return
+	}
+
+	ctx := r.Context()
+
+	data, err := c.chatService.SetDisappearingTimer(ctx, timerRequest)
+	if err != nil {
+		log.Println(err)
+		switch err.Error() {
+		case constant.ERROR_INVALID_TIMER:
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		case constant.ERROR_NOT_ROOM_MEMBER:
+			http.Error(w, err.Error(), http.StatusForbidden)
+		default:
+			http.Error(w, "Failed to set disappearing timer", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	resp := dto.Response{
+		Code:   200,
+		Status: "OK",
+		Data:   data,
+	}
+
+	w.Header().Add("Content-Type", "application/json")
+	encoder := json.NewEncoder(w)
+	if err := encoder.Encode(resp); err != nil {
+		log.Println(err)
+		http.Error(w, "Failed to encode response", http.StatusBadRequest)
+		return
+	}
+}
+
+// @Summary Mark a chat room as read
+// @Description Tell the room a member read its messages up to a sequence number. It starts the timers of the messages that disappear once read.
+// @Tags chat rooms
+// @Accept json
+// @Produce json
+// @Param roomID path string true "Chat Room ID"
+// @Param read body dto.MarkReadRequest true "Member and last sequence number read"
+// @Success 200 {object} dto.MarkReadResponse
+// @Failure 400 {object} error
+// @Failure 403 {object} error
+// @Router /chat-rooms/{roomID}/read [put]
+func (c *ChatControllerImpl) MarkRead(w http.ResponseWriter, r *http.Request, param httprouter.Params) {
+	readRequest := dto.MarkReadRequest{}
+
+	decoder := json.NewDecoder(r.Body)
+	if err := decoder.Decode(&readRequest); err != nil {
+		log.Println(err)
+		http.Error(w, "Invalid request payload", http.StatusBadRequest)
+		return
+	}
+	readRequest.RoomID = param.ByName("roomID")
+
+	if readRequest.UserID == "" {
+		http.Error(w, "user_id is required", http.StatusBadRequest)
+		return
+	}
+
+	ctx := r.Context()
+
+	data, err := c.chatService.MarkRead(ctx, readRequest)
+	if err != nil {
+		log.Println(err)
+		switch err.Error() {
+		case constant.ERROR_INVALID_SEQ_RANGE:
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		case constant.ERROR_NOT_ROOM_MEMBER:
+			http.Error(w, err.Error(), http.StatusForbidden)
+		default:
+			http.Error(w, "Failed to mark chat room as read", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	resp := dto.Response{
+		Code:   200,
+		Status: "OK",
+		Data:   data,
+	}
+
+	w.Header().Add("Content-Type", "application/json")
+	encoder := json.NewEncoder(w)
+	if err := encoder.Encode(resp); err != nil {
+		log.Println(err)
+		http.Error(w, "Failed to encode response", http.StatusBadRequest)
+		return
+	}
+}
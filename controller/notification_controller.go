@@ -0,0 +1,192 @@
+package controller
+
+import (
+	"encoding/json"
+	"go-chat/constant"
+	"go-chat/dto"
+	"go-chat/service"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/julienschmidt/httprouter"
+)
+
+type NotificationController interface {
+	GetNotifications(w http.ResponseWriter, r *http.Request, param httprouter.Params)
+	CountUnread(w http.ResponseWriter, r *http.Request, param httprouter.Params)
+	MarkAsRead(w http.ResponseWriter, r *http.Request, param httprouter.Params)
+	MarkAllAsRead(w http.ResponseWriter, r *http.Request, param httprouter.Params)
+	DeleteNotification(w http.ResponseWriter, r *http.Request, param httprouter.Params)
+}
+
+type NotificationControllerImpl struct {
+	notificationService service.NotificationService
+}
+
+func NewNotificationController(notificationService service.NotificationService) NotificationController {
+	return &NotificationControllerImpl{notificationService: notificationService}
+}
+
+// @Summary Get notifications
+// @Description Retrieve a user's notifications, newest first. Pass next_cursor from a previous page as cursor to get the page after it.
+// @Tags notifications
+// @Produce json
+// @Param userID path string true "User ID"
+// @Param cursor query string false "Cursor returned by the previous page"
+// @Param limit query int false "Maximum number of notifications, 20 by default"
+// @Param unread query bool false "Only return unread notifications"
+// @Success 200 {object} dto.GetNotificationsResponse
+// @Failure 400 {object} error
+// @Router /notifications/{userID} [get]
+func (n *NotificationControllerImpl) GetNotifications(w http.ResponseWriter, r *http.Request, param httprouter.Params) {
+	query := r.URL.Query()
+
+	notificationsRequest := dto.GetNotificationsRequest{
+		UserID:     param.ByName("userID"),
+		Cursor:     query.Get("cursor"),
+		UnreadOnly: query.Get("unread") == "true",
+	}
+
+	if limitStr := query.Get("limit"); limitStr != "" {
+		limit, err := strconv.ParseInt(limitStr, 10, 64)
+		if err != nil {
+			log.Println(err)
+			http.Error(w, "Invalid limit parameter", http.StatusBadRequest)
+			return
+		}
+		notificationsRequest.Limit = limit
+	}
+
+	ctx := r.Context()
+
+	data, err := n.notificationService.GetNotifications(ctx, notificationsRequest)
+	if err != nil {
+		log.Println(err)
+		if err.Error() == constant.ERROR_INVALID_CURSOR {
+			http.Error(w, "Invalid cursor parameter", http.StatusBadRequest)
+			return
+		}
+		http.Error(w, "Failed to get notifications", http.StatusInternalServerError)
+		return
+	}
+
+	writeNotificationResponse(w, data)
+}
+
+// @Summary Count unread notifications
+// @Description Count the notifications a user hasn't read yet
+// @Tags notifications
+// @Produce json
+// @Param userID path string true "User ID"
+// @Success 200 {object} dto.UnreadCountResponse
+// @Failure 400 {object} error
+// @Router /notifications/{userID}/unread-count [get]
+func (n *NotificationControllerImpl) CountUnread(w http.ResponseWriter, r *http.Request, param httprouter.Params) {
+	userID := param.ByName("userID")
+
+	ctx := r.Context()
+
+	data, err := n.notificationService.CountUnread(ctx, userID)
+	if err != nil {
+		log.Println(err)
+		http.Error(w, "Failed to count unread notifications", http.StatusInternalServerError)
+		return
+	}
+
+	writeNotificationResponse(w, data)
+}
+
+// @Summary Mark a notification as read
+// @Tags notifications
+// @Produce json
+// @Param userID path string true "User ID"
+// @Param notificationID path string true "Notification ID"
+// @Success 200 {object} dto.Response
+// @Failure 404 {object} error
+// @Router /notifications/{userID}/read/{notificationID} [put]
+func (n *NotificationControllerImpl) MarkAsRead(w http.ResponseWriter, r *http.Request, param httprouter.Params) {
+	userID := param.ByName("userID")
+	notificationID := param.ByName("notificationID")
+
+	ctx := r.Context()
+
+	err := n.notificationService.MarkAsRead(ctx, userID, notificationID)
+	if err != nil {
+		log.Println(err)
+		if err.Error() == constant.ERROR_NOTIFICATION_NOT_EXIST {
+			http.Error(w, "Notification not found", http.StatusNotFound)
+			return
+		}
+		http.Error(w, "Failed to mark notification as read", http.StatusInternalServerError)
+		return
+	}
+
+	writeNotificationResponse(w, nil)
+}
+
+// @Summary Mark all notifications as read
+// @Tags notifications
+// @Produce json
+// @Param userID path string true "User ID"
+// @Success 200 {object} dto.MarkAllReadResponse
+// @Failure 400 {object} error
+// @Router /notifications/{userID}/read-all [put]
+func (n *NotificationControllerImpl) MarkAllAsRead(w http.ResponseWriter, r *http.Request, param httprouter.Params) {
+	userID := param.ByName("userID")
+
+	ctx := r.Context()
+
+	data, err := n.notificationService.MarkAllAsRead(ctx, userID)
+	if err != nil {
+		log.Println(err)
+		http.Error(w, "Failed to mark notifications as read", http.StatusInternalServerError)
+		return
+	}
+
+	writeNotificationResponse(w, data)
+}
+
+// @Summary Delete a notification
+// @Tags notifications
+// @Produce json
+// @Param userID path string true "User ID"
+// @Param notificationID path string true "Notification ID"
+// @Success 200 {object} dto.Response
+// @Failure 404 {object} error
+// @Router /notifications/{userID}/{notificationID} [delete]
+func (n *NotificationControllerImpl) DeleteNotification(w http.ResponseWriter, r *http.Request, param httprouter.Params) {
+	userID := param.ByName("userID")
+	notificationID := param.ByName("notificationID")
+
+	ctx := r.Context()
+
+	err := n.notificationService.DeleteNotification(ctx, userID, notificationID)
+	if err != nil {
+		log.Println(err)
+		if err.Error() == constant.ERROR_NOTIFICATION_NOT_EXIST {
+			http.Error(w, "Notification not found", http.StatusNotFound)
+			return
+		}
+		http.Error(w, "Failed to delete notification", http.StatusInternalServerError)
+		return
+	}
+
+	writeNotificationResponse(w, nil)
+}
+
+func writeNotificationResponse(w http.ResponseWriter, data interface{}) {
+	resp := dto.Response{
+		Code:   200,
+		Status: "OK",
+		Data:   data,
+	}
+
+	w.Header().Add("Content-Type", "application/json")
+	encoder := json.NewEncoder(w)
+	if err := encoder.Encode(resp); err != nil {
+		log.Println(err)
+		http.Error(w, "Failed to encode response", http.StatusBadRequest)
+		return
+	}
+}
@@ -0,0 +1,197 @@
+package controller
+
+import (
+	"encoding/json"
+	"go-chat/constant"
+	"go-chat/dto"
+	"go-chat/service"
+	"log"
+	"net/http"
+
+	"github.com/julienschmidt/httprouter"
+)
+
+type ScheduleController interface {
+	ScheduleMessage(w http.ResponseWriter, r *http.Request, param httprouter.Params)
+	GetScheduledMessages(w http.ResponseWriter, r *http.Request, param httprouter.Params)
+	EditScheduledMessage(w http.ResponseWriter, r *http.Request, param httprouter.Params)
+	CancelScheduledMessage(w http.ResponseWriter, r *http.Request, param httprouter.Params)
+}
+
+type ScheduleControllerImpl struct {
+	scheduleService service.ScheduleService
+}
+
+func NewScheduleController(scheduleService service.ScheduleService) ScheduleController {
+	return &ScheduleControllerImpl{scheduleService: scheduleService}
+}
+
+// @Summary Schedule a message
+// @Description Store a message to be sent to a chat room at send_at, up to a year ahead. It's sent like any other message then, until then it can be listed, edited and cancelled by its sender.
+// @Tags scheduled messages
+// @Accept json
+// @Produce json
+// @Param message body dto.ScheduleMessageRequest true "Message Data"
+// @Success 200 {object} dto.ScheduledMessageResponse
+// @Failure 400 {object} error
+// @Failure 403 {object} error
+// @Router /scheduled-messages [post]
+func (s *ScheduleControllerImpl) ScheduleMessage(w http.ResponseWriter, r *http.Request, param httprouter.Params) {
+	scheduleRequest := dto.ScheduleMessageRequest{}
+
+	decoder := json.NewDecoder(r.Body)
+	if err := decoder.Decode(&scheduleRequest); err != nil {
+		log.Println(err)
+		http.Error(w, "Invalid request payload", http.StatusBadRequest)
+		return
+	}
+
+	if scheduleRequest.RoomID == "" || scheduleRequest.SenderID == "" || scheduleRequest.SendAt.IsZero() {
+		http.Error(w, "chat_room_id, sender_id and send_at are required", http.StatusBadRequest)
+		return
+	}
+
+	ctx := r.Context()
+
+	data, err := s.scheduleService.ScheduleMessage(ctx, scheduleRequest)
+	if err != nil {
+		log.Println(err)
+		writeScheduleError(w, err, "Failed to schedule message")
+		return
+	}
+
+	writeScheduleResponse(w, data)
+}
+
+// @Summary Get scheduled messages
+// @Description Retrieve the messages a user scheduled and that weren't sent yet, soonest first. Failed ones are kept until edited or cancelled.
+// @Tags scheduled messages
+// @Produce json
+// @Param user_id query string true "ID of the user who scheduled the messages"
+// @Param chat_room_id query string false "Only list the messages of this chat room"
+// @Success 200 {array} dto.ScheduledMessageResponse
+// @Failure 400 {object} error
+// @Router /scheduled-messages [get]
+func (s *ScheduleControllerImpl) GetScheduledMessages(w http.ResponseWriter, r *http.Request, param httprouter.Params) {
+	query := r.URL.Query()
+
+	messagesRequest := dto.GetScheduledMessagesRequest{
+		UserID: query.Get("user_id"),
+		RoomID: query.Get("chat_room_id"),
+	}
+
+	if messagesRequest.UserID == "" {
+		http.Error(w, "user_id is required", http.StatusBadRequest)
+		return
+	}
+
+	ctx := r.Context()
+
+	data, err := s.scheduleService.GetScheduledMessages(ctx, messagesRequest)
+	if err != nil {
+		log.Println(err)
+		http.Error(w, "Failed to get scheduled messages", http.StatusInternalServerError)
+		return
+	}
+
+	writeScheduleResponse(w, data)
+}
+
+// @Summary Edit a scheduled message
+// @Description Change the text, attachments or time of a scheduled message, fields left out are kept. Editing a failed message schedules it again.
+// @Tags scheduled messages
+// @Accept json
+// @Produce json
+// @Param scheduledMessageID path string true "Scheduled Message ID"
+// @Param message body dto.EditScheduledMessageRequest true "Sender and changes"
+// @Success 200 {object} dto.ScheduledMessageResponse
+// @Failure 400 {object} error
+// @Failure 404 {object} error
+// @Failure 409 {object} error
+// @Router /scheduled-messages/{scheduledMessageID} [put]
+func (s *ScheduleControllerImpl) EditScheduledMessage(w http.ResponseWriter, r *http.Request, param httprouter.Params) {
+	editRequest := dto.EditScheduledMessageRequest{}
+
+	decoder := json.NewDecoder(r.Body)
+	if err := decoder.Decode(&editRequest); err != nil {
+		log.Println(err)
+		http.Error(w, "Invalid request payload", http.StatusBadRequest)
+		return
+	}
+	editRequest.ScheduledMessageID = param.ByName("scheduledMessageID")
+
+	if editRequest.SenderID == "" {
+		http.Error(w, "sender_id is required", http.StatusBadRequest)
+		return
+	}
+
+	ctx := r.Context()
+
+	data, err := s.scheduleService.EditScheduledMessage(ctx, editRequest)
+	if err != nil {
+		log.Println(err)
+		writeScheduleError(w, err, "Failed to edit scheduled message")
+		return
+	}
+
+	writeScheduleResponse(w, data)
+}
+
+// @Summary Cancel a scheduled message
+// @Tags scheduled messages
+// @Produce json
+// @Param scheduledMessageID path string true "Scheduled Message ID"
+// @Param user_id query string true "ID of the user who scheduled the message"
+// @Success 200 {object} dto.Response
+// @Failure 404 {object} error
+// @Failure 409 {object} error
+// @Router /scheduled-messages/{scheduledMessageID} [delete]
+func (s *ScheduleControllerImpl) CancelScheduledMessage(w http.ResponseWriter, r *http.Request, param httprouter.Params) {
+	cancelRequest := dto.CancelScheduledMessageRequest{
+		ScheduledMessageID: param.ByName("scheduledMessageID"),
+		UserID:             r.URL.Query().Get("user_id"),
+	}
+
+	ctx := r.Context()
+
+	err := s.scheduleService.CancelScheduledMessage(ctx, cancelRequest)
+	if err != nil {
+		log.Println(err)
+		writeScheduleError(w, err, "Failed to cancel scheduled message")
+		return
+	}
+
+	writeScheduleResponse(w, nil)
+}
+
+func writeScheduleError(w http.ResponseWriter, err error, message string) {
+	switch err.Error() {
+	case constant.ERROR_SCHEDULED_MESSAGE_NOT_EXIST:
+		http.Error(w, "Scheduled message not found", http.StatusNotFound)
+	case constant.ERROR_SCHEDULED_MESSAGE_SENDING:
+		http.Error(w, err.Error(), http.StatusConflict)
+	case constant.ERROR_SCHEDULED_MESSAGE_EMPTY, constant.ERROR_SEND_AT_PAST, constant.ERROR_SEND_AT_TOO_FAR,
+		constant.ERROR_TOO_MANY_ATTACHMENTS:
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case constant.ERROR_NOT_ROOM_MEMBER, constant.ERROR_TOO_MANY_SCHEDULED:
+		http.Error(w, err.Error(), http.StatusForbidden)
+	default:
+		http.Error(w, message, http.StatusInternalServerError)
+	}
+}
+
+func writeScheduleResponse(w http.ResponseWriter, data interface{}) {
+	resp := dto.Response{
+		Code:   200,
+		Status: "OK",
+		Data:   data,
+	}
+
+	w.Header().Add("Content-Type", "application/json")
+	encoder := json.NewEncoder(w)
+	if err := encoder.Encode(resp); err != nil {
+		log.Println(err)
+		http.Error(w, "Failed to encode response", http.StatusBadRequest)
+		return
+	}
+}
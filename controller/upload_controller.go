@@ -0,0 +1,309 @@
+package controller
+
+import (
+	"encoding/base64"
+	"go-chat/constant"
+	"go-chat/dto"
+	"go-chat/service"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/julienschmidt/httprouter"
+)
+
+// uploads follow the tus resumable upload protocol, https://tus.io/protocols/resumable-upload
+const (
+	tusVersion    = "1.0.0"
+	tusExtensions = "creation,expiration,termination"
+)
+
+type UploadController interface {
+	GetOptions(w http.ResponseWriter, r *http.Request, param httprouter.Params)
+	CreateUpload(w http.ResponseWriter, r *http.Request, param httprouter.Params)
+	GetUpload(w http.ResponseWriter, r *http.Request, param httprouter.Params)
+	PatchUpload(w http.ResponseWriter, r *http.Request, param httprouter.Params)
+	DeleteUpload(w http.ResponseWriter, r *http.Request, param httprouter.Params)
+}
+
+type UploadControllerImpl struct {
+	uploadService service.UploadService
+}
+
+func NewUploadController(uploadService service.UploadService) UploadController {
+	return &UploadControllerImpl{uploadService: uploadService}
+}
+
+// @Summary Describe resumable uploads
+// @Description Tell tus clients the protocol version, extensions and maximum size the server supports.
+// @Tags uploads
+// @Success 204
+// @Router /uploads [options]
+func (u *UploadControllerImpl) GetOptions(w http.ResponseWriter, r *http.Request, param httprouter.Params) {
+	w.Header().Set("Tus-Resumable", tusVersion)
+	w.Header().Set("Tus-Version", tusVersion)
+	w.Header().Set("Tus-Extension", tusExtensions)
+	w.Header().Set("Tus-Max-Size", strconv.FormatInt(u.uploadService.MaxSize(), 10))
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// @Summary Create a resumable upload
+// @Description Start a tus upload of an attachment to a chat room. The file name is read from the filename key of Upload-Metadata. The content is then sent with PATCH requests to the returned Location.
+// @Tags uploads
+// @Param chat_room_id query string true "Chat Room ID"
+// @Param user_id query string true "ID of the user uploading the file"
+// @Param Tus-Resumable header string true "Protocol version, 1.0.0"
+// @Param Upload-Length header int true "Size of the file in bytes"
+// @Param Upload-Metadata header string false "Comma separated keys and base64 values, such as filename"
+// @Success 201
+// @Header 201 {string} Location "URL of the upload"
+// @Header 201 {string} Upload-Expires "When the upload expires unless more content is sent"
+// @Failure 400 {object} error
+// @Failure 403 {object} error
+// @Failure 412 {object} error
+// @Failure 413 {object} error
+// @Router /uploads [post]
+func (u *UploadControllerImpl) CreateUpload(w http.ResponseWriter, r *http.Request, param httprouter.Params) {
+	if !checkTusVersion(w, r) {
+		return
+	}
+
+	createRequest := dto.CreateUploadRequest{
+		ChatRoomID: r.URL.Query().Get("chat_room_id"),
+		UploaderID: r.URL.Query().Get("user_id"),
+		FileName:   parseUploadMetadata(r.Header.Get("Upload-Metadata"))["filename"],
+	}
+
+	if createRequest.ChatRoomID == "" || createRequest.UploaderID == "" {
+		http.Error(w, "chat_room_id and user_id are required", http.StatusBadRequest)
+		return
+	}
+
+	// uploads of unknown length (Upload-Defer-Length) aren't supported
+	length, err := strconv.ParseInt(r.Header.Get("Upload-Length"), 10, 64)
+	if err != nil || length < 0 {
+		http.Error(w, "Invalid Upload-Length", http.StatusBadRequest)
+		return
+	}
+	createRequest.Length = length
+
+	ctx := r.Context()
+
+	data, err := u.uploadService.CreateUpload(ctx, createRequest)
+	if err != nil {
+		log.Println(err)
+		switch err.Error() {
+		case constant.ERROR_ATTACHMENT_EMPTY:
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		case constant.ERROR_NOT_ROOM_MEMBER, constant.ERROR_UPLOAD_QUOTA:
+			http.Error(w, err.Error(), http.StatusForbidden)
+		case constant.ERROR_ATTACHMENT_TOO_LARGE:
+			http.Error(w, err.Error(), http.StatusRequestEntityTooLarge)
+		default:
+			http.Error(w, "Failed to create upload", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	// the user is kept in the URL, so clients resume with it as is
+	location := url.URL{
+		Path:     "/uploads/" + data.UploadID,
+		RawQuery: url.Values{"user_id": {data.UploaderID}}.Encode(),
+	}
+
+	w.Header().Set("Location", location.String())
+	writeUploadHeaders(w, data)
+	w.WriteHeader(http.StatusCreated)
+}
+
+// @Summary Get a resumable upload's offset
+// @Description Tell a tus client how much of the upload the server has, so it resumes from there. X-Attachment-ID is set once the upload is complete.
+// @Tags uploads
+// @Param uploadID path string true "Upload ID"
+// @Param user_id query string true "ID of the user uploading the file"
+// @Param Tus-Resumable header string true "Protocol version, 1.0.0"
+// @Success 200
+// @Header 200 {int} Upload-Offset "Bytes received"
+// @Header 200 {int} Upload-Length "Size of the file in bytes"
+// @Header 200 {string} X-Attachment-ID "ID of the attachment the upload made"
+// @Failure 404 {object} error
+// @Failure 410 {object} error
+// @Failure 412 {object} error
+// @Router /uploads/{uploadID} [head]
+func (u *UploadControllerImpl) GetUpload(w http.ResponseWriter, r *http.Request, param httprouter.Params) {
+	if !checkTusVersion(w, r) {
+		return
+	}
+
+	uploadRequest := dto.UploadRequest{
+		UploadID: param.ByName("uploadID"),
+		UserID:   r.URL.Query().Get("user_id"),
+	}
+
+	ctx := r.Context()
+
+	data, err := u.uploadService.GetUpload(ctx, uploadRequest)
+	if err != nil {
+		log.Println(err)
+		writeUploadError(w, err, "Failed to get upload")
+		return
+	}
+
+	w.Header().Set("Cache-Control", "no-store")
+	writeUploadHeaders(w, data)
+	w.WriteHeader(http.StatusOK)
+}
+
+// @Summary Send content of a resumable upload
+// @Description Append the request body to a tus upload at Upload-Offset, which must be the upload's current offset. The attachment is made once all of the file is received, and its ID returned in X-Attachment-ID.
+// @Tags uploads
+// @Accept application/offset+octet-stream
+// @Param uploadID path string true "Upload ID"
+// @Param user_id query string true "ID of the user uploading the file"
+// @Param Tus-Resumable header string true "Protocol version, 1.0.0"
+// @Param Upload-Offset header int true "Offset the content starts at"
+// @Success 204
+// @Header 204 {int} Upload-Offset "Bytes received"
+// @Header 204 {string} X-Attachment-ID "ID of the attachment the upload made"
+// @Failure 400 {object} error
+// @Failure 403 {object} error
+// @Failure 404 {object} error
+// @Failure 409 {object} error
+// @Failure 410 {object} error
+// @Failure 412 {object} error
+// @Failure 413 {object} error
+// @Failure 415 {object} error
+// @Router /uploads/{uploadID} [patch]
+func (u *UploadControllerImpl) PatchUpload(w http.ResponseWriter, r *http.Request, param httprouter.Params) {
+	if !checkTusVersion(w, r) {
+		return
+	}
+
+	if r.Header.Get("Content-Type") != "application/offset+octet-stream" {
+		http.Error(w, "Content-Type must be application/offset+octet-stream", http.StatusUnsupportedMediaType)
+		return
+	}
+
+	offset, err := strconv.ParseInt(r.Header.Get("Upload-Offset"), 10, 64)
+	if err != nil || offset < 0 {
+		http.Error(w, "Invalid Upload-Offset", http.StatusBadRequest)
+		return
+	}
+
+	patchRequest := dto.PatchUploadRequest{
+		UploadID: param.ByName("uploadID"),
+		UserID:   r.URL.Query().Get("user_id"),
+		Offset:   offset,
+		Content:  r.Body,
+	}
+
+	ctx := r.Context()
+
+	data, err := u.uploadService.PatchUpload(ctx, patchRequest)
+	if err != nil {
+		log.Println(err)
+		writeUploadError(w, err, "Failed to store upload")
+		return
+	}
+
+	writeUploadHeaders(w, data)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// @Summary Cancel a resumable upload
+// @Description Delete a tus upload and the content received so far. An attachment the upload already made is kept.
+// @Tags uploads
+// @Param uploadID path string true "Upload ID"
+// @Param user_id query string true "ID of the user uploading the file"
+// @Param Tus-Resumable header string true "Protocol version, 1.0.0"
+// @Success 204
+// @Failure 404 {object} error
+// @Failure 410 {object} error
+// @Failure 412 {object} error
+// @Router /uploads/{uploadID} [delete]
+func (u *UploadControllerImpl) DeleteUpload(w http.ResponseWriter, r *http.Request, param httprouter.Params) {
+	if !checkTusVersion(w, r) {
+		return
+	}
+
+	uploadRequest := dto.UploadRequest{
+		UploadID: param.ByName("uploadID"),
+		UserID:   r.URL.Query().Get("user_id"),
+	}
+
+	ctx := r.Context()
+
+	err := u.uploadService.DeleteUpload(ctx, uploadRequest)
+	if err != nil {
+		log.Println(err)
+		writeUploadError(w, err, "Failed to delete upload")
+		return
+	}
+
+	w.Header().Set("Tus-Resumable", tusVersion)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// checkTusVersion answers requests for another version of the protocol with
+// the version the server supports.
+func checkTusVersion(w http.ResponseWriter, r *http.Request) bool {
+	if r.Header.Get("Tus-Resumable") != tusVersion {
+		w.Header().Set("Tus-Version", tusVersion)
+		http.Error(w, "Unsupported Tus-Resumable version", http.StatusPreconditionFailed)
+		return false
+	}
+	return true
+}
+
+func writeUploadHeaders(w http.ResponseWriter, upload dto.UploadResponse) {
+	w.Header().Set("Tus-Resumable", tusVersion)
+	w.Header().Set("Upload-Offset", strconv.FormatInt(upload.Offset, 10))
+	w.Header().Set("Upload-Length", strconv.FormatInt(upload.Length, 10))
+	w.Header().Set("Upload-Expires", upload.ExpiresAt.UTC().Format(http.TimeFormat))
+	if upload.AttachmentID != "" {
+		w.Header().Set("X-Attachment-ID", upload.AttachmentID)
+	}
+}
+
+func writeUploadError(w http.ResponseWriter, err error, message string) {
+	w.Header().Set("Tus-Resumable", tusVersion)
+	switch err.Error() {
+	case constant.ERROR_UPLOAD_NOT_EXIST:
+		http.Error(w, "Upload not found", http.StatusNotFound)
+	case constant.ERROR_UPLOAD_EXPIRED:
+		http.Error(w, err.Error(), http.StatusGone)
+	case constant.ERROR_UPLOAD_OFFSET:
+		http.Error(w, err.Error(), http.StatusConflict)
+	case constant.ERROR_ATTACHMENT_EMPTY, constant.ERROR_INVALID_IMAGE, constant.ERROR_UPLOAD_TOO_MANY_CHUNKS:
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case constant.ERROR_NOT_ROOM_MEMBER:
+		http.Error(w, err.Error(), http.StatusForbidden)
+	case constant.ERROR_UPLOAD_LENGTH, constant.ERROR_ATTACHMENT_TOO_LARGE:
+		http.Error(w, err.Error(), http.StatusRequestEntityTooLarge)
+	case constant.ERROR_ATTACHMENT_TYPE:
+		http.Error(w, err.Error(), http.StatusUnsupportedMediaType)
+	default:
+		http.Error(w, message, http.StatusInternalServerError)
+	}
+}
+
+// parseUploadMetadata reads an Upload-Metadata header, comma separated keys
+// each followed by a space and its base64 value. Malformed pairs are skipped.
+func parseUploadMetadata(header string) map[string]string {
+	metadata := make(map[string]string)
+	for _, pair := range strings.Split(header, ",") {
+		key, value, _ := strings.Cut(strings.TrimSpace(pair), " ")
+		if key == "" {
+			continue
+		}
+
+		decoded, err := base64.StdEncoding.DecodeString(value)
+		if err != nil {
+			continue
+		}
+		metadata[key] = string(decoded)
+	}
+	return metadata
+}
@@ -24,9 +24,42 @@ const docTemplate = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
-        "/friend-request/respond": {
+        "/admin/hub": {
+            "get": {
+                "description": "List the connections attached to this instance's hub with their queue depths, grouped counts per room and per user",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Inspect the hub",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Bearer admin token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/dto.HubStateResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {}
+                    }
+                }
+            }
+        },
+        "/admin/hub/kick": {
             "post": {
-                "description": "Accept or reject a friend request",
+                "description": "Disconnect a single connection by connection_id, or every connection of user_id",
                 "consumes": [
                     "application/json"
                 ],
@@ -34,17 +67,24 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "friends"
+                    "admin"
                 ],
-                "summary": "Respond to a friend request",
+                "summary": "Kick connections",
                 "parameters": [
                     {
-                        "description": "Friend Request Response",
-                        "name": "response",
+                        "type": "string",
+                        "description": "Bearer admin token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "description": "Connections to kick",
+                        "name": "kick",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/dto.UpdateRequestParameter"
+                            "$ref": "#/definitions/dto.KickConnectionRequest"
                         }
                     }
                 ],
@@ -52,55 +92,139 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/dto.UpdateFriendRequestResponse"
+                            "$ref": "#/definitions/dto.KickConnectionResponse"
                         }
                     },
                     "400": {
                         "description": "Bad Request",
                         "schema": {}
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {}
                     }
                 }
             }
         },
-        "/friend-request/{userID}": {
-            "get": {
-                "description": "Retrieve a list of friend requests for a specific user",
+        "/attachments": {
+            "post": {
+                "description": "Upload a file to a chat room, to be referenced by a message sent there afterwards. The type is sniffed from the content, and uploads of the same content share storage.",
                 "consumes": [
-                    "application/json"
+                    "multipart/form-data"
                 ],
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "friends"
+                    "attachments"
                 ],
-                "summary": "Get friend requests",
+                "summary": "Upload an attachment",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "User ID",
-                        "name": "userID",
+                        "description": "Chat Room ID",
+                        "name": "chat_room_id",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ID of the user uploading the file",
+                        "name": "user_id",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "file",
+                        "description": "File to upload",
+                        "name": "file",
+                        "in": "formData",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/dto.AttachmentResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {}
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {}
+                    },
+                    "413": {
+                        "description": "Request Entity Too Large",
+                        "schema": {}
+                    },
+                    "415": {
+                        "description": "Unsupported Media Type",
+                        "schema": {}
+                    }
+                }
+            }
+        },
+        "/attachments/{attachmentID}": {
+            "get": {
+                "description": "Download the content of an attachment, or one of an image's thumbnails. Members of the chat room it was uploaded to may download it while a message they can see carries it, its uploader may also before sending it.",
+                "produces": [
+                    "application/octet-stream"
+                ],
+                "tags": [
+                    "attachments"
+                ],
+                "summary": "Download an attachment",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Attachment ID",
+                        "name": "attachmentID",
                         "in": "path",
                         "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ID of the user downloading the file",
+                        "name": "user_id",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Size of the image's thumbnail to download instead, as listed in its preview",
+                        "name": "thumbnail_size",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/dto.FriendRequestResponse"
+                            "type": "file"
                         }
                     },
                     "400": {
                         "description": "Bad Request",
                         "schema": {}
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {}
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {}
                     }
                 }
             }
         },
-        "/friends/add": {
-            "post": {
-                "description": "Send a friend request to another user",
+        "/chat-rooms/{roomID}/disappearing-timer": {
+            "put": {
+                "description": "Make the room's new messages expire disappear_after seconds after they're sent, or read by another member when disappear_from is read. 0 turns the timer off. A notice of the change is posted in the room.",
                 "consumes": [
                     "application/json"
                 ],
@@ -108,17 +232,24 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "friends"
+                    "chat rooms"
                 ],
-                "summary": "Add a friend",
+                "summary": "Set a chat room's disappearing message timer",
                 "parameters": [
                     {
-                        "description": "Friend Request Data",
-                        "name": "friend",
+                        "type": "string",
+                        "description": "Chat Room ID",
+                        "name": "roomID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Member and timer",
+                        "name": "timer",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/dto.FriendRequestParameter"
+                            "$ref": "#/definitions/dto.SetDisappearingTimerRequest"
                         }
                     }
                 ],
@@ -126,19 +257,23 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/dto.FriendRequestResponse"
+                            "$ref": "#/definitions/dto.DisappearingTimerResponse"
                         }
                     },
                     "400": {
                         "description": "Bad Request",
                         "schema": {}
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {}
                     }
                 }
             }
         },
-        "/friends/list/{userID}": {
-            "get": {
-                "description": "Retrieve a list of friends for a specific user",
+        "/chat-rooms/{roomID}/read": {
+            "put": {
+                "description": "Tell the room a member read its messages up to a sequence number. It starts the timers of the messages that disappear once read.",
                 "consumes": [
                     "application/json"
                 ],
@@ -146,35 +281,48 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "friends"
+                    "chat rooms"
                 ],
-                "summary": "Get friend lists",
+                "summary": "Mark a chat room as read",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "User ID",
-                        "name": "userID",
+                        "description": "Chat Room ID",
+                        "name": "roomID",
                         "in": "path",
                         "required": true
+                    },
+                    {
+                        "description": "Member and last sequence number read",
+                        "name": "read",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.MarkReadRequest"
+                        }
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/dto.GetFriendListsResponse"
+                            "$ref": "#/definitions/dto.MarkReadResponse"
                         }
                     },
                     "400": {
                         "description": "Bad Request",
                         "schema": {}
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {}
                     }
                 }
             }
         },
-        "/messages/ChatRoom": {
+        "/friend-request/respond": {
             "post": {
-                "description": "Retrieve an existing chat room for the specified users or create a new one if it doesn't exist.",
+                "description": "Accept or reject a friend request",
                 "consumes": [
                     "application/json"
                 ],
@@ -182,30 +330,25 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "messages"
+                    "friends"
                 ],
-                "summary": "Get or Create Chat Room",
+                "summary": "Respond to a friend request",
                 "parameters": [
                     {
-                        "type": "string",
-                        "description": "ID of the user in the chat room",
-                        "name": "user_id",
-                        "in": "query",
-                        "required": true
-                    },
-                    {
-                        "type": "string",
-                        "description": "ID of the friend in the chat room",
-                        "name": "friend_id",
-                        "in": "query",
-                        "required": true
+                        "description": "Friend Request Response",
+                        "name": "response",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.UpdateRequestParameter"
+                        }
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/dto.GetorCreateChatRoomResponse"
+                            "$ref": "#/definitions/dto.UpdateFriendRequestResponse"
                         }
                     },
                     "400": {
@@ -215,9 +358,9 @@ const docTemplate = `{
                 }
             }
         },
-        "/messages/{roomID}": {
+        "/friend-request/{userID}": {
             "get": {
-                "description": "Retrieve a list of messages for a specific chat room",
+                "description": "Retrieve a list of friend requests for a specific user",
                 "consumes": [
                     "application/json"
                 ],
@@ -225,29 +368,23 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "messages"
+                    "friends"
                 ],
-                "summary": "Get messages by room ID",
+                "summary": "Get friend requests",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Chat Room ID",
-                        "name": "roomId",
+                        "description": "User ID",
+                        "name": "userID",
                         "in": "path",
                         "required": true
-                    },
-                    {
-                        "type": "integer",
-                        "description": "Limit the number of messages returned",
-                        "name": "limit",
-                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/dto.GetMessagesResponse"
+                            "$ref": "#/definitions/dto.FriendRequestResponse"
                         }
                     },
                     "400": {
@@ -257,9 +394,9 @@ const docTemplate = `{
                 }
             }
         },
-        "/users/login": {
+        "/friends/add": {
             "post": {
-                "description": "Authenticate a user and return a token",
+                "description": "Send a friend request to another user",
                 "consumes": [
                     "application/json"
                 ],
@@ -267,17 +404,17 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "users"
+                    "friends"
                 ],
-                "summary": "Login a user",
+                "summary": "Add a friend",
                 "parameters": [
                     {
-                        "description": "Login Credentials",
-                        "name": "login",
+                        "description": "Friend Request Data",
+                        "name": "friend",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/dto.LoginDataRequest"
+                            "$ref": "#/definitions/dto.FriendRequestParameter"
                         }
                     }
                 ],
@@ -285,7 +422,7 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/dto.LoginDataResponse"
+                            "$ref": "#/definitions/dto.FriendRequestResponse"
                         }
                     },
                     "400": {
@@ -295,9 +432,9 @@ const docTemplate = `{
                 }
             }
         },
-        "/users/register": {
-            "post": {
-                "description": "Create a new user account",
+        "/friends/list/{userID}": {
+            "get": {
+                "description": "Retrieve a list of friends for a specific user",
                 "consumes": [
                     "application/json"
                 ],
@@ -305,25 +442,71 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "users"
+                    "friends"
                 ],
-                "summary": "Register a new user",
+                "summary": "Get friend lists",
                 "parameters": [
                     {
-                        "description": "User Data",
-                        "name": "user",
-                        "in": "body",
-                        "required": true,
+                        "type": "string",
+                        "description": "User ID",
+                        "name": "userID",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/dto.RegisterDataRequest"
+                            "$ref": "#/definitions/dto.GetFriendListsResponse"
                         }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {}
+                    }
+                }
+            }
+        },
+        "/mentions/{userID}": {
+            "get": {
+                "description": "Retrieve the messages that mention the user in any chat room, newest first",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "messages"
+                ],
+                "summary": "Get messages mentioning a user",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "User ID",
+                        "name": "userID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Maximum number of messages, 20 by default",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Number of messages to skip",
+                        "name": "offset",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/dto.RegisterDataResponse"
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/dto.GetMessagesResponse"
+                            }
                         }
                     },
                     "400": {
@@ -332,173 +515,2205 @@ const docTemplate = `{
                     }
                 }
             }
-        }
+        },
+        "/messages/chatRoom": {
+            "post": {
+                "description": "Retrieve an existing chat room for the specified users or create a new one if it doesn't exist.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "messages"
+                ],
+                "summary": "Get or Create Chat Room",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID of the user in the chat room",
+                        "name": "user_id",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ID of the friend in the chat room",
+                        "name": "friend_id",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/dto.GetorCreateChatRoomResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {}
+                    }
+                }
+            }
+        },
+        "/messages/send": {
+            "post": {
+                "description": "Store a message and deliver it to every subscriber of the chat room. Used by clients on the SSE transport. Attachments are uploaded to /attachments first and referenced by ID.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "messages"
+                ],
+                "summary": "Send a message",
+                "parameters": [
+                    {
+                        "description": "Message Data",
+                        "name": "message",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.SendMessageRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/dto.SendMessageResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {}
+                    }
+                }
+            }
+        },
+        "/messages/{messageID}": {
+            "put": {
+                "description": "Replace the text of a message. Only its sender may edit it, within the configured edit window. The previous text is kept in the message's edit history.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "messages"
+                ],
+                "summary": "Edit a message",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Message ID",
+                        "name": "messageID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Sender and new text",
+                        "name": "message",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.EditMessageRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/dto.EditMessageResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {}
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {}
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {}
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {}
+                    }
+                }
+            },
+            "delete": {
+                "description": "Delete a message for the user only (scope \"me\"), or replace it with a tombstone for everyone in the room (scope \"everyone\"). Deleting for everyone is limited to the sender, moderators may delete any message at any time.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "messages"
+                ],
+                "summary": "Delete a message",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Message ID",
+                        "name": "messageID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ID of the user deleting the message",
+                        "name": "user_id",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "me or everyone",
+                        "name": "scope",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/dto.DeleteMessageResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {}
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {}
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {}
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {}
+                    }
+                }
+            }
+        },
+        "/messages/{messageID}/reactions/{emoji}": {
+            "put": {
+                "description": "Add the user's reaction with an emoji to a message. Reacting twice with the same emoji has no effect.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "messages"
+                ],
+                "summary": "React to a message",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Message ID",
+                        "name": "messageID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Emoji, URL encoded",
+                        "name": "emoji",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ID of the user reacting",
+                        "name": "user_id",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ReactionResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {}
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {}
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {}
+                    }
+                }
+            },
+            "delete": {
+                "description": "Take back the user's reaction with an emoji to a message",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "messages"
+                ],
+                "summary": "Remove a reaction",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Message ID",
+                        "name": "messageID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Emoji, URL encoded",
+                        "name": "emoji",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ID of the user who reacted",
+                        "name": "user_id",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ReactionResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {}
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {}
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {}
+                    }
+                }
+            }
+        },
+        "/messages/{roomID}": {
+            "get": {
+                "description": "Retrieve a page of messages of a specific chat room, newest first. Pages are walked with the before and after cursors of the previous page.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "messages"
+                ],
+                "summary": "Get messages by room ID",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Chat Room ID",
+                        "name": "roomId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Maximum number of messages, 20 by default",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Cursor of a page, returns the messages sent before it",
+                        "name": "before",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Cursor of a page, returns the messages sent after it",
+                        "name": "after",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Message ID, returns the message with those sent just before and after it",
+                        "name": "around",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "First sequence number of a range of messages to return, given with to_seq",
+                        "name": "from_seq",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Last sequence number of the range, at most 100 messages after from_seq",
+                        "name": "to_seq",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Hide the messages this user deleted for themselves",
+                        "name": "viewer_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Leave out thread replies, their roots carry the reply count",
+                        "name": "collapse_threads",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/dto.GetMessagesPageResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {}
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {}
+                    }
+                }
+            }
+        },
+        "/notifications/{userID}": {
+            "get": {
+                "description": "Retrieve a user's notifications, newest first. Pass next_cursor from a previous page as cursor to get the page after it.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "notifications"
+                ],
+                "summary": "Get notifications",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "User ID",
+                        "name": "userID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Cursor returned by the previous page",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Maximum number of notifications, 20 by default",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Only return unread notifications",
+                        "name": "unread",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/dto.GetNotificationsResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {}
+                    }
+                }
+            }
+        },
+        "/notifications/{userID}/read-all": {
+            "put": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "notifications"
+                ],
+                "summary": "Mark all notifications as read",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "User ID",
+                        "name": "userID",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/dto.MarkAllReadResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {}
+                    }
+                }
+            }
+        },
+        "/notifications/{userID}/read/{notificationID}": {
+            "put": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "notifications"
+                ],
+                "summary": "Mark a notification as read",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "User ID",
+                        "name": "userID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Notification ID",
+                        "name": "notificationID",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Response"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {}
+                    }
+                }
+            }
+        },
+        "/notifications/{userID}/unread-count": {
+            "get": {
+                "description": "Count the notifications a user hasn't read yet",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "notifications"
+                ],
+                "summary": "Count unread notifications",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "User ID",
+                        "name": "userID",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/dto.UnreadCountResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {}
+                    }
+                }
+            }
+        },
+        "/notifications/{userID}/{notificationID}": {
+            "delete": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "notifications"
+                ],
+                "summary": "Delete a notification",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "User ID",
+                        "name": "userID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Notification ID",
+                        "name": "notificationID",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Response"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {}
+                    }
+                }
+            }
+        },
+        "/scheduled-messages": {
+            "get": {
+                "description": "Retrieve the messages a user scheduled and that weren't sent yet, soonest first. Failed ones are kept until edited or cancelled.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "scheduled messages"
+                ],
+                "summary": "Get scheduled messages",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID of the user who scheduled the messages",
+                        "name": "user_id",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only list the messages of this chat room",
+                        "name": "chat_room_id",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/dto.ScheduledMessageResponse"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {}
+                    }
+                }
+            },
+            "post": {
+                "description": "Store a message to be sent to a chat room at send_at, up to a year ahead. It's sent like any other message then, until then it can be listed, edited and cancelled by its sender.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "scheduled messages"
+                ],
+                "summary": "Schedule a message",
+                "parameters": [
+                    {
+                        "description": "Message Data",
+                        "name": "message",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.ScheduleMessageRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ScheduledMessageResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {}
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {}
+                    }
+                }
+            }
+        },
+        "/scheduled-messages/{scheduledMessageID}": {
+            "put": {
+                "description": "Change the text, attachments or time of a scheduled message, fields left out are kept. Editing a failed message schedules it again.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "scheduled messages"
+                ],
+                "summary": "Edit a scheduled message",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Scheduled Message ID",
+                        "name": "scheduledMessageID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Sender and changes",
+                        "name": "message",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.EditScheduledMessageRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ScheduledMessageResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {}
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {}
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {}
+                    }
+                }
+            },
+            "delete": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "scheduled messages"
+                ],
+                "summary": "Cancel a scheduled message",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Scheduled Message ID",
+                        "name": "scheduledMessageID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ID of the user who scheduled the message",
+                        "name": "user_id",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Response"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {}
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {}
+                    }
+                }
+            }
+        },
+        "/search": {
+            "get": {
+                "description": "Search the messages of every chat room the user is a member of, best matches first, or newest first without a text query. Messages the user deleted for themselves are left out.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "messages"
+                ],
+                "summary": "Search messages",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID of the user searching",
+                        "name": "user_id",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Words to match, a quoted phrase must match as a whole and -word excludes messages containing the word",
+                        "name": "q",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only messages sent by this user",
+                        "name": "sender_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only messages in this chat room",
+                        "name": "chat_room_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only messages sent at or after this RFC 3339 time",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only messages sent before this RFC 3339 time",
+                        "name": "to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Maximum number of messages, 20 by default",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Number of messages to skip",
+                        "name": "offset",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/dto.SearchMessagesResponse"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {}
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {}
+                    }
+                }
+            }
+        },
+        "/threads/{messageID}": {
+            "get": {
+                "description": "Retrieve the first message of a thread and a page of its replies, oldest first. Any message of the thread can be given.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "messages"
+                ],
+                "summary": "Get a thread",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID of a message in the thread",
+                        "name": "messageID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Hide the replies this user deleted for themselves",
+                        "name": "viewer_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Maximum number of replies, 20 by default",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Number of replies to skip",
+                        "name": "offset",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/dto.GetThreadResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {}
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {}
+                    }
+                }
+            }
+        },
+        "/uploads": {
+            "post": {
+                "description": "Start a tus upload of an attachment to a chat room. The file name is read from the filename key of Upload-Metadata. The content is then sent with PATCH requests to the returned Location.",
+                "tags": [
+                    "uploads"
+                ],
+                "summary": "Create a resumable upload",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Chat Room ID",
+                        "name": "chat_room_id",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ID of the user uploading the file",
+                        "name": "user_id",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Protocol version, 1.0.0",
+                        "name": "Tus-Resumable",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Size of the file in bytes",
+                        "name": "Upload-Length",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Comma separated keys and base64 values, such as filename",
+                        "name": "Upload-Metadata",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "headers": {
+                            "Location": {
+                                "type": "string",
+                                "description": "URL of the upload"
+                            },
+                            "Upload-Expires": {
+                                "type": "string",
+                                "description": "When the upload expires unless more content is sent"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {}
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {}
+                    },
+                    "412": {
+                        "description": "Precondition Failed",
+                        "schema": {}
+                    },
+                    "413": {
+                        "description": "Request Entity Too Large",
+                        "schema": {}
+                    }
+                }
+            },
+            "options": {
+                "description": "Tell tus clients the protocol version, extensions and maximum size the server supports.",
+                "tags": [
+                    "uploads"
+                ],
+                "summary": "Describe resumable uploads",
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    }
+                }
+            }
+        },
+        "/uploads/{uploadID}": {
+            "delete": {
+                "description": "Delete a tus upload and the content received so far. An attachment the upload already made is kept.",
+                "tags": [
+                    "uploads"
+                ],
+                "summary": "Cancel a resumable upload",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Upload ID",
+                        "name": "uploadID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ID of the user uploading the file",
+                        "name": "user_id",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Protocol version, 1.0.0",
+                        "name": "Tus-Resumable",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {}
+                    },
+                    "410": {
+                        "description": "Gone",
+                        "schema": {}
+                    },
+                    "412": {
+                        "description": "Precondition Failed",
+                        "schema": {}
+                    }
+                }
+            },
+            "head": {
+                "description": "Tell a tus client how much of the upload the server has, so it resumes from there. X-Attachment-ID is set once the upload is complete.",
+                "tags": [
+                    "uploads"
+                ],
+                "summary": "Get a resumable upload's offset",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Upload ID",
+                        "name": "uploadID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ID of the user uploading the file",
+                        "name": "user_id",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Protocol version, 1.0.0",
+                        "name": "Tus-Resumable",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "headers": {
+                            "Upload-Length": {
+                                "type": "int",
+                                "description": "Size of the file in bytes"
+                            },
+                            "Upload-Offset": {
+                                "type": "int",
+                                "description": "Bytes received"
+                            },
+                            "X-Attachment-ID": {
+                                "type": "string",
+                                "description": "ID of the attachment the upload made"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {}
+                    },
+                    "410": {
+                        "description": "Gone",
+                        "schema": {}
+                    },
+                    "412": {
+                        "description": "Precondition Failed",
+                        "schema": {}
+                    }
+                }
+            },
+            "patch": {
+                "description": "Append the request body to a tus upload at Upload-Offset, which must be the upload's current offset. The attachment is made once all of the file is received, and its ID returned in X-Attachment-ID.",
+                "consumes": [
+                    "application/offset+octet-stream"
+                ],
+                "tags": [
+                    "uploads"
+                ],
+                "summary": "Send content of a resumable upload",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Upload ID",
+                        "name": "uploadID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ID of the user uploading the file",
+                        "name": "user_id",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Protocol version, 1.0.0",
+                        "name": "Tus-Resumable",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Offset the content starts at",
+                        "name": "Upload-Offset",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content",
+                        "headers": {
+                            "Upload-Offset": {
+                                "type": "int",
+                                "description": "Bytes received"
+                            },
+                            "X-Attachment-ID": {
+                                "type": "string",
+                                "description": "ID of the attachment the upload made"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {}
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {}
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {}
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {}
+                    },
+                    "410": {
+                        "description": "Gone",
+                        "schema": {}
+                    },
+                    "412": {
+                        "description": "Precondition Failed",
+                        "schema": {}
+                    },
+                    "413": {
+                        "description": "Request Entity Too Large",
+                        "schema": {}
+                    },
+                    "415": {
+                        "description": "Unsupported Media Type",
+                        "schema": {}
+                    }
+                }
+            }
+        },
+        "/users/login": {
+            "post": {
+                "description": "Authenticate a user and return a token",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Login a user",
+                "parameters": [
+                    {
+                        "description": "Login Credentials",
+                        "name": "login",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.LoginDataRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/dto.LoginDataResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {}
+                    }
+                }
+            }
+        },
+        "/users/register": {
+            "post": {
+                "description": "Create a new user account",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Register a new user",
+                "parameters": [
+                    {
+                        "description": "User Data",
+                        "name": "user",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.RegisterDataRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/dto.RegisterDataResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {}
+                    }
+                }
+            }
+        }
     },
     "definitions": {
+        "dto.Attachment": {
+            "type": "object",
+            "properties": {
+                "_id": {
+                    "type": "string"
+                },
+                "content_type": {
+                    "type": "string"
+                },
+                "file_name": {
+                    "type": "string"
+                },
+                "preview": {
+                    "$ref": "#/definitions/dto.ImagePreview"
+                },
+                "size": {
+                    "type": "integer"
+                }
+            }
+        },
+        "dto.AttachmentResponse": {
+            "type": "object",
+            "properties": {
+                "_id": {
+                    "type": "string"
+                },
+                "chat_room_id": {
+                    "type": "string"
+                },
+                "content_type": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "file_name": {
+                    "type": "string"
+                },
+                "preview": {
+                    "$ref": "#/definitions/dto.ImagePreview"
+                },
+                "sha256": {
+                    "type": "string"
+                },
+                "size": {
+                    "type": "integer"
+                },
+                "uploader_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "dto.DeleteMessageResponse": {
+            "type": "object",
+            "properties": {
+                "_id": {
+                    "type": "string"
+                },
+                "chat_room_id": {
+                    "type": "string"
+                },
+                "deleted_at": {
+                    "type": "string"
+                },
+                "scope": {
+                    "type": "string"
+                }
+            }
+        },
+        "dto.DisappearingTimerResponse": {
+            "type": "object",
+            "properties": {
+                "chat_room_id": {
+                    "type": "string"
+                },
+                "disappear_after": {
+                    "type": "integer"
+                },
+                "disappear_from": {
+                    "type": "string"
+                },
+                "notice": {
+                    "$ref": "#/definitions/dto.SendMessageResponse"
+                }
+            }
+        },
+        "dto.EditMessageRequest": {
+            "type": "object",
+            "properties": {
+                "message_text": {
+                    "type": "string"
+                },
+                "sender_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "dto.EditMessageResponse": {
+            "type": "object",
+            "properties": {
+                "_id": {
+                    "type": "string"
+                },
+                "chat_room_id": {
+                    "type": "string"
+                },
+                "edited_at": {
+                    "type": "string"
+                },
+                "mentions": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "message_text": {
+                    "type": "string"
+                },
+                "sender_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "dto.EditScheduledMessageRequest": {
+            "type": "object",
+            "properties": {
+                "attachment_ids": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "message_text": {
+                    "type": "string"
+                },
+                "send_at": {
+                    "type": "string"
+                },
+                "sender_id": {
+                    "type": "string"
+                }
+            }
+        },
         "dto.FriendRequestParameter": {
             "type": "object",
             "properties": {
-                "friend_id": {
+                "friend_id": {
+                    "type": "string"
+                },
+                "user_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "dto.FriendRequestResponse": {
+            "type": "object",
+            "properties": {
+                "_id": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "receiver_id": {
+                    "type": "string"
+                },
+                "sender_id": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string"
+                },
+                "update_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "dto.GetFriendListsResponse": {
+            "type": "object",
+            "properties": {
+                "friends": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "user_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "dto.GetMessagesPageResponse": {
+            "type": "object",
+            "properties": {
+                "after": {
+                    "type": "string"
+                },
+                "before": {
+                    "type": "string"
+                },
+                "has_more_after": {
+                    "type": "boolean"
+                },
+                "has_more_before": {
+                    "type": "boolean"
+                },
+                "last_seq": {
+                    "type": "integer"
+                },
+                "messages": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dto.GetMessagesResponse"
+                    }
+                }
+            }
+        },
+        "dto.GetMessagesResponse": {
+            "type": "object",
+            "properties": {
+                "_id": {
+                    "type": "string"
+                },
+                "attachments": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dto.Attachment"
+                    }
+                },
+                "chat_room_id": {
+                    "type": "string"
+                },
+                "deleted": {
+                    "type": "boolean"
+                },
+                "deleted_at": {
+                    "type": "string"
+                },
+                "edited_at": {
+                    "type": "string"
+                },
+                "expire_after_read": {
+                    "type": "integer"
+                },
+                "expires_at": {
+                    "description": "ExpiresAt is when a disappearing message is removed, ExpireAfterRead\nthe seconds it has left once read, until it is.",
+                    "type": "string"
+                },
+                "last_reply_at": {
+                    "type": "string"
+                },
+                "mentions": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "message_text": {
+                    "type": "string"
+                },
+                "notice": {
+                    "$ref": "#/definitions/dto.RoomNotice"
+                },
+                "reactions": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dto.Reaction"
+                    }
+                },
+                "receiver_id": {
+                    "type": "string"
+                },
+                "reply_count": {
+                    "type": "integer"
+                },
+                "reply_to_message_id": {
+                    "type": "string"
+                },
+                "sender_id": {
+                    "type": "string"
+                },
+                "seq": {
+                    "description": "Seq is the message's position in its room, messages saved before\nrooms numbered them have none.",
+                    "type": "integer"
+                },
+                "thread_root_id": {
+                    "type": "string"
+                },
+                "timestamp": {
+                    "type": "string"
+                }
+            }
+        },
+        "dto.GetNotificationsResponse": {
+            "type": "object",
+            "properties": {
+                "has_more": {
+                    "type": "boolean"
+                },
+                "next_cursor": {
+                    "type": "string"
+                },
+                "notifications": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dto.NotificationResponse"
+                    }
+                }
+            }
+        },
+        "dto.GetThreadResponse": {
+            "type": "object",
+            "properties": {
+                "has_more": {
+                    "type": "boolean"
+                },
+                "replies": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dto.GetMessagesResponse"
+                    }
+                },
+                "root": {
+                    "$ref": "#/definitions/dto.GetMessagesResponse"
+                }
+            }
+        },
+        "dto.GetorCreateChatRoomResponse": {
+            "type": "object",
+            "properties": {
+                "chat_room_id": {
+                    "type": "string"
+                },
+                "disappear_after": {
+                    "type": "integer"
+                },
+                "disappear_from": {
+                    "type": "string"
+                },
+                "user_ids": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "dto.HubConnectionResponse": {
+            "type": "object",
+            "properties": {
+                "age_seconds": {
+                    "type": "integer"
+                },
+                "coalesced": {
+                    "type": "integer"
+                },
+                "connected_at": {
+                    "type": "string"
+                },
+                "connection_id": {
+                    "type": "string"
+                },
+                "dropped": {
+                    "type": "integer"
+                },
+                "protocol": {
+                    "type": "string"
+                },
+                "queue_depth": {
+                    "type": "integer"
+                },
+                "queue_high_water": {
+                    "type": "integer"
+                },
+                "remote_addr": {
+                    "type": "string"
+                },
+                "room_id": {
+                    "type": "string"
+                },
+                "transport": {
+                    "type": "string"
+                },
+                "user_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "dto.HubStateResponse": {
+            "type": "object",
+            "properties": {
+                "connections": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dto.HubConnectionResponse"
+                    }
+                },
+                "rooms": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "integer"
+                    }
+                },
+                "users": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "integer"
+                    }
+                }
+            }
+        },
+        "dto.ImagePreview": {
+            "type": "object",
+            "properties": {
+                "blurhash": {
+                    "type": "string"
+                },
+                "height": {
+                    "type": "integer"
+                },
+                "status": {
+                    "type": "string"
+                },
+                "thumbnails": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dto.Thumbnail"
+                    }
+                },
+                "width": {
+                    "type": "integer"
+                }
+            }
+        },
+        "dto.KickConnectionRequest": {
+            "type": "object",
+            "properties": {
+                "connection_id": {
+                    "type": "string"
+                },
+                "user_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "dto.KickConnectionResponse": {
+            "type": "object",
+            "properties": {
+                "kicked": {
+                    "type": "integer"
+                }
+            }
+        },
+        "dto.LoginDataRequest": {
+            "type": "object",
+            "properties": {
+                "identifier": {
+                    "type": "string"
+                },
+                "password": {
+                    "type": "string"
+                }
+            }
+        },
+        "dto.LoginDataResponse": {
+            "type": "object",
+            "properties": {
+                "_id": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "email": {
+                    "type": "string"
+                },
+                "friends": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "password": {
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "user_id": {
+                    "type": "string"
+                },
+                "username": {
+                    "type": "string"
+                }
+            }
+        },
+        "dto.MarkAllReadResponse": {
+            "type": "object",
+            "properties": {
+                "updated": {
+                    "type": "integer"
+                },
+                "user_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "dto.MarkReadRequest": {
+            "type": "object",
+            "properties": {
+                "seq": {
+                    "type": "integer"
+                },
+                "user_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "dto.MarkReadResponse": {
+            "type": "object",
+            "properties": {
+                "chat_room_id": {
+                    "type": "string"
+                },
+                "read_at": {
                     "type": "string"
                 },
+                "seq": {
+                    "type": "integer"
+                },
                 "user_id": {
                     "type": "string"
                 }
             }
         },
-        "dto.FriendRequestResponse": {
+        "dto.NotificationResponse": {
             "type": "object",
             "properties": {
                 "_id": {
                     "type": "string"
                 },
-                "created_at": {
+                "content": {
                     "type": "string"
                 },
-                "receiver_id": {
+                "notification_type": {
                     "type": "string"
                 },
-                "sender_id": {
+                "payload": {},
+                "read": {
+                    "type": "boolean"
+                },
+                "timestamp": {
                     "type": "string"
                 },
-                "status": {
+                "user_id": {
                     "type": "string"
+                }
+            }
+        },
+        "dto.Reaction": {
+            "type": "object",
+            "properties": {
+                "count": {
+                    "type": "integer"
                 },
-                "update_at": {
+                "emoji": {
                     "type": "string"
+                },
+                "user_ids": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
                 }
             }
         },
-        "dto.GetFriendListsResponse": {
+        "dto.ReactionResponse": {
             "type": "object",
             "properties": {
-                "friends": {
+                "_id": {
+                    "type": "string"
+                },
+                "chat_room_id": {
+                    "type": "string"
+                },
+                "count": {
+                    "type": "integer"
+                },
+                "emoji": {
+                    "type": "string"
+                },
+                "user_ids": {
                     "type": "array",
                     "items": {
                         "type": "string"
                     }
+                }
+            }
+        },
+        "dto.RegisterDataRequest": {
+            "type": "object",
+            "properties": {
+                "email": {
+                    "type": "string"
+                },
+                "password": {
+                    "type": "string"
                 },
                 "user_id": {
                     "type": "string"
+                },
+                "username": {
+                    "type": "string"
                 }
             }
         },
-        "dto.GetMessagesResponse": {
+        "dto.RegisterDataResponse": {
             "type": "object",
             "properties": {
                 "_id": {
                     "type": "string"
                 },
-                "chat_room_id": {
+                "created_at": {
                     "type": "string"
                 },
-                "message_text": {
+                "email": {
                     "type": "string"
                 },
-                "receiver_id": {
+                "password": {
                     "type": "string"
                 },
-                "sender_id": {
+                "user_id": {
                     "type": "string"
                 },
-                "timestamp": {
+                "username": {
                     "type": "string"
                 }
             }
         },
-        "dto.GetorCreateChatRoomResponse": {
+        "dto.Response": {
             "type": "object",
             "properties": {
-                "chat_room_id": {
+                "code": {
+                    "type": "integer"
+                },
+                "data": {},
+                "status": {
+                    "type": "string"
+                }
+            }
+        },
+        "dto.RoomNotice": {
+            "type": "object",
+            "properties": {
+                "disappear_after": {
+                    "type": "integer"
+                },
+                "disappear_from": {
                     "type": "string"
                 },
-                "user_ids": {
+                "type": {
+                    "type": "string"
+                }
+            }
+        },
+        "dto.ScheduleMessageRequest": {
+            "type": "object",
+            "properties": {
+                "attachment_ids": {
                     "type": "array",
                     "items": {
                         "type": "string"
                     }
+                },
+                "chat_room_id": {
+                    "type": "string"
+                },
+                "message_text": {
+                    "type": "string"
+                },
+                "receiver_id": {
+                    "type": "string"
+                },
+                "reply_to_message_id": {
+                    "type": "string"
+                },
+                "send_at": {
+                    "type": "string"
+                },
+                "sender_id": {
+                    "type": "string"
                 }
             }
         },
-        "dto.LoginDataRequest": {
+        "dto.ScheduledMessageResponse": {
             "type": "object",
             "properties": {
-                "identifier": {
+                "_id": {
                     "type": "string"
                 },
-                "password": {
+                "attachment_ids": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "chat_room_id": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "error": {
+                    "type": "string"
+                },
+                "message_text": {
+                    "type": "string"
+                },
+                "receiver_id": {
+                    "type": "string"
+                },
+                "reply_to_message_id": {
+                    "type": "string"
+                },
+                "send_at": {
+                    "type": "string"
+                },
+                "sender_id": {
+                    "type": "string"
+                },
+                "status": {
+                    "description": "Status is pending, sending or failed, with the reason in Error.",
+                    "type": "string"
+                },
+                "updated_at": {
                     "type": "string"
                 }
             }
         },
-        "dto.LoginDataResponse": {
+        "dto.SearchMessagesResponse": {
             "type": "object",
             "properties": {
                 "_id": {
                     "type": "string"
                 },
-                "created_at": {
+                "attachments": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dto.Attachment"
+                    }
+                },
+                "chat_room_id": {
                     "type": "string"
                 },
-                "email": {
+                "deleted": {
+                    "type": "boolean"
+                },
+                "deleted_at": {
                     "type": "string"
                 },
-                "friends": {
+                "edited_at": {
+                    "type": "string"
+                },
+                "expire_after_read": {
+                    "type": "integer"
+                },
+                "expires_at": {
+                    "description": "ExpiresAt is when a disappearing message is removed, ExpireAfterRead\nthe seconds it has left once read, until it is.",
+                    "type": "string"
+                },
+                "last_reply_at": {
+                    "type": "string"
+                },
+                "mentions": {
                     "type": "array",
                     "items": {
                         "type": "string"
                     }
                 },
-                "password": {
+                "message_text": {
                     "type": "string"
                 },
-                "updated_at": {
+                "notice": {
+                    "$ref": "#/definitions/dto.RoomNotice"
+                },
+                "reactions": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dto.Reaction"
+                    }
+                },
+                "receiver_id": {
                     "type": "string"
                 },
-                "user_id": {
+                "reply_count": {
+                    "type": "integer"
+                },
+                "reply_to_message_id": {
                     "type": "string"
                 },
-                "username": {
+                "sender_id": {
+                    "type": "string"
+                },
+                "seq": {
+                    "description": "Seq is the message's position in its room, messages saved before\nrooms numbered them have none.",
+                    "type": "integer"
+                },
+                "snippet": {
+                    "type": "string"
+                },
+                "thread_root_id": {
+                    "type": "string"
+                },
+                "timestamp": {
                     "type": "string"
                 }
             }
         },
-        "dto.RegisterDataRequest": {
+        "dto.SendMessageRequest": {
             "type": "object",
             "properties": {
-                "email": {
+                "attachment_ids": {
+                    "description": "AttachmentIDs are attachments the sender uploaded to the chat room\nbeforehand. The text may be left empty when there are some.",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "chat_room_id": {
                     "type": "string"
                 },
-                "password": {
+                "message_text": {
                     "type": "string"
                 },
-                "user_id": {
+                "receiver_id": {
                     "type": "string"
                 },
-                "username": {
+                "reply_to_message_id": {
+                    "description": "ReplyToMessageID makes the message a reply in that message's thread.",
+                    "type": "string"
+                },
+                "sender_id": {
                     "type": "string"
                 }
             }
         },
-        "dto.RegisterDataResponse": {
+        "dto.SendMessageResponse": {
             "type": "object",
             "properties": {
                 "_id": {
                     "type": "string"
                 },
-                "created_at": {
+                "attachments": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dto.Attachment"
+                    }
+                },
+                "chat_room_id": {
                     "type": "string"
                 },
-                "email": {
+                "expire_after_read": {
+                    "type": "integer"
+                },
+                "expires_at": {
                     "type": "string"
                 },
-                "password": {
+                "mentions": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "message_text": {
+                    "type": "string"
+                },
+                "notice": {
+                    "$ref": "#/definitions/dto.RoomNotice"
+                },
+                "receiver_id": {
+                    "type": "string"
+                },
+                "reply_to_message_id": {
+                    "type": "string"
+                },
+                "sender_id": {
+                    "type": "string"
+                },
+                "seq": {
+                    "type": "integer"
+                },
+                "thread_root_id": {
+                    "type": "string"
+                },
+                "timestamp": {
+                    "type": "string"
+                }
+            }
+        },
+        "dto.SetDisappearingTimerRequest": {
+            "type": "object",
+            "properties": {
+                "disappear_after": {
+                    "type": "integer"
+                },
+                "disappear_from": {
                     "type": "string"
                 },
                 "user_id": {
                     "type": "string"
+                }
+            }
+        },
+        "dto.Thumbnail": {
+            "type": "object",
+            "properties": {
+                "content_type": {
+                    "type": "string"
                 },
-                "username": {
+                "height": {
+                    "type": "integer"
+                },
+                "size": {
+                    "type": "integer"
+                },
+                "width": {
+                    "type": "integer"
+                }
+            }
+        },
+        "dto.UnreadCountResponse": {
+            "type": "object",
+            "properties": {
+                "unread": {
+                    "type": "integer"
+                },
+                "user_id": {
                     "type": "string"
                 }
             }
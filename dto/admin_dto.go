@@ -0,0 +1,33 @@
+package dto
+
+import "time"
+
+type HubConnectionResponse struct {
+	ConnectionID   string    `json:"connection_id"`
+	UserID         string    `json:"user_id"`
+	RoomID         string    `json:"room_id"`
+	RemoteAddr     string    `json:"remote_addr"`
+	Transport      string    `json:"transport"`
+	Protocol       string    `json:"protocol"`
+	ConnectedAt    time.Time `json:"connected_at"`
+	AgeSeconds     int64     `json:"age_seconds"`
+	QueueDepth     int       `json:"queue_depth"`
+	QueueHighWater int       `json:"queue_high_water"`
+	Dropped        uint64    `json:"dropped"`
+	Coalesced      uint64    `json:"coalesced"`
+}
+
+type HubStateResponse struct {
+	Connections []HubConnectionResponse `json:"connections"`
+	Rooms       map[string]int          `json:"rooms"`
+	Users       map[string]int          `json:"users"`
+}
+
+type KickConnectionRequest struct {
+	ConnectionID string `json:"connection_id"`
+	UserID       string `json:"user_id"`
+}
+
+type KickConnectionResponse struct {
+	Kicked int `json:"kicked"`
+}
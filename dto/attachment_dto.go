@@ -0,0 +1,62 @@
+package dto
+
+import (
+	"io"
+	"time"
+)
+
+type UploadAttachmentRequest struct {
+	ChatRoomID string    `json:"chat_room_id"`
+	UploaderID string    `json:"uploader_id"`
+	FileName   string    `json:"file_name"`
+	Content    io.Reader `json:"-"`
+}
+
+type DownloadAttachmentRequest struct {
+	AttachmentID string `json:"attachment_id"`
+	UserID       string `json:"user_id"`
+	// ThumbnailSize asks for the image's thumbnail of that size instead of
+	// the image itself.
+	ThumbnailSize int `json:"thumbnail_size"`
+}
+
+type AttachmentResponse struct {
+	AttachmentID string        `json:"_id"`
+	ChatRoomID   string        `json:"chat_room_id"`
+	UploaderID   string        `json:"uploader_id"`
+	FileName     string        `json:"file_name"`
+	ContentType  string        `json:"content_type"`
+	Size         int64         `json:"size"`
+	SHA256       string        `json:"sha256"`
+	CreatedAt    time.Time     `json:"created_at"`
+	Preview      *ImagePreview `json:"preview,omitempty"`
+}
+
+// Attachment is an attachment as listed on the messages referencing it, its
+// content is downloaded from /attachments/{_id}.
+type Attachment struct {
+	AttachmentID string        `json:"_id"`
+	FileName     string        `json:"file_name"`
+	ContentType  string        `json:"content_type"`
+	Size         int64         `json:"size"`
+	Preview      *ImagePreview `json:"preview,omitempty"`
+}
+
+// ImagePreview lets clients lay out an image and show a placeholder before
+// downloading it. Status is "pending" until the placeholder and thumbnails
+// are ready, an attachment_preview event announces them.
+type ImagePreview struct {
+	Status     string      `json:"status"`
+	Width      int         `json:"width"`
+	Height     int         `json:"height"`
+	BlurHash   string      `json:"blurhash,omitempty"`
+	Thumbnails []Thumbnail `json:"thumbnails,omitempty"`
+}
+
+// Thumbnail is downloaded from /attachments/{_id}?thumbnail_size={size}.
+type Thumbnail struct {
+	Size        int    `json:"size"`
+	Width       int    `json:"width"`
+	Height      int    `json:"height"`
+	ContentType string `json:"content_type"`
+}
@@ -4,8 +4,23 @@ import "time"
 
 type GetMessagesRequest struct {
 	RoomID string `json:"chat_room_id"`
-	Limit  int64    `json:"limit"`
-	Offset int64    `json:"offset"`
+	Limit  int64  `json:"limit"`
+	// Before and After are cursors of a previous page, Around the ID of a
+	// message to center the page on. At most one is set, without any the
+	// page holds the newest messages.
+	Before string `json:"before"`
+	After  string `json:"after"`
+	Around string `json:"around"`
+	// FromSeq and ToSeq ask for the messages numbered in that range, both
+	// included, instead of a page of cursors. Clients use it to fill the gaps
+	// they find in the sequence numbers.
+	FromSeq int64 `json:"from_seq"`
+	ToSeq   int64 `json:"to_seq"`
+	// ViewerID hides the messages this user deleted for themselves.
+	ViewerID string `json:"viewer_id"`
+	// CollapseThreads leaves thread replies out, their roots carry the
+	// reply count instead.
+	CollapseThreads bool `json:"collapse_threads"`
 }
 
 type GetMessagesResponse struct {
@@ -15,9 +30,211 @@ type GetMessagesResponse struct {
 	MessageText string    `json:"message_text"`
 	Timestamp   time.Time `json:"timestamp"`
 	ChatRoomID  string    `json:"chat_room_id"`
+	// Seq is the message's position in its room, messages saved before
+	// rooms numbered them have none.
+	Seq       int64      `json:"seq,omitempty"`
+	EditedAt  *time.Time `json:"edited_at,omitempty"`
+	Deleted   bool       `json:"deleted,omitempty"`
+	DeletedAt *time.Time `json:"deleted_at,omitempty"`
+	Reactions []Reaction `json:"reactions,omitempty"`
+
+	ReplyToMessageID string       `json:"reply_to_message_id,omitempty"`
+	ThreadRootID     string       `json:"thread_root_id,omitempty"`
+	ReplyCount       int          `json:"reply_count,omitempty"`
+	LastReplyAt      *time.Time   `json:"last_reply_at,omitempty"`
+	Mentions         []string     `json:"mentions,omitempty"`
+	Attachments      []Attachment `json:"attachments,omitempty"`
+	// ExpiresAt is when a disappearing message is removed, ExpireAfterRead
+	// the seconds it has left once read, until it is.
+	ExpiresAt       *time.Time  `json:"expires_at,omitempty"`
+	ExpireAfterRead int64       `json:"expire_after_read,omitempty"`
+	Notice          *RoomNotice `json:"notice,omitempty"`
+}
+
+// RoomNotice marks the messages the server posts when a room's settings
+// change, clients may render them from it rather than from the text.
+type RoomNotice struct {
+	Type           string `json:"type"`
+	DisappearAfter int64  `json:"disappear_after,omitempty"`
+	DisappearFrom  string `json:"disappear_from,omitempty"`
+}
+
+// GetMessagesPageResponse is a page of a chat room's history, newest first.
+// Before and After are the cursors of the pages of older and newer messages.
+// LastSeq is the sequence number of the room's latest message, so clients can
+// tell what they missed.
+type GetMessagesPageResponse struct {
+	Messages      []GetMessagesResponse `json:"messages"`
+	Before        string                `json:"before,omitempty"`
+	After         string                `json:"after,omitempty"`
+	HasMoreBefore bool                  `json:"has_more_before"`
+	HasMoreAfter  bool                  `json:"has_more_after"`
+	LastSeq       int64                 `json:"last_seq"`
+}
+
+// Reaction aggregates the reactions to a message with one emoji.
+type Reaction struct {
+	Emoji   string   `json:"emoji"`
+	Count   int      `json:"count"`
+	UserIDs []string `json:"user_ids"`
+}
+
+type SendMessageRequest struct {
+	RoomID      string `json:"chat_room_id"`
+	SenderID    string `json:"sender_id"`
+	ReceiverID  string `json:"receiver_id"`
+	MessageText string `json:"message_text"`
+	// ReplyToMessageID makes the message a reply in that message's thread.
+	ReplyToMessageID string `json:"reply_to_message_id,omitempty"`
+	// AttachmentIDs are attachments the sender uploaded to the chat room
+	// beforehand. The text may be left empty when there are some.
+	AttachmentIDs []string `json:"attachment_ids,omitempty"`
+	// MessageID is set by the scheduler, so a scheduled message sent again
+	// keeps its ID and can't be stored twice.
+	MessageID string `json:"-"`
+}
+
+type SendMessageResponse struct {
+	MessageID        string       `json:"_id"`
+	SenderID         string       `json:"sender_id"`
+	ReceiverID       string       `json:"receiver_id"`
+	MessageText      string       `json:"message_text"`
+	Timestamp        time.Time    `json:"timestamp"`
+	ChatRoomID       string       `json:"chat_room_id"`
+	Seq              int64        `json:"seq"`
+	ReplyToMessageID string       `json:"reply_to_message_id,omitempty"`
+	ThreadRootID     string       `json:"thread_root_id,omitempty"`
+	Mentions         []string     `json:"mentions,omitempty"`
+	Attachments      []Attachment `json:"attachments,omitempty"`
+	ExpiresAt        *time.Time   `json:"expires_at,omitempty"`
+	ExpireAfterRead  int64        `json:"expire_after_read,omitempty"`
+	Notice           *RoomNotice  `json:"notice,omitempty"`
+}
+
+type EditMessageRequest struct {
+	MessageID   string `json:"-"`
+	SenderID    string `json:"sender_id"`
+	MessageText string `json:"message_text"`
+}
+
+type EditMessageResponse struct {
+	MessageID   string    `json:"_id"`
+	SenderID    string    `json:"sender_id"`
+	MessageText string    `json:"message_text"`
+	ChatRoomID  string    `json:"chat_room_id"`
+	Mentions    []string  `json:"mentions,omitempty"`
+	EditedAt    time.Time `json:"edited_at"`
+}
+
+type DeleteMessageRequest struct {
+	MessageID string `json:"-"`
+	UserID    string `json:"user_id"`
+	// Scope is "me" to hide the message for UserID only or "everyone" to
+	// replace it with a tombstone.
+	Scope string `json:"scope"`
+}
+
+type DeleteMessageResponse struct {
+	MessageID  string    `json:"_id"`
+	ChatRoomID string    `json:"chat_room_id"`
+	Scope      string    `json:"scope"`
+	DeletedAt  time.Time `json:"deleted_at"`
+}
+
+type ReactionRequest struct {
+	MessageID string `json:"message_id"`
+	UserID    string `json:"user_id"`
+	Emoji     string `json:"emoji"`
+}
+
+type ReactionResponse struct {
+	MessageID  string `json:"_id"`
+	ChatRoomID string `json:"chat_room_id"`
+	Reaction
+}
+
+type GetThreadRequest struct {
+	MessageID string `json:"message_id"`
+	ViewerID  string `json:"viewer_id"`
+	Limit     int64  `json:"limit"`
+	Offset    int64  `json:"offset"`
+}
+
+// GetThreadResponse is the thread's first message and a page of its replies,
+// oldest first.
+type GetThreadResponse struct {
+	Root    GetMessagesResponse   `json:"root"`
+	Replies []GetMessagesResponse `json:"replies"`
+	HasMore bool                  `json:"has_more"`
+}
+
+type GetMentionsRequest struct {
+	UserID string `json:"user_id"`
+	Limit  int64  `json:"limit"`
+	Offset int64  `json:"offset"`
+}
+
+type SearchMessagesRequest struct {
+	UserID     string    `json:"user_id"`
+	Query      string    `json:"q"`
+	SenderID   string    `json:"sender_id"`
+	ChatRoomID string    `json:"chat_room_id"`
+	From       time.Time `json:"from"`
+	To         time.Time `json:"to"`
+	Limit      int64     `json:"limit"`
+	Offset     int64     `json:"offset"`
+}
+
+// SearchMessagesResponse is a message matching a search. Snippet is an HTML
+// escaped excerpt of its text with the matching words in <mark> tags.
+type SearchMessagesResponse struct {
+	GetMessagesResponse
+	Snippet string `json:"snippet,omitempty"`
 }
 
 type GetorCreateChatRoomResponse struct {
-	ChatRoomID string   `json:"chat_room_id"`
-	UserIDs    []string `json:"user_ids"`
+	ChatRoomID     string   `json:"chat_room_id"`
+	UserIDs        []string `json:"user_ids"`
+	DisappearAfter int64    `json:"disappear_after,omitempty"`
+	DisappearFrom  string   `json:"disappear_from,omitempty"`
+}
+
+// SetDisappearingTimerRequest makes the room's new messages expire
+// DisappearAfter seconds after they're sent, or read when DisappearFrom is
+// "read". Zero turns the timer off.
+type SetDisappearingTimerRequest struct {
+	RoomID         string `json:"-"`
+	UserID         string `json:"user_id"`
+	DisappearAfter int64  `json:"disappear_after"`
+	DisappearFrom  string `json:"disappear_from"`
+}
+
+// DisappearingTimerResponse is the room's timer and the notice posted about
+// the change, none when the timer was already set so.
+type DisappearingTimerResponse struct {
+	ChatRoomID     string               `json:"chat_room_id"`
+	DisappearAfter int64                `json:"disappear_after"`
+	DisappearFrom  string               `json:"disappear_from,omitempty"`
+	Notice         *SendMessageResponse `json:"notice,omitempty"`
+}
+
+// MarkReadRequest tells that UserID read the room's messages up to Seq,
+// which starts the timers of those disappearing once read.
+type MarkReadRequest struct {
+	RoomID string `json:"-"`
+	UserID string `json:"user_id"`
+	Seq    int64  `json:"seq"`
+}
+
+// TypingRequest tells a room UserID is typing, it's relayed but not stored.
+type TypingRequest struct {
+	RoomID string
+	UserID string
+}
+
+type MarkReadResponse struct {
+	ChatRoomID string    `json:"chat_room_id"`
+	UserID     string    `json:"user_id"`
+	Seq        int64     `json:"seq"`
+	ReadAt     time.Time `json:"read_at"`
 }
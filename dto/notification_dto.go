@@ -0,0 +1,64 @@
+package dto
+
+import "time"
+
+type NotificationResponse struct {
+	NotificationID   string      `json:"_id"`
+	UserID           string      `json:"user_id"`
+	NotificationType string      `json:"notification_type"`
+	Content          string      `json:"content"`
+	Payload          interface{} `json:"payload"`
+	Timestamp        time.Time   `json:"timestamp"`
+	Read             bool        `json:"read"`
+}
+
+type FriendRequestPayload struct {
+	RequestID string `json:"request_id"`
+	SenderID  string `json:"sender_id"`
+}
+
+type FriendRequestResponsePayload struct {
+	RequestID   string `json:"request_id"`
+	ResponderID string `json:"responder_id"`
+}
+
+type MentionPayload struct {
+	MessageID  string `json:"message_id"`
+	ChatRoomID string `json:"chat_room_id"`
+	SenderID   string `json:"sender_id"`
+	Excerpt    string `json:"excerpt"`
+}
+
+type RoomInvitePayload struct {
+	ChatRoomID string `json:"chat_room_id"`
+	InviterID  string `json:"inviter_id"`
+}
+
+type ScheduledFailedPayload struct {
+	ScheduledMessageID string `json:"scheduled_message_id"`
+	ChatRoomID         string `json:"chat_room_id"`
+	Reason             string `json:"reason"`
+}
+
+type GetNotificationsRequest struct {
+	UserID     string `json:"user_id"`
+	Cursor     string `json:"cursor"`
+	Limit      int64  `json:"limit"`
+	UnreadOnly bool   `json:"unread_only"`
+}
+
+type GetNotificationsResponse struct {
+	Notifications []NotificationResponse `json:"notifications"`
+	NextCursor    string                 `json:"next_cursor"`
+	HasMore       bool                   `json:"has_more"`
+}
+
+type UnreadCountResponse struct {
+	UserID string `json:"user_id"`
+	Unread int64  `json:"unread"`
+}
+
+type MarkAllReadResponse struct {
+	UserID  string `json:"user_id"`
+	Updated int64  `json:"updated"`
+}
@@ -0,0 +1,50 @@
+package dto
+
+import "time"
+
+type ScheduleMessageRequest struct {
+	RoomID           string    `json:"chat_room_id"`
+	SenderID         string    `json:"sender_id"`
+	ReceiverID       string    `json:"receiver_id"`
+	MessageText      string    `json:"message_text"`
+	ReplyToMessageID string    `json:"reply_to_message_id,omitempty"`
+	AttachmentIDs    []string  `json:"attachment_ids,omitempty"`
+	SendAt           time.Time `json:"send_at"`
+}
+
+type GetScheduledMessagesRequest struct {
+	UserID string `json:"user_id"`
+	// RoomID narrows the list down to one chat room.
+	RoomID string `json:"chat_room_id"`
+}
+
+// EditScheduledMessageRequest changes the fields that are set, the others
+// are kept. Editing a failed message schedules it again.
+type EditScheduledMessageRequest struct {
+	ScheduledMessageID string     `json:"-"`
+	SenderID           string     `json:"sender_id"`
+	MessageText        *string    `json:"message_text,omitempty"`
+	AttachmentIDs      []string   `json:"attachment_ids,omitempty"`
+	SendAt             *time.Time `json:"send_at,omitempty"`
+}
+
+type CancelScheduledMessageRequest struct {
+	ScheduledMessageID string `json:"-"`
+	UserID             string `json:"user_id"`
+}
+
+type ScheduledMessageResponse struct {
+	ScheduledMessageID string    `json:"_id"`
+	ChatRoomID         string    `json:"chat_room_id"`
+	SenderID           string    `json:"sender_id"`
+	ReceiverID         string    `json:"receiver_id"`
+	MessageText        string    `json:"message_text"`
+	ReplyToMessageID   string    `json:"reply_to_message_id,omitempty"`
+	AttachmentIDs      []string  `json:"attachment_ids,omitempty"`
+	SendAt             time.Time `json:"send_at"`
+	// Status is pending, sending or failed, with the reason in Error.
+	Status    string    `json:"status"`
+	Error     string    `json:"error,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
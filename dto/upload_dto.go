@@ -0,0 +1,36 @@
+package dto
+
+import (
+	"io"
+	"time"
+)
+
+type CreateUploadRequest struct {
+	ChatRoomID string `json:"chat_room_id"`
+	UploaderID string `json:"uploader_id"`
+	FileName   string `json:"file_name"`
+	Length     int64  `json:"length"`
+}
+
+type UploadRequest struct {
+	UploadID string `json:"upload_id"`
+	UserID   string `json:"user_id"`
+}
+
+type PatchUploadRequest struct {
+	UploadID string    `json:"upload_id"`
+	UserID   string    `json:"user_id"`
+	Offset   int64     `json:"offset"`
+	Content  io.Reader `json:"-"`
+}
+
+type UploadResponse struct {
+	UploadID     string    `json:"_id"`
+	ChatRoomID   string    `json:"chat_room_id"`
+	UploaderID   string    `json:"uploader_id"`
+	FileName     string    `json:"file_name"`
+	Length       int64     `json:"length"`
+	Offset       int64     `json:"offset"`
+	AttachmentID string    `json:"attachment_id,omitempty"`
+	ExpiresAt    time.Time `json:"expires_at"`
+}
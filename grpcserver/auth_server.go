@@ -0,0 +1,64 @@
+package grpcserver
+
+import (
+	"context"
+	"go-chat/dto"
+	"go-chat/pkg/pb"
+	"go-chat/service"
+	"log"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+type AuthServerImpl struct {
+	pb.UnimplementedAuthServiceServer
+	authService service.AuthService
+}
+
+func NewAuthServer(authService service.AuthService) pb.AuthServiceServer {
+	return &AuthServerImpl{authService: authService}
+}
+
+func (a *AuthServerImpl) Register(ctx context.Context, req *pb.RegisterRequest) (*pb.User, error) {
+	data, err := a.authService.RegisterUser(ctx, dto.RegisterDataRequest{
+		UserID:   req.GetUserId(),
+		Username: req.GetUsername(),
+		Email:    req.GetEmail(),
+		Password: req.GetPassword(),
+	})
+	if err != nil {
+		log.Println(err)
+		return nil, status.Error(codes.InvalidArgument, "Failed to register new user")
+	}
+
+	return &pb.User{
+		Id:        data.ID,
+		UserId:    data.UserID,
+		Username:  data.Username,
+		Email:     data.Email,
+		CreatedAt: timestamppb.New(data.CreatedAt),
+	}, nil
+}
+
+func (a *AuthServerImpl) Login(ctx context.Context, req *pb.LoginRequest) (*pb.User, error) {
+	data, err := a.authService.CheckLogin(ctx, dto.LoginDataRequest{
+		Identifier: req.GetIdentifier(),
+		Password:   req.GetPassword(),
+	})
+	if err != nil {
+		log.Println(err)
+		return nil, status.Error(codes.Unauthenticated, "Failed to verify login, try again later!")
+	}
+
+	return &pb.User{
+		Id:        data.ID,
+		UserId:    data.UserID,
+		Username:  data.Username,
+		Email:     data.Email,
+		CreatedAt: timestamppb.New(data.CreatedAt),
+		UpdatedAt: timestamppb.New(data.UpdatedAt),
+		Friends:   data.Friends,
+	}, nil
+}
@@ -0,0 +1,373 @@
+package grpcserver
+
+import (
+	"context"
+	"encoding/json"
+	"go-chat/dto"
+	"go-chat/pkg/pb"
+	"go-chat/pkg/websocket"
+	"go-chat/service"
+	"io"
+	"log"
+	"sync"
+	"time"
+
+	gorilla "github.com/gorilla/websocket"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+type ChatServerImpl struct {
+	pb.UnimplementedChatServiceServer
+	chatService service.ChatService
+	hub         *websocket.Hub
+}
+
+func NewChatServer(chatService service.ChatService, hub *websocket.Hub) pb.ChatServiceServer {
+	return &ChatServerImpl{
+		chatService: chatService,
+		hub:         hub,
+	}
+}
+
+func (c *ChatServerImpl) GetMessages(ctx context.Context, req *pb.GetMessagesRequest) (*pb.GetMessagesResponse, error) {
+	data, err := c.chatService.GetMessages(ctx, dto.GetMessagesRequest{
+		RoomID:          req.GetChatRoomId(),
+		Limit:           req.GetLimit(),
+		Before:          req.GetBefore(),
+		After:           req.GetAfter(),
+		Around:          req.GetAround(),
+		FromSeq:         req.GetFromSeq(),
+		ToSeq:           req.GetToSeq(),
+		ViewerID:        req.GetViewerId(),
+		CollapseThreads: req.GetCollapseThreads(),
+	})
+	if err != nil {
+		log.Println(err)
+		return nil, status.Error(codes.InvalidArgument, "Failed to retrieve messages")
+	}
+
+	resp := &pb.GetMessagesResponse{
+		Before:        data.Before,
+		After:         data.After,
+		HasMoreBefore: data.HasMoreBefore,
+		HasMoreAfter:  data.HasMoreAfter,
+		LastSeq:       data.LastSeq,
+	}
+	for _, message := range data.Messages {
+		item := &pb.Message{
+			Id:               message.MessageID,
+			SenderId:         message.SenderID,
+			ReceiverId:       message.ReceiverID,
+			MessageText:      message.MessageText,
+			Timestamp:        timestamppb.New(message.Timestamp),
+			ChatRoomId:       message.ChatRoomID,
+			Seq:              message.Seq,
+			Deleted:          message.Deleted,
+			ReplyToMessageId: message.ReplyToMessageID,
+			ThreadRootId:     message.ThreadRootID,
+			ReplyCount:       int32(message.ReplyCount),
+			Mentions:         message.Mentions,
+			Attachments:      toAttachments(message.Attachments),
+			ExpireAfterRead:  message.ExpireAfterRead,
+			Notice:           toRoomNotice(message.Notice),
+		}
+		if message.EditedAt != nil {
+			item.EditedAt = timestamppb.New(*message.EditedAt)
+		}
+		if message.LastReplyAt != nil {
+			item.LastReplyAt = timestamppb.New(*message.LastReplyAt)
+		}
+		if message.ExpiresAt != nil {
+			item.ExpiresAt = timestamppb.New(*message.ExpiresAt)
+		}
+		for _, reaction := range message.Reactions {
+			item.Reactions = append(item.Reactions, &pb.Reaction{
+				Emoji:   reaction.Emoji,
+				Count:   int32(reaction.Count),
+				UserIds: reaction.UserIDs,
+			})
+		}
+		resp.Messages = append(resp.Messages, item)
+	}
+	return resp, nil
+}
+
+func (c *ChatServerImpl) GetOrCreateChatRoom(ctx context.Context, req *pb.GetOrCreateChatRoomRequest) (*pb.ChatRoom, error) {
+	data, err := c.chatService.GetorCreateChatRoom(ctx, req.GetUserId(), req.GetFriendId())
+	if err != nil {
+		log.Println(err)
+		return nil, status.Error(codes.InvalidArgument, "Failed to get or create chat room")
+	}
+
+	return &pb.ChatRoom{
+		ChatRoomId:     data.ChatRoomID,
+		UserIds:        data.UserIDs,
+		DisappearAfter: data.DisappearAfter,
+		DisappearFrom:  data.DisappearFrom,
+	}, nil
+}
+
+func (c *ChatServerImpl) SendMessage(ctx context.Context, req *pb.SendMessageRequest) (*pb.Message, error) {
+	data, err := c.chatService.SendMessage(ctx, dto.SendMessageRequest{
+		RoomID:           req.GetChatRoomId(),
+		SenderID:         req.GetSenderId(),
+		ReceiverID:       req.GetReceiverId(),
+		MessageText:      req.GetMessageText(),
+		ReplyToMessageID: req.GetReplyToMessageId(),
+		AttachmentIDs:    req.GetAttachmentIds(),
+	})
+	if err != nil {
+		log.Println(err)
+		return nil, status.Error(codes.InvalidArgument, "Failed to send message")
+	}
+
+	resp := &pb.Message{
+		Id:               data.MessageID,
+		SenderId:         data.SenderID,
+		ReceiverId:       data.ReceiverID,
+		MessageText:      data.MessageText,
+		Timestamp:        timestamppb.New(data.Timestamp),
+		ChatRoomId:       data.ChatRoomID,
+		Seq:              data.Seq,
+		ReplyToMessageId: data.ReplyToMessageID,
+		ThreadRootId:     data.ThreadRootID,
+		Mentions:         data.Mentions,
+		Attachments:      toAttachments(data.Attachments),
+		ExpireAfterRead:  data.ExpireAfterRead,
+		Notice:           toRoomNotice(data.Notice),
+	}
+	if data.ExpiresAt != nil {
+		resp.ExpiresAt = timestamppb.New(*data.ExpiresAt)
+	}
+	return resp, nil
+}
+
+// Chat subscribes the caller to its room on the Hub, the same way /ws does,
+// and relays room events until either side ends the stream.
+func (c *ChatServerImpl) Chat(stream pb.ChatService_ChatServer) error {
+	ctx := stream.Context()
+
+	md, _ := metadata.FromIncomingContext(ctx)
+	userID := firstValue(md, "user-id")
+	roomID := firstValue(md, "room-id")
+	receiverID := firstValue(md, "receiver-id")
+
+	if userID == "" || roomID == "" || receiverID == "" {
+		return status.Error(codes.InvalidArgument, "Missing required metadata")
+	}
+
+	info := websocket.ConnectionInfo{
+		ID:          primitive.NewObjectID().Hex(),
+		UserID:      userID,
+		RoomID:      roomID,
+		Transport:   "grpc",
+		Protocol:    websocket.ProtocolJSON,
+		ConnectedAt: time.Now(),
+	}
+	if p, ok := peer.FromContext(ctx); ok {
+		info.RemoteAddr = p.Addr.String()
+	}
+
+	hubStream, err := c.hub.OpenStream(info, firstValue(md, "last-event-id"))
+	if err != nil {
+		return status.Error(codes.Unavailable, "Server shutting down")
+	}
+	defer hubStream.Detach()
+
+	// replies from the receive loop and room events share the stream, and
+	// grpc doesn't allow concurrent sends
+	var mu sync.Mutex
+	send := func(frame *pb.ChatServerFrame) error {
+		mu.Lock()
+		defer mu.Unlock()
+		return stream.Send(frame)
+	}
+
+	received := make(chan error, 1)
+	go func() {
+		received <- c.receive(stream, hubStream, info, receiverID, send)
+	}()
+
+	for {
+		select {
+		case <-hubStream.Ready():
+			events, gap, closed := hubStream.Drain()
+
+			if gap > 0 {
+				marker, _ := json.Marshal(hubStream.ResyncMarker(gap))
+				if err := send(eventFrame(websocket.RoomEvent{RoomID: roomID, Data: marker})); err != nil {
+					return err
+				}
+			}
+
+			for _, event := range events {
+				if err := send(eventFrame(event)); err != nil {
+					return err
+				}
+			}
+
+			if closed {
+				code, reason := hubStream.CloseStatus()
+				if code == gorilla.CloseGoingAway {
+					return status.Error(codes.Unavailable, reason)
+				}
+				return status.Error(codes.Aborted, reason)
+			}
+		case err := <-received:
+			if err == io.EOF {
+				return nil
+			}
+			return err
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// receive handles the caller's frames until it closes its side of the stream.
+// Actions go through the same rate limits as those of websocket clients.
+func (c *ChatServerImpl) receive(stream pb.ChatService_ChatServer, hubStream *websocket.Stream, info websocket.ConnectionInfo, receiverID string, send func(*pb.ChatServerFrame) error) error {
+	ctx := stream.Context()
+
+	for {
+		frame, err := stream.Recv()
+		if err != nil {
+			return err
+		}
+
+		action := frameAction(frame)
+		if ok, retryAfter, exceeded := hubStream.Allow(action); !ok {
+			if exceeded {
+				log.Printf("Disconnecting %s from room %s: rate limit exceeded", info.UserID, info.RoomID)
+				return status.Error(codes.ResourceExhausted, "rate limit exceeded")
+			}
+
+			limited := errorFrame(action, "rate_limited")
+			limited.GetError().RetryAfterMs = retryAfter.Milliseconds()
+			if err := send(limited); err != nil {
+				return err
+			}
+			continue
+		}
+
+		switch f := frame.GetFrame().(type) {
+		case *pb.ChatClientFrame_SendMessage:
+			_, err = c.chatService.SendMessage(ctx, dto.SendMessageRequest{
+				RoomID:      info.RoomID,
+				SenderID:    info.UserID,
+				ReceiverID:  receiverID,
+				MessageText: f.SendMessage,
+			})
+			if err != nil {
+				log.Println("Failed to send message: ", err)
+				err = send(errorFrame("send_message", "failed to send message"))
+			}
+		case *pb.ChatClientFrame_GetMessages:
+			req := f.GetMessages
+			if req.GetChatRoomId() == "" {
+				req.ChatRoomId = info.RoomID
+			}
+			req.ViewerId = info.UserID
+
+			var messages *pb.GetMessagesResponse
+			messages, err = c.GetMessages(ctx, req)
+			if err != nil {
+				err = send(errorFrame("get_messages", "failed to retrieve messages"))
+			} else {
+				err = send(&pb.ChatServerFrame{Frame: &pb.ChatServerFrame_Messages{Messages: messages}})
+			}
+		default:
+			err = send(errorFrame("", "unknown frame"))
+		}
+
+		if err != nil {
+			return err
+		}
+	}
+}
+
+func toRoomNotice(notice *dto.RoomNotice) *pb.RoomNotice {
+	if notice == nil {
+		return nil
+	}
+	return &pb.RoomNotice{
+		Type:           notice.Type,
+		DisappearAfter: notice.DisappearAfter,
+		DisappearFrom:  notice.DisappearFrom,
+	}
+}
+
+func toAttachments(attachments []dto.Attachment) []*pb.Attachment {
+	var resp []*pb.Attachment
+	for _, attachment := range attachments {
+		resp = append(resp, &pb.Attachment{
+			Id:          attachment.AttachmentID,
+			FileName:    attachment.FileName,
+			ContentType: attachment.ContentType,
+			Size:        attachment.Size,
+			Preview:     toImagePreview(attachment.Preview),
+		})
+	}
+	return resp
+}
+
+func toImagePreview(preview *dto.ImagePreview) *pb.ImagePreview {
+	if preview == nil {
+		return nil
+	}
+
+	resp := &pb.ImagePreview{
+		Status:   preview.Status,
+		Width:    int32(preview.Width),
+		Height:   int32(preview.Height),
+		Blurhash: preview.BlurHash,
+	}
+	for _, thumbnail := range preview.Thumbnails {
+		resp.Thumbnails = append(resp.Thumbnails, &pb.Thumbnail{
+			Size:        int32(thumbnail.Size),
+			Width:       int32(thumbnail.Width),
+			Height:      int32(thumbnail.Height),
+			ContentType: thumbnail.ContentType,
+		})
+	}
+	return resp
+}
+
+func eventFrame(event websocket.RoomEvent) *pb.ChatServerFrame {
+	return &pb.ChatServerFrame{Frame: &pb.ChatServerFrame_Event{Event: &pb.RoomEvent{
+		Id:     event.ID,
+		RoomId: event.RoomID,
+		Data:   event.Data,
+	}}}
+}
+
+// frameAction is the name of the websocket action a client frame stands for.
+func frameAction(frame *pb.ChatClientFrame) string {
+	switch frame.GetFrame().(type) {
+	case *pb.ChatClientFrame_SendMessage:
+		return "send_message"
+	case *pb.ChatClientFrame_GetMessages:
+		return "get_messages"
+	default:
+		return ""
+	}
+}
+
+func errorFrame(action string, reason string) *pb.ChatServerFrame {
+	return &pb.ChatServerFrame{Frame: &pb.ChatServerFrame_Error{Error: &pb.Error{
+		Action: action,
+		Error:  reason,
+	}}}
+}
+
+func firstValue(md metadata.MD, key string) string {
+	if values := md.Get(key); len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
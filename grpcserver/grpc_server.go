@@ -0,0 +1,50 @@
+package grpcserver
+
+import (
+	"context"
+	"crypto/subtle"
+	"go-chat/pkg/pb"
+	"log"
+	"strings"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// NewServer registers the services on a grpc server that only accepts calls
+// carrying token as a bearer token. Every call is refused while token is empty.
+func NewServer(token string, auth pb.AuthServiceServer, chat pb.ChatServiceServer, user pb.UserServiceServer) *grpc.Server {
+	server := grpc.NewServer(
+		grpc.UnaryInterceptor(func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+			if err := authorize(ctx, token, info.FullMethod); err != nil {
+				return nil, err
+			}
+			return handler(ctx, req)
+		}),
+		grpc.StreamInterceptor(func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+			if err := authorize(ss.Context(), token, info.FullMethod); err != nil {
+				return err
+			}
+			return handler(srv, ss)
+		}),
+	)
+
+	pb.RegisterAuthServiceServer(server, auth)
+	pb.RegisterChatServiceServer(server, chat)
+	pb.RegisterUserServiceServer(server, user)
+
+	return server
+}
+
+func authorize(ctx context.Context, token string, method string) error {
+	md, _ := metadata.FromIncomingContext(ctx)
+	given := strings.TrimPrefix(firstValue(md, "authorization"), "Bearer ")
+
+	if token == "" || subtle.ConstantTimeCompare([]byte(token), []byte(given)) != 1 {
+		log.Printf("Rejected grpc call to %s", method)
+		return status.Error(codes.Unauthenticated, "Unauthorized")
+	}
+	return nil
+}
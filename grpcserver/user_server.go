@@ -0,0 +1,95 @@
+package grpcserver
+
+import (
+	"context"
+	"go-chat/dto"
+	"go-chat/pkg/pb"
+	"go-chat/service"
+	"log"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+type UserServerImpl struct {
+	pb.UnimplementedUserServiceServer
+	userService service.UserService
+}
+
+func NewUserServer(userService service.UserService) pb.UserServiceServer {
+	return &UserServerImpl{userService: userService}
+}
+
+func (u *UserServerImpl) AddFriend(ctx context.Context, req *pb.AddFriendRequest) (*pb.FriendRequest, error) {
+	data, err := u.userService.AddFriend(ctx, dto.FriendRequestParameter{
+		UserID:   req.GetUserId(),
+		FriendID: req.GetFriendId(),
+	})
+	if err != nil {
+		log.Println(err)
+		return nil, status.Error(codes.InvalidArgument, "Failed to add new friend")
+	}
+
+	return toFriendRequest(data), nil
+}
+
+func (u *UserServerImpl) RespondFriendRequest(ctx context.Context, req *pb.RespondFriendRequestRequest) (*pb.FriendRequest, error) {
+	data, err := u.userService.UpdateFriendRequest(ctx, dto.UpdateRequestParameter{
+		RequestID:  req.GetRequestId(),
+		SenderID:   req.GetSenderId(),
+		ReceiverID: req.GetReceiverId(),
+		Acceptance: req.GetAcceptance(),
+	})
+	if err != nil {
+		log.Println(err)
+		return nil, status.Error(codes.InvalidArgument, "Failed to update friend request")
+	}
+
+	return &pb.FriendRequest{
+		RequestId:  data.RequestID,
+		SenderId:   data.SenderID,
+		ReceiverId: data.ReceiverID,
+		Status:     data.Status,
+		CreatedAt:  timestamppb.New(data.CreatedAt),
+		UpdatedAt:  timestamppb.New(data.UpdatedAt),
+	}, nil
+}
+
+func (u *UserServerImpl) GetFriendLists(ctx context.Context, req *pb.GetFriendListsRequest) (*pb.GetFriendListsResponse, error) {
+	data, err := u.userService.GetFriendLists(ctx, req.GetUserId())
+	if err != nil {
+		log.Println(err)
+		return nil, status.Error(codes.InvalidArgument, "Failed to get friend lists")
+	}
+
+	return &pb.GetFriendListsResponse{
+		UserId:  data.UserID,
+		Friends: data.Friends,
+	}, nil
+}
+
+func (u *UserServerImpl) GetFriendRequests(ctx context.Context, req *pb.GetFriendRequestsRequest) (*pb.GetFriendRequestsResponse, error) {
+	data, err := u.userService.GetFriendRequests(ctx, req.GetUserId())
+	if err != nil {
+		log.Println(err)
+		return nil, status.Error(codes.InvalidArgument, "Failed to get friend requests")
+	}
+
+	resp := &pb.GetFriendRequestsResponse{}
+	for _, request := range data {
+		resp.Requests = append(resp.Requests, toFriendRequest(request))
+	}
+	return resp, nil
+}
+
+func toFriendRequest(data dto.FriendRequestResponse) *pb.FriendRequest {
+	return &pb.FriendRequest{
+		RequestId:  data.RequestID,
+		SenderId:   data.SenderID,
+		ReceiverId: data.ReceiverID,
+		Status:     data.Status,
+		CreatedAt:  timestamppb.New(data.CreatedAt),
+		UpdatedAt:  timestamppb.New(data.UpdatedAt),
+	}
+}
@@ -1,20 +1,36 @@
 package main
 
 import (
+	"context"
 	"go-chat/app"
 	"go-chat/controller"
 	_ "go-chat/docs"
+	"go-chat/grpcserver"
+	"go-chat/pkg/storage"
+	"go-chat/pkg/util"
 	"go-chat/pkg/websocket"
 	"go-chat/repository"
 	"go-chat/service"
 	"log"
+	"net"
 	"net/http"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
 
 	"github.com/joho/godotenv"
 	"github.com/rs/cors"
 	httpSwagger "github.com/swaggo/http-swagger/v2"
 )
 
+// shutdownTimeout bounds how long pending sends and in-flight saves may take
+// to finish once a shutdown signal arrives.
+const shutdownTimeout = 15 * time.Second
+
 // @title Swagger Chat-App API
 // @version 1.0
 // @description This is a Chat-App server
@@ -40,31 +56,129 @@ func main() {
 		log.Fatalf("Failed to create MongoDB client: %v", err)
 	}
 
+	var backplane websocket.Backplane = websocket.NewMemoryBackplane()
+	if os.Getenv("HUB_BACKPLANE") == "mongo" {
+		backplane, err = websocket.NewMongoBackplane(context.Background(), mongo)
+		if err != nil {
+			log.Fatalf("Failed to create hub backplane: %v", err)
+		}
+	}
+
+	policy, err := websocket.ParseBackpressurePolicy(os.Getenv("HUB_BACKPRESSURE"))
+	if err != nil {
+		log.Fatalf("Invalid hub backpressure policy: %v", err)
+	}
+
+	origins := util.NewOriginPolicyFromEnv()
+
+	hub := websocket.NewHub(backplane, websocket.HubConfig{
+		Backpressure: policy,
+		RateLimits:   rateLimitsEnv(),
+		AllowOrigin:  origins.Allowed,
+	})
+
+	var blobStore storage.BlobStore
+	if os.Getenv("BLOB_STORE") == "s3" {
+		blobStore, err = storage.NewS3BlobStore(context.Background(), storage.S3Config{
+			Endpoint:  os.Getenv("S3_ENDPOINT"),
+			Region:    os.Getenv("S3_REGION"),
+			Bucket:    os.Getenv("S3_BUCKET"),
+			AccessKey: os.Getenv("S3_ACCESS_KEY"),
+			SecretKey: os.Getenv("S3_SECRET_KEY"),
+			UseSSL:    os.Getenv("S3_USE_SSL") == "true",
+		})
+	} else {
+		blobDir := os.Getenv("BLOB_DIR")
+		if blobDir == "" {
+			blobDir = "data/blobs"
+		}
+		blobStore, err = storage.NewFileBlobStore(blobDir)
+	}
+	if err != nil {
+		log.Fatalf("Failed to create blob store: %v", err)
+	}
+
 	authRepository := repository.NewAuthRepository(mongo)
 	authService := service.NewAuthService(authRepository)
 	authController := controller.NewAuthController(authService)
 
+	notificationRepository := repository.NewNotificationRepository(mongo)
+	notificationService := service.NewNotificationService(notificationRepository, hub)
+	notificationController := controller.NewNotificationController(notificationService)
+
+	var moderators []string
+	if value := os.Getenv("MODERATOR_IDS"); value != "" {
+		moderators = strings.Split(value, ",")
+	}
+
 	chatRepository := repository.NewChatRepository(mongo)
-	chatService := service.NewChatService(chatRepository)
+	if err := chatRepository.EnsureIndexes(context.Background()); err != nil {
+		log.Fatalf("Failed to create message indexes: %v", err)
+	}
+	attachmentRepository := repository.NewAttachmentRepository(mongo)
+	if err := attachmentRepository.EnsureIndexes(context.Background()); err != nil {
+		log.Fatalf("Failed to create attachment indexes: %v", err)
+	}
+	scheduleRepository := repository.NewScheduleRepository(mongo)
+	if err := scheduleRepository.EnsureIndexes(context.Background()); err != nil {
+		log.Fatalf("Failed to create scheduled message indexes: %v", err)
+	}
+
+	attachmentMaxSize := sizeEnv("ATTACHMENT_MAX_SIZE", 25<<20)
+	attachmentService := service.NewAttachmentService(attachmentRepository, chatRepository, scheduleRepository, blobStore, hub, service.AttachmentConfig{
+		MaxSize:      attachmentMaxSize,
+		MaxImageSize: sizeEnv("ATTACHMENT_MAX_IMAGE_SIZE", 10<<20),
+	})
+	attachmentController := controller.NewAttachmentController(attachmentService)
+
+	chatService := service.NewChatService(chatRepository, attachmentRepository, attachmentService, notificationService, hub, service.ChatConfig{
+		EditWindow:   durationEnv("MESSAGE_EDIT_WINDOW", 15*time.Minute),
+		DeleteWindow: durationEnv("MESSAGE_DELETE_WINDOW", time.Hour),
+		HideWindow:   durationEnv("MESSAGE_HIDE_WINDOW", 0),
+		Moderators:   moderators,
+	})
 	chatController := controller.NewChatController(chatService)
 
+	scheduleService := service.NewScheduleService(scheduleRepository, chatRepository, chatService, attachmentService, notificationService)
+	scheduleController := controller.NewScheduleController(scheduleService)
+
+	uploadRepository := repository.NewUploadRepository(mongo)
+	uploadService := service.NewUploadService(uploadRepository, chatRepository, attachmentService, blobStore, service.UploadConfig{
+		MaxSize:    attachmentMaxSize,
+		UserQuota:  sizeEnv("UPLOAD_USER_QUOTA", 1<<30),
+		Expiration: durationEnv("UPLOAD_EXPIRATION", 24*time.Hour),
+	})
+	uploadController := controller.NewUploadController(uploadService)
+
 	userRepository := repository.NewUserRepository(mongo)
-	userService := service.NewUserService(authRepository, userRepository)
+	userService := service.NewUserService(authRepository, userRepository, notificationService)
 	userController := controller.NewUserController(userService)
 
-	hub := websocket.NewHub()
+	adminService := service.NewAdminService(hub)
+	adminController := controller.NewAdminController(adminService)
+
+	grpcServer := grpcserver.NewServer(
+		os.Getenv("GRPC_AUTH_TOKEN"),
+		grpcserver.NewAuthServer(authService),
+		grpcserver.NewChatServer(chatService, hub),
+		grpcserver.NewUserServer(userService),
+	)
+
+	chatActionController := controller.NewChatActionController(chatService)
+	actions := app.SetupActions(chatActionController)
 
-	router := app.SetupRoutes(authController, chatController, userController, hub, chatRepository)
+	router := app.SetupRoutes(authController, chatController, scheduleController, attachmentController, uploadController, userController, notificationController, adminController, hub, actions)
 
 	http.Handle("/swagger/", httpSwagger.Handler(
 		httpSwagger.URL("http://localhost:8000/swagger/doc.json"),
 	))
 
 	c := cors.New(cors.Options{
-		AllowedOrigins:   []string{"http://localhost:3000"},
+		AllowOriginFunc:  origins.Allowed,
 		AllowCredentials: true,
-		AllowedMethods:   []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
-		AllowedHeaders:   []string{"Authorization", "Content-Type"},
+		AllowedMethods:   []string{"GET", "HEAD", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
+		AllowedHeaders:   []string{"Authorization", "Content-Type", "Tus-Resumable", "Upload-Length", "Upload-Metadata", "Upload-Offset"},
+		ExposedHeaders:   []string{"Location", "Tus-Resumable", "Tus-Version", "Tus-Extension", "Tus-Max-Size", "Upload-Offset", "Upload-Length", "Upload-Expires", "X-Attachment-ID"},
 	})
 
 	handler := c.Handler(router)
@@ -78,7 +192,177 @@ func main() {
 		Handler: handler,
 	}
 
-	if err := server.ListenAndServe(); err != nil {
-		log.Fatalf("Server failed: %v", err)
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// background workers are waited for before the Mongo client closes, so
+	// work they already started, like sending a scheduled message, finishes
+	var workers sync.WaitGroup
+	runWorker := func(run func(ctx context.Context)) {
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			run(ctx)
+		}()
+	}
+
+	// the preview worker stops with the server, images it didn't get to stay
+	// pending and are picked up on the next start
+	runWorker(attachmentService.RunPreviewWorker)
+	runWorker(uploadService.RunCleanup)
+	// scheduled messages are stored, those that come due while no instance
+	// runs are sent on the next start
+	runWorker(scheduleService.RunScheduler)
+	runWorker(chatService.RunExpirySweeper)
+
+	go func() {
+		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			log.Fatalf("Server failed: %v", err)
+		}
+	}()
+
+	grpcAddr := os.Getenv("GRPC_ADDR")
+	if grpcAddr == "" {
+		grpcAddr = "localhost:9000"
+	}
+
+	listener, err := net.Listen("tcp", grpcAddr)
+	if err != nil {
+		log.Fatalf("Failed to listen for grpc: %v", err)
+	}
+
+	go func() {
+		if err := grpcServer.Serve(listener); err != nil {
+			log.Fatalf("Grpc server failed: %v", err)
+		}
+	}()
+
+	<-ctx.Done()
+	log.Println("Shutting down server")
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+
+	// the hub is stopped alongside the servers because they wait for open
+	// SSE and chat streams, which only end once the hub closes them
+	hubErr := make(chan error, 1)
+	go func() {
+		hubErr <- hub.Shutdown(shutdownCtx)
+	}()
+
+	grpcStopped := make(chan struct{})
+	go func() {
+		grpcServer.GracefulStop()
+		close(grpcStopped)
+	}()
+
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		log.Printf("Failed to shut down server: %v", err)
+	}
+
+	select {
+	case <-grpcStopped:
+	case <-shutdownCtx.Done():
+		grpcServer.Stop()
+	}
+
+	if err := <-hubErr; err != nil {
+		log.Printf("Failed to drain hub: %v", err)
+	}
+
+	workersStopped := make(chan struct{})
+	go func() {
+		workers.Wait()
+		close(workersStopped)
+	}()
+
+	select {
+	case <-workersStopped:
+	case <-shutdownCtx.Done():
+		log.Printf("Failed to stop background workers: %v", shutdownCtx.Err())
+	}
+
+	if err := mongo.Disconnect(shutdownCtx); err != nil {
+		log.Printf("Failed to disconnect MongoDB client: %v", err)
+	}
+
+	log.Println("Server stopped")
+}
+
+// durationEnv reads a duration such as "15m" from the environment variable
+// key, or returns fallback when it's unset.
+func durationEnv(key string, fallback time.Duration) time.Duration {
+	value := os.Getenv(key)
+	if value == "" {
+		return fallback
+	}
+
+	duration, err := time.ParseDuration(value)
+	if err != nil {
+		log.Fatalf("Invalid %s: %v", key, err)
+	}
+	return duration
+}
+
+// rateLimitsEnv overrides the default websocket rate limits with the
+// RATE_LIMIT_CONN_<ACTION> and RATE_LIMIT_USER_<ACTION> environment variables,
+// set to "rate/burst" or "off", and RATE_LIMIT_MAX_VIOLATIONS and
+// RATE_LIMIT_VIOLATION_WINDOW.
+func rateLimitsEnv() websocket.RateLimits {
+	limits := websocket.DefaultRateLimits()
+
+	for _, entry := range os.Environ() {
+		key, value, _ := strings.Cut(entry, "=")
+		if value == "" {
+			continue
+		}
+
+		var action string
+		var actions map[string]websocket.Limit
+		if strings.HasPrefix(key, "RATE_LIMIT_CONN_") {
+			action, actions = strings.TrimPrefix(key, "RATE_LIMIT_CONN_"), limits.PerConnection
+		} else if strings.HasPrefix(key, "RATE_LIMIT_USER_") {
+			action, actions = strings.TrimPrefix(key, "RATE_LIMIT_USER_"), limits.PerUser
+		} else {
+			continue
+		}
+		action = strings.ToLower(action)
+
+		if value == "off" {
+			delete(actions, action)
+			continue
+		}
+
+		limit, err := websocket.ParseLimit(value)
+		if err != nil {
+			log.Fatalf("Invalid %s: %v", key, err)
+		}
+		actions[action] = limit
+	}
+
+	if value := os.Getenv("RATE_LIMIT_MAX_VIOLATIONS"); value != "" {
+		maxViolations, err := strconv.Atoi(value)
+		if err != nil || maxViolations < 0 {
+			log.Fatalf("Invalid RATE_LIMIT_MAX_VIOLATIONS: %q", value)
+		}
+		limits.MaxViolations = maxViolations
+	}
+	limits.ViolationWindow = durationEnv("RATE_LIMIT_VIOLATION_WINDOW", limits.ViolationWindow)
+
+	return limits
+}
+
+// sizeEnv reads a size in bytes from the environment variable key, or returns
+// fallback when it's unset.
+func sizeEnv(key string, fallback int64) int64 {
+	value := os.Getenv(key)
+	if value == "" {
+		return fallback
+	}
+
+	size, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || size <= 0 {
+		log.Fatalf("Invalid %s: %q", key, value)
 	}
+	return size
 }
@@ -0,0 +1,53 @@
+package model
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Attachment is a file uploaded to a chat room. Its content is stored in the
+// blob store under its SHA256, so uploads of the same content share one blob.
+type Attachment struct {
+	AttachmentID primitive.ObjectID `bson:"_id, omitempty"`
+	ChatRoomID   string             `bson:"chat_room_id"`
+	UploaderID   string             `bson:"uploader_id"`
+	FileName     string             `bson:"file_name"`
+	ContentType  string             `bson:"content_type"`
+	Size         int64              `bson:"size"`
+	SHA256       string             `bson:"sha256"`
+	CreatedAt    time.Time          `bson:"created_at"`
+	// Preview is only set for images.
+	Preview *ImagePreview `bson:"preview,omitempty"`
+}
+
+// ImagePreview describes an uploaded image. Its dimensions are known from the
+// upload on, the placeholder and thumbnails once the preview worker is done.
+type ImagePreview struct {
+	Status     string      `bson:"status"`
+	Width      int         `bson:"width"`
+	Height     int         `bson:"height"`
+	BlurHash   string      `bson:"blurhash,omitempty"`
+	Thumbnails []Thumbnail `bson:"thumbnails,omitempty"`
+}
+
+// Thumbnail is a scaled down copy of an image whose longest edge is Size
+// pixels, stored as a blob under BlobKey.
+type Thumbnail struct {
+	Size        int    `bson:"size"`
+	Width       int    `bson:"width"`
+	Height      int    `bson:"height"`
+	ContentType string `bson:"content_type"`
+	Length      int64  `bson:"length"`
+	BlobKey     string `bson:"blob_key"`
+}
+
+// MessageAttachment is the copy of an attachment's details kept on the
+// messages that reference it.
+type MessageAttachment struct {
+	AttachmentID string        `bson:"attachment_id"`
+	FileName     string        `bson:"file_name"`
+	ContentType  string        `bson:"content_type"`
+	Size         int64         `bson:"size"`
+	Preview      *ImagePreview `bson:"preview,omitempty"`
+}
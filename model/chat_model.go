@@ -3,9 +3,21 @@ package model
 import (
 	"time"
 
+	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
+// MessageCursor is a position in a chat room's history, that of a message.
+// Messages are ordered by sequence number. Those saved before rooms numbered
+// their messages have none and come first, ordered by timestamp, then by ID
+// for those sent at the same millisecond. Timestamp and MessageID only matter
+// when Seq is 0.
+type MessageCursor struct {
+	Seq       int64
+	Timestamp time.Time
+	MessageID primitive.ObjectID
+}
+
 type Message struct {
 	MessageID   primitive.ObjectID `bson:"_id, omitempty"`
 	SenderID    string             `bson:"sender_id"`
@@ -13,6 +25,53 @@ type Message struct {
 	MessageText string             `bson:"message_text"`
 	Timestamp   time.Time          `bson:"timestamp"`
 	ChatRoomID  string             `bson:"chat_room_id"`
+	// Seq is the message's position in its room, handed out by the room in
+	// the order messages are saved, starting at 1.
+	Seq         int64            `bson:"seq,omitempty"`
+	EditedAt    time.Time        `bson:"edited_at,omitempty"`
+	EditHistory []MessageVersion `bson:"edit_history,omitempty"`
+	// DeletedFor lists the users who deleted the message for themselves only.
+	DeletedFor []string `bson:"deleted_for,omitempty"`
+	// DeletedAt is set once the message is deleted for everyone, its text and
+	// edit history are wiped and only this tombstone is left.
+	DeletedAt time.Time `bson:"deleted_at,omitempty"`
+	DeletedBy string    `bson:"deleted_by,omitempty"`
+	// Reactions maps each emoji to the users who reacted with it.
+	Reactions map[string][]string `bson:"reactions,omitempty"`
+	// Replies point at the message they answer and at the first message of
+	// their thread, which may be the same one.
+	ReplyToMessageID string `bson:"reply_to_message_id,omitempty"`
+	ThreadRootID     string `bson:"thread_root_id,omitempty"`
+	// Thread roots track their replies and who follows the thread: the root's
+	// sender and everyone who replied.
+	ReplyCount      int       `bson:"reply_count,omitempty"`
+	LastReplyAt     time.Time `bson:"last_reply_at,omitempty"`
+	ThreadFollowers []string  `bson:"thread_followers,omitempty"`
+	// Mentions are the room members mentioned as "@user_id" in the text.
+	Mentions    []string            `bson:"mentions,omitempty"`
+	Attachments []MessageAttachment `bson:"attachments,omitempty"`
+	// ExpiresAt is when a disappearing message is removed. Messages of rooms
+	// whose timer starts once they're read carry ExpireAfterRead, in
+	// seconds, until a member other than the sender reads them.
+	ExpiresAt       time.Time `bson:"expires_at,omitempty"`
+	ExpireAfterRead int64     `bson:"expire_after_read,omitempty"`
+	// Notice is set on the messages the server posts in a room when its
+	// settings change, their sender is the member who changed them. They can't
+	// be edited, deleted or reacted to.
+	Notice *RoomNotice `bson:"notice,omitempty"`
+}
+
+// RoomNotice describes a change of a room's settings.
+type RoomNotice struct {
+	Type           string `bson:"type"`
+	DisappearAfter int64  `bson:"disappear_after,omitempty"`
+	DisappearFrom  string `bson:"disappear_from,omitempty"`
+}
+
+// MessageVersion is a previous text of an edited message.
+type MessageVersion struct {
+	MessageText string    `bson:"message_text"`
+	ReplacedAt  time.Time `bson:"replaced_at"`
 }
 
 type ChatRoom struct {
@@ -20,6 +79,10 @@ type ChatRoom struct {
 	UserIDs    []string           `bson:"user_ids"`
 	CreatedAt  time.Time          `bson:"created_at"`
 	UpdatedAt  time.Time          `bson:"updated_at"`
+	// DisappearAfter makes new messages expire that many seconds after they
+	// are sent, or read when DisappearFrom is "read". Zero keeps them.
+	DisappearAfter int64  `bson:"disappear_after,omitempty"`
+	DisappearFrom  string `bson:"disappear_from,omitempty"`
 }
 
 type Notification struct {
@@ -27,6 +90,37 @@ type Notification struct {
 	UserID           string             `bson:"user_id"`
 	NotificationType string             `bson:"notification_type"`
 	Content          string             `bson:"content"`
+	Payload          bson.Raw           `bson:"payload,omitempty"`
 	Timestamp        time.Time          `bson:"timestamp"`
 	Read             bool               `bson:"read"`
 }
+
+// Notification payloads, the one stored depends on NotificationType.
+
+type FriendRequestPayload struct {
+	RequestID string `bson:"request_id"`
+	SenderID  string `bson:"sender_id"`
+}
+
+type FriendRequestResponsePayload struct {
+	RequestID   string `bson:"request_id"`
+	ResponderID string `bson:"responder_id"`
+}
+
+type MentionPayload struct {
+	MessageID  string `bson:"message_id"`
+	ChatRoomID string `bson:"chat_room_id"`
+	SenderID   string `bson:"sender_id"`
+	Excerpt    string `bson:"excerpt"`
+}
+
+type RoomInvitePayload struct {
+	ChatRoomID string `bson:"chat_room_id"`
+	InviterID  string `bson:"inviter_id"`
+}
+
+type ScheduledFailedPayload struct {
+	ScheduledMessageID string `bson:"scheduled_message_id"`
+	ChatRoomID         string `bson:"chat_room_id"`
+	Reason             string `bson:"reason"`
+}
@@ -0,0 +1,31 @@
+package model
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ScheduledMessage is a message a user asked to send to a room at SendAt.
+// The scheduler sends it like any other message, with ScheduledMessageID as
+// the message's ID, and then deletes it.
+type ScheduledMessage struct {
+	ScheduledMessageID primitive.ObjectID `bson:"_id, omitempty"`
+	ChatRoomID         string             `bson:"chat_room_id"`
+	SenderID           string             `bson:"sender_id"`
+	ReceiverID         string             `bson:"receiver_id"`
+	MessageText        string             `bson:"message_text"`
+	ReplyToMessageID   string             `bson:"reply_to_message_id,omitempty"`
+	AttachmentIDs      []string           `bson:"attachment_ids,omitempty"`
+	SendAt             time.Time          `bson:"send_at"`
+	// Status is pending until a server instance claims the message to send
+	// it. The claim lasts until LockedUntil, so the messages of an instance
+	// that stopped are sent by another one. Messages that can't be sent are
+	// kept as failed with the reason in Error, until edited or cancelled.
+	Status      string    `bson:"status"`
+	LockedUntil time.Time `bson:"locked_until,omitempty"`
+	Attempts    int       `bson:"attempts"`
+	Error       string    `bson:"error,omitempty"`
+	CreatedAt   time.Time `bson:"created_at"`
+	UpdatedAt   time.Time `bson:"updated_at"`
+}
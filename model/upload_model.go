@@ -0,0 +1,31 @@
+package model
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Upload is a resumable upload of an attachment, following the tus protocol.
+// The content of each request is stored as a chunk blob, the chunks are
+// joined into an attachment once all Length bytes are received.
+type Upload struct {
+	UploadID   primitive.ObjectID `bson:"_id, omitempty"`
+	ChatRoomID string             `bson:"chat_room_id"`
+	UploaderID string             `bson:"uploader_id"`
+	FileName   string             `bson:"file_name"`
+	Length     int64              `bson:"length"`
+	Offset     int64              `bson:"offset"`
+	Chunks     []UploadChunk      `bson:"chunks,omitempty"`
+	// AttachmentID is set once the upload is complete.
+	AttachmentID string    `bson:"attachment_id,omitempty"`
+	CreatedAt    time.Time `bson:"created_at"`
+	// ExpiresAt is pushed back by every chunk received, uploads abandoned
+	// past it are deleted.
+	ExpiresAt time.Time `bson:"expires_at"`
+}
+
+type UploadChunk struct {
+	BlobKey string `bson:"blob_key"`
+	Size    int64  `bson:"size"`
+}
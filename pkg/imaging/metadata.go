@@ -0,0 +1,272 @@
+package imaging
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+)
+
+// ErrMalformed is returned for images whose structure can't be parsed.
+var ErrMalformed = errors.New("malformed image")
+
+var (
+	jpegExif     = []byte("Exif\x00\x00")
+	pngSignature = []byte("\x89PNG\r\n\x1a\n")
+)
+
+const (
+	jpegSOI  = 0xD8
+	jpegEOI  = 0xD9
+	jpegSOS  = 0xDA
+	jpegAPP0 = 0xE0
+	jpegAPP1 = 0xE1
+	// APP13 carries Photoshop and IPTC metadata.
+	jpegAPP13 = 0xED
+	jpegCOM   = 0xFE
+
+	exifOrientationTag = 0x0112
+)
+
+// StripMetadata removes the EXIF, XMP and text metadata of a JPEG, PNG or
+// WebP image, which may tell where and with what a photo was taken, without
+// re-encoding it. A JPEG keeps its orientation so it still displays upright.
+// Images of other types are returned as they are.
+func StripMetadata(data []byte, contentType string) ([]byte, error) {
+	switch contentType {
+	case "image/jpeg":
+		return stripJPEG(data)
+	case "image/png":
+		return stripPNG(data)
+	case "image/webp":
+		return stripWebP(data)
+	}
+	return data, nil
+}
+
+// Orientation returns the EXIF orientation of a JPEG image, from 1 to 8, or
+// 1 when it has none.
+func Orientation(data []byte) int {
+	orientation := 1
+	walkJPEG(data, func(marker byte, payload []byte) {
+		if marker == jpegAPP1 && bytes.HasPrefix(payload, jpegExif) {
+			orientation = exifOrientation(payload[len(jpegExif):])
+		}
+	})
+	return orientation
+}
+
+func stripJPEG(data []byte) ([]byte, error) {
+	orientation := Orientation(data)
+
+	out := make([]byte, 0, len(data))
+	out = append(out, 0xFF, jpegSOI)
+
+	// the orientation goes right after SOI, or after the JFIF header which
+	// has to come first
+	inserted := orientation == 1
+	insert := func() {
+		if !inserted {
+			out = append(out, orientationSegment(orientation)...)
+			inserted = true
+		}
+	}
+
+	end, err := walkJPEG(data, func(marker byte, payload []byte) {
+		switch marker {
+		case jpegAPP1, jpegAPP13, jpegCOM:
+			return
+		case jpegAPP0:
+		default:
+			insert()
+		}
+		out = append(out, 0xFF, marker)
+		out = binary.BigEndian.AppendUint16(out, uint16(len(payload)+2))
+		out = append(out, payload...)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	insert()
+	return append(out, data[end:]...), nil
+}
+
+// walkJPEG calls fn with the segments of a JPEG image up to its image data,
+// and returns the offset of the start of scan marker the image data follows.
+func walkJPEG(data []byte, fn func(marker byte, payload []byte)) (int, error) {
+	if len(data) < 4 || data[0] != 0xFF || data[1] != jpegSOI {
+		return 0, ErrMalformed
+	}
+
+	pos := 2
+	for {
+		if pos+2 > len(data) || data[pos] != 0xFF {
+			return 0, ErrMalformed
+		}
+
+		marker := data[pos+1]
+		switch {
+		case marker == 0xFF:
+			// fill byte
+			pos++
+			continue
+		case marker == jpegSOS || marker == jpegEOI:
+			return pos, nil
+		case marker == 0x01 || (marker >= 0xD0 && marker <= 0xD7):
+			// markers without a payload
+			pos += 2
+			continue
+		}
+
+		if pos+4 > len(data) {
+			return 0, ErrMalformed
+		}
+		length := int(binary.BigEndian.Uint16(data[pos+2:]))
+		end := pos + 2 + length
+		if length < 2 || end > len(data) {
+			return 0, ErrMalformed
+		}
+
+		fn(marker, data[pos+4:end])
+		pos = end
+	}
+}
+
+// exifOrientation reads the orientation tag from the first IFD of a TIFF
+// structure.
+func exifOrientation(tiff []byte) int {
+	if len(tiff) < 8 {
+		return 1
+	}
+
+	var order binary.ByteOrder
+	switch string(tiff[:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return 1
+	}
+
+	ifd := int(order.Uint32(tiff[4:]))
+	if ifd < 8 || ifd+2 > len(tiff) {
+		return 1
+	}
+
+	count := int(order.Uint16(tiff[ifd:]))
+	for i := 0; i < count; i++ {
+		entry := ifd + 2 + i*12
+		if entry+12 > len(tiff) {
+			break
+		}
+		if order.Uint16(tiff[entry:]) == exifOrientationTag {
+			orientation := int(order.Uint16(tiff[entry+8:]))
+			if orientation < 1 || orientation > 8 {
+				return 1
+			}
+			return orientation
+		}
+	}
+	return 1
+}
+
+// orientationSegment is an APP1 segment with an EXIF structure holding only
+// the orientation tag.
+func orientationSegment(orientation int) []byte {
+	payload := append([]byte{}, jpegExif...)
+	// big endian TIFF header, its first IFD follows right after
+	payload = append(payload, 'M', 'M', 0x00, 0x2A, 0x00, 0x00, 0x00, 0x08)
+	payload = binary.BigEndian.AppendUint16(payload, 1)
+	// the tag as a single SHORT, padded to the four bytes of the value field
+	payload = binary.BigEndian.AppendUint16(payload, exifOrientationTag)
+	payload = binary.BigEndian.AppendUint16(payload, 3)
+	payload = binary.BigEndian.AppendUint32(payload, 1)
+	payload = binary.BigEndian.AppendUint16(payload, uint16(orientation))
+	payload = binary.BigEndian.AppendUint16(payload, 0)
+	// no next IFD
+	payload = binary.BigEndian.AppendUint32(payload, 0)
+
+	segment := []byte{0xFF, jpegAPP1}
+	segment = binary.BigEndian.AppendUint16(segment, uint16(len(payload)+2))
+	return append(segment, payload...)
+}
+
+// stripPNG drops the eXIf, text and time chunks, and anything after IEND.
+func stripPNG(data []byte) ([]byte, error) {
+	if !bytes.HasPrefix(data, pngSignature) {
+		return nil, ErrMalformed
+	}
+
+	out := make([]byte, 0, len(data))
+	out = append(out, pngSignature...)
+
+	pos := len(pngSignature)
+	for {
+		if pos+12 > len(data) {
+			return nil, ErrMalformed
+		}
+		length := int(binary.BigEndian.Uint32(data[pos:]))
+		end := pos + 12 + length
+		if length < 0 || end > len(data) {
+			return nil, ErrMalformed
+		}
+
+		chunkType := string(data[pos+4 : pos+8])
+		switch chunkType {
+		case "eXIf", "tEXt", "zTXt", "iTXt", "tIME":
+		default:
+			out = append(out, data[pos:end]...)
+		}
+
+		pos = end
+		if chunkType == "IEND" {
+			return out, nil
+		}
+	}
+}
+
+// stripWebP drops the EXIF and XMP chunks and clears their flags in the
+// extended header.
+func stripWebP(data []byte) ([]byte, error) {
+	if len(data) < 12 || string(data[:4]) != "RIFF" || string(data[8:12]) != "WEBP" {
+		return nil, ErrMalformed
+	}
+
+	out := make([]byte, 0, len(data))
+	out = append(out, data[:12]...)
+
+	header := -1
+	pos := 12
+	for pos < len(data) {
+		if pos+8 > len(data) {
+			return nil, ErrMalformed
+		}
+		size := int(binary.LittleEndian.Uint32(data[pos+4:]))
+		end := pos + 8 + size + size%2
+		if size < 0 || pos+8+size > len(data) {
+			return nil, ErrMalformed
+		}
+		if end > len(data) {
+			// the last chunk may miss its padding byte
+			end = len(data)
+		}
+
+		switch fourCC := string(data[pos : pos+4]); fourCC {
+		case "EXIF", "XMP ":
+		default:
+			if fourCC == "VP8X" && size > 0 {
+				header = len(out) + 8
+			}
+			out = append(out, data[pos:end]...)
+		}
+		pos = end
+	}
+
+	if header >= 0 {
+		const exifFlag, xmpFlag = 0x08, 0x04
+		out[header] &^= exifFlag | xmpFlag
+	}
+	binary.LittleEndian.PutUint32(out[4:], uint32(len(out)-8))
+	return out, nil
+}
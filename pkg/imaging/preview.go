@@ -0,0 +1,102 @@
+package imaging
+
+import (
+	"bytes"
+	"image"
+	_ "image/gif"
+	"image/jpeg"
+	"image/png"
+	"io"
+
+	_ "golang.org/x/image/bmp"
+	"golang.org/x/image/draw"
+	_ "golang.org/x/image/webp"
+)
+
+const thumbnailQuality = 80
+
+// Dimensions returns the width and height of an image as displayed, once its
+// orientation is applied, without decoding its pixels.
+func Dimensions(data []byte) (width int, height int, err error) {
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return 0, 0, err
+	}
+
+	if Orientation(data) >= 5 {
+		return config.Height, config.Width, nil
+	}
+	return config.Width, config.Height, nil
+}
+
+// Decode decodes a JPEG, PNG, GIF, WebP or BMP image. Only the first frame of
+// an animation is kept.
+func Decode(data []byte) (image.Image, error) {
+	img, _, err := image.Decode(bytes.NewReader(data))
+	return img, err
+}
+
+// Thumbnail scales img so its longest edge is size pixels, then turns it
+// upright for the given EXIF orientation. Scaling first keeps reorienting
+// cheap.
+func Thumbnail(img image.Image, size int, orientation int) image.Image {
+	bounds := img.Bounds()
+	width, height := size, size
+	if bounds.Dx() >= bounds.Dy() {
+		height = max(1, bounds.Dy()*size/bounds.Dx())
+	} else {
+		width = max(1, bounds.Dx()*size/bounds.Dy())
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.CatmullRom.Scale(dst, dst.Bounds(), img, bounds, draw.Src, nil)
+	return orient(dst, orientation)
+}
+
+// Encode writes img as a JPEG, or as a PNG when it has transparent pixels,
+// and returns the content type it used.
+func Encode(w io.Writer, img image.Image) (contentType string, err error) {
+	if opaque, ok := img.(interface{ Opaque() bool }); ok && !opaque.Opaque() {
+		return "image/png", png.Encode(w, img)
+	}
+	return "image/jpeg", jpeg.Encode(w, img, &jpeg.Options{Quality: thumbnailQuality})
+}
+
+// orient applies one of the eight EXIF orientations, mirroring and rotating
+// img so it displays upright.
+func orient(img *image.RGBA, orientation int) *image.RGBA {
+	if orientation < 2 || orientation > 8 {
+		return img
+	}
+
+	width, height := img.Bounds().Dx(), img.Bounds().Dy()
+	dstWidth, dstHeight := width, height
+	if orientation >= 5 {
+		dstWidth, dstHeight = height, width
+	}
+	dst := image.NewRGBA(image.Rect(0, 0, dstWidth, dstHeight))
+
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			var dx, dy int
+			switch orientation {
+			case 2: // mirrored
+				dx, dy = width-1-x, y
+			case 3: // rotated 180°
+				dx, dy = width-1-x, height-1-y
+			case 4: // mirrored vertically
+				dx, dy = x, height-1-y
+			case 5: // transposed
+				dx, dy = y, x
+			case 6: // rotated 90° clockwise
+				dx, dy = height-1-y, x
+			case 7: // transversed
+				dx, dy = height-1-y, width-1-x
+			case 8: // rotated 90° counterclockwise
+				dx, dy = y, width-1-x
+			}
+			dst.SetRGBA(dx, dy, img.RGBAAt(x, y))
+		}
+	}
+	return dst
+}
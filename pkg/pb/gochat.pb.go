@@ -0,0 +1,2147 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.9
+// 	protoc        v5.29.3
+// source: gochat.proto
+
+package pb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type RegisterRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	UserId        string                 `protobuf:"bytes,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	Username      string                 `protobuf:"bytes,2,opt,name=username,proto3" json:"username,omitempty"`
+	Email         string                 `protobuf:"bytes,3,opt,name=email,proto3" json:"email,omitempty"`
+	Password      string                 `protobuf:"bytes,4,opt,name=password,proto3" json:"password,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RegisterRequest) Reset() {
+	*x = RegisterRequest{}
+	mi := &file_gochat_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RegisterRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RegisterRequest) ProtoMessage() {}
+
+func (x *RegisterRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_gochat_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RegisterRequest.ProtoReflect.Descriptor instead.
+func (*RegisterRequest) Descriptor() ([]byte, []int) {
+	return file_gochat_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *RegisterRequest) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+func (x *RegisterRequest) GetUsername() string {
+	if x != nil {
+		return x.Username
+	}
+	return ""
+}
+
+func (x *RegisterRequest) GetEmail() string {
+	if x != nil {
+		return x.Email
+	}
+	return ""
+}
+
+func (x *RegisterRequest) GetPassword() string {
+	if x != nil {
+		return x.Password
+	}
+	return ""
+}
+
+type LoginRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Identifier    string                 `protobuf:"bytes,1,opt,name=identifier,proto3" json:"identifier,omitempty"`
+	Password      string                 `protobuf:"bytes,2,opt,name=password,proto3" json:"password,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *LoginRequest) Reset() {
+	*x = LoginRequest{}
+	mi := &file_gochat_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *LoginRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LoginRequest) ProtoMessage() {}
+
+func (x *LoginRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_gochat_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use LoginRequest.ProtoReflect.Descriptor instead.
+func (*LoginRequest) Descriptor() ([]byte, []int) {
+	return file_gochat_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *LoginRequest) GetIdentifier() string {
+	if x != nil {
+		return x.Identifier
+	}
+	return ""
+}
+
+func (x *LoginRequest) GetPassword() string {
+	if x != nil {
+		return x.Password
+	}
+	return ""
+}
+
+type User struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	UserId        string                 `protobuf:"bytes,2,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	Username      string                 `protobuf:"bytes,3,opt,name=username,proto3" json:"username,omitempty"`
+	Email         string                 `protobuf:"bytes,4,opt,name=email,proto3" json:"email,omitempty"`
+	CreatedAt     *timestamppb.Timestamp `protobuf:"bytes,5,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	UpdatedAt     *timestamppb.Timestamp `protobuf:"bytes,6,opt,name=updated_at,json=updatedAt,proto3" json:"updated_at,omitempty"`
+	Friends       []string               `protobuf:"bytes,7,rep,name=friends,proto3" json:"friends,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *User) Reset() {
+	*x = User{}
+	mi := &file_gochat_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *User) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*User) ProtoMessage() {}
+
+func (x *User) ProtoReflect() protoreflect.Message {
+	mi := &file_gochat_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use User.ProtoReflect.Descriptor instead.
+func (*User) Descriptor() ([]byte, []int) {
+	return file_gochat_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *User) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *User) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+func (x *User) GetUsername() string {
+	if x != nil {
+		return x.Username
+	}
+	return ""
+}
+
+func (x *User) GetEmail() string {
+	if x != nil {
+		return x.Email
+	}
+	return ""
+}
+
+func (x *User) GetCreatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return nil
+}
+
+func (x *User) GetUpdatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.UpdatedAt
+	}
+	return nil
+}
+
+func (x *User) GetFriends() []string {
+	if x != nil {
+		return x.Friends
+	}
+	return nil
+}
+
+type Message struct {
+	state            protoimpl.MessageState `protogen:"open.v1"`
+	Id               string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	SenderId         string                 `protobuf:"bytes,2,opt,name=sender_id,json=senderId,proto3" json:"sender_id,omitempty"`
+	ReceiverId       string                 `protobuf:"bytes,3,opt,name=receiver_id,json=receiverId,proto3" json:"receiver_id,omitempty"`
+	MessageText      string                 `protobuf:"bytes,4,opt,name=message_text,json=messageText,proto3" json:"message_text,omitempty"`
+	Timestamp        *timestamppb.Timestamp `protobuf:"bytes,5,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
+	ChatRoomId       string                 `protobuf:"bytes,6,opt,name=chat_room_id,json=chatRoomId,proto3" json:"chat_room_id,omitempty"`
+	EditedAt         *timestamppb.Timestamp `protobuf:"bytes,7,opt,name=edited_at,json=editedAt,proto3" json:"edited_at,omitempty"`
+	Deleted          bool                   `protobuf:"varint,8,opt,name=deleted,proto3" json:"deleted,omitempty"`
+	Reactions        []*Reaction            `protobuf:"bytes,9,rep,name=reactions,proto3" json:"reactions,omitempty"`
+	ReplyToMessageId string                 `protobuf:"bytes,10,opt,name=reply_to_message_id,json=replyToMessageId,proto3" json:"reply_to_message_id,omitempty"`
+	ThreadRootId     string                 `protobuf:"bytes,11,opt,name=thread_root_id,json=threadRootId,proto3" json:"thread_root_id,omitempty"`
+	ReplyCount       int32                  `protobuf:"varint,12,opt,name=reply_count,json=replyCount,proto3" json:"reply_count,omitempty"`
+	LastReplyAt      *timestamppb.Timestamp `protobuf:"bytes,13,opt,name=last_reply_at,json=lastReplyAt,proto3" json:"last_reply_at,omitempty"`
+	Mentions         []string               `protobuf:"bytes,14,rep,name=mentions,proto3" json:"mentions,omitempty"`
+	Attachments      []*Attachment          `protobuf:"bytes,15,rep,name=attachments,proto3" json:"attachments,omitempty"`
+	Seq              int64                  `protobuf:"varint,16,opt,name=seq,proto3" json:"seq,omitempty"`
+	ExpiresAt        *timestamppb.Timestamp `protobuf:"bytes,17,opt,name=expires_at,json=expiresAt,proto3" json:"expires_at,omitempty"`
+	ExpireAfterRead  int64                  `protobuf:"varint,18,opt,name=expire_after_read,json=expireAfterRead,proto3" json:"expire_after_read,omitempty"`
+	Notice           *RoomNotice            `protobuf:"bytes,19,opt,name=notice,proto3" json:"notice,omitempty"`
+	unknownFields    protoimpl.UnknownFields
+	sizeCache        protoimpl.SizeCache
+}
+
+func (x *Message) Reset() {
+	*x = Message{}
+	mi := &file_gochat_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Message) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Message) ProtoMessage() {}
+
+func (x *Message) ProtoReflect() protoreflect.Message {
+	mi := &file_gochat_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Message.ProtoReflect.Descriptor instead.
+func (*Message) Descriptor() ([]byte, []int) {
+	return file_gochat_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *Message) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *Message) GetSenderId() string {
+	if x != nil {
+		return x.SenderId
+	}
+	return ""
+}
+
+func (x *Message) GetReceiverId() string {
+	if x != nil {
+		return x.ReceiverId
+	}
+	return ""
+}
+
+func (x *Message) GetMessageText() string {
+	if x != nil {
+		return x.MessageText
+	}
+	return ""
+}
+
+func (x *Message) GetTimestamp() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Timestamp
+	}
+	return nil
+}
+
+func (x *Message) GetChatRoomId() string {
+	if x != nil {
+		return x.ChatRoomId
+	}
+	return ""
+}
+
+func (x *Message) GetEditedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.EditedAt
+	}
+	return nil
+}
+
+func (x *Message) GetDeleted() bool {
+	if x != nil {
+		return x.Deleted
+	}
+	return false
+}
+
+func (x *Message) GetReactions() []*Reaction {
+	if x != nil {
+		return x.Reactions
+	}
+	return nil
+}
+
+func (x *Message) GetReplyToMessageId() string {
+	if x != nil {
+		return x.ReplyToMessageId
+	}
+	return ""
+}
+
+func (x *Message) GetThreadRootId() string {
+	if x != nil {
+		return x.ThreadRootId
+	}
+	return ""
+}
+
+func (x *Message) GetReplyCount() int32 {
+	if x != nil {
+		return x.ReplyCount
+	}
+	return 0
+}
+
+func (x *Message) GetLastReplyAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.LastReplyAt
+	}
+	return nil
+}
+
+func (x *Message) GetMentions() []string {
+	if x != nil {
+		return x.Mentions
+	}
+	return nil
+}
+
+func (x *Message) GetAttachments() []*Attachment {
+	if x != nil {
+		return x.Attachments
+	}
+	return nil
+}
+
+func (x *Message) GetSeq() int64 {
+	if x != nil {
+		return x.Seq
+	}
+	return 0
+}
+
+func (x *Message) GetExpiresAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.ExpiresAt
+	}
+	return nil
+}
+
+func (x *Message) GetExpireAfterRead() int64 {
+	if x != nil {
+		return x.ExpireAfterRead
+	}
+	return 0
+}
+
+func (x *Message) GetNotice() *RoomNotice {
+	if x != nil {
+		return x.Notice
+	}
+	return nil
+}
+
+type RoomNotice struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	Type           string                 `protobuf:"bytes,1,opt,name=type,proto3" json:"type,omitempty"`
+	DisappearAfter int64                  `protobuf:"varint,2,opt,name=disappear_after,json=disappearAfter,proto3" json:"disappear_after,omitempty"`
+	DisappearFrom  string                 `protobuf:"bytes,3,opt,name=disappear_from,json=disappearFrom,proto3" json:"disappear_from,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *RoomNotice) Reset() {
+	*x = RoomNotice{}
+	mi := &file_gochat_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RoomNotice) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RoomNotice) ProtoMessage() {}
+
+func (x *RoomNotice) ProtoReflect() protoreflect.Message {
+	mi := &file_gochat_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RoomNotice.ProtoReflect.Descriptor instead.
+func (*RoomNotice) Descriptor() ([]byte, []int) {
+	return file_gochat_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *RoomNotice) GetType() string {
+	if x != nil {
+		return x.Type
+	}
+	return ""
+}
+
+func (x *RoomNotice) GetDisappearAfter() int64 {
+	if x != nil {
+		return x.DisappearAfter
+	}
+	return 0
+}
+
+func (x *RoomNotice) GetDisappearFrom() string {
+	if x != nil {
+		return x.DisappearFrom
+	}
+	return ""
+}
+
+type Reaction struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Emoji         string                 `protobuf:"bytes,1,opt,name=emoji,proto3" json:"emoji,omitempty"`
+	Count         int32                  `protobuf:"varint,2,opt,name=count,proto3" json:"count,omitempty"`
+	UserIds       []string               `protobuf:"bytes,3,rep,name=user_ids,json=userIds,proto3" json:"user_ids,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Reaction) Reset() {
+	*x = Reaction{}
+	mi := &file_gochat_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Reaction) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Reaction) ProtoMessage() {}
+
+func (x *Reaction) ProtoReflect() protoreflect.Message {
+	mi := &file_gochat_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Reaction.ProtoReflect.Descriptor instead.
+func (*Reaction) Descriptor() ([]byte, []int) {
+	return file_gochat_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *Reaction) GetEmoji() string {
+	if x != nil {
+		return x.Emoji
+	}
+	return ""
+}
+
+func (x *Reaction) GetCount() int32 {
+	if x != nil {
+		return x.Count
+	}
+	return 0
+}
+
+func (x *Reaction) GetUserIds() []string {
+	if x != nil {
+		return x.UserIds
+	}
+	return nil
+}
+
+type Attachment struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	FileName      string                 `protobuf:"bytes,2,opt,name=file_name,json=fileName,proto3" json:"file_name,omitempty"`
+	ContentType   string                 `protobuf:"bytes,3,opt,name=content_type,json=contentType,proto3" json:"content_type,omitempty"`
+	Size          int64                  `protobuf:"varint,4,opt,name=size,proto3" json:"size,omitempty"`
+	Preview       *ImagePreview          `protobuf:"bytes,5,opt,name=preview,proto3" json:"preview,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Attachment) Reset() {
+	*x = Attachment{}
+	mi := &file_gochat_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Attachment) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Attachment) ProtoMessage() {}
+
+func (x *Attachment) ProtoReflect() protoreflect.Message {
+	mi := &file_gochat_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Attachment.ProtoReflect.Descriptor instead.
+func (*Attachment) Descriptor() ([]byte, []int) {
+	return file_gochat_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *Attachment) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *Attachment) GetFileName() string {
+	if x != nil {
+		return x.FileName
+	}
+	return ""
+}
+
+func (x *Attachment) GetContentType() string {
+	if x != nil {
+		return x.ContentType
+	}
+	return ""
+}
+
+func (x *Attachment) GetSize() int64 {
+	if x != nil {
+		return x.Size
+	}
+	return 0
+}
+
+func (x *Attachment) GetPreview() *ImagePreview {
+	if x != nil {
+		return x.Preview
+	}
+	return nil
+}
+
+type ImagePreview struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Status        string                 `protobuf:"bytes,1,opt,name=status,proto3" json:"status,omitempty"`
+	Width         int32                  `protobuf:"varint,2,opt,name=width,proto3" json:"width,omitempty"`
+	Height        int32                  `protobuf:"varint,3,opt,name=height,proto3" json:"height,omitempty"`
+	Blurhash      string                 `protobuf:"bytes,4,opt,name=blurhash,proto3" json:"blurhash,omitempty"`
+	Thumbnails    []*Thumbnail           `protobuf:"bytes,5,rep,name=thumbnails,proto3" json:"thumbnails,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ImagePreview) Reset() {
+	*x = ImagePreview{}
+	mi := &file_gochat_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ImagePreview) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ImagePreview) ProtoMessage() {}
+
+func (x *ImagePreview) ProtoReflect() protoreflect.Message {
+	mi := &file_gochat_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ImagePreview.ProtoReflect.Descriptor instead.
+func (*ImagePreview) Descriptor() ([]byte, []int) {
+	return file_gochat_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *ImagePreview) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
+func (x *ImagePreview) GetWidth() int32 {
+	if x != nil {
+		return x.Width
+	}
+	return 0
+}
+
+func (x *ImagePreview) GetHeight() int32 {
+	if x != nil {
+		return x.Height
+	}
+	return 0
+}
+
+func (x *ImagePreview) GetBlurhash() string {
+	if x != nil {
+		return x.Blurhash
+	}
+	return ""
+}
+
+func (x *ImagePreview) GetThumbnails() []*Thumbnail {
+	if x != nil {
+		return x.Thumbnails
+	}
+	return nil
+}
+
+type Thumbnail struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Size          int32                  `protobuf:"varint,1,opt,name=size,proto3" json:"size,omitempty"`
+	Width         int32                  `protobuf:"varint,2,opt,name=width,proto3" json:"width,omitempty"`
+	Height        int32                  `protobuf:"varint,3,opt,name=height,proto3" json:"height,omitempty"`
+	ContentType   string                 `protobuf:"bytes,4,opt,name=content_type,json=contentType,proto3" json:"content_type,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Thumbnail) Reset() {
+	*x = Thumbnail{}
+	mi := &file_gochat_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Thumbnail) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Thumbnail) ProtoMessage() {}
+
+func (x *Thumbnail) ProtoReflect() protoreflect.Message {
+	mi := &file_gochat_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Thumbnail.ProtoReflect.Descriptor instead.
+func (*Thumbnail) Descriptor() ([]byte, []int) {
+	return file_gochat_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *Thumbnail) GetSize() int32 {
+	if x != nil {
+		return x.Size
+	}
+	return 0
+}
+
+func (x *Thumbnail) GetWidth() int32 {
+	if x != nil {
+		return x.Width
+	}
+	return 0
+}
+
+func (x *Thumbnail) GetHeight() int32 {
+	if x != nil {
+		return x.Height
+	}
+	return 0
+}
+
+func (x *Thumbnail) GetContentType() string {
+	if x != nil {
+		return x.ContentType
+	}
+	return ""
+}
+
+type GetMessagesRequest struct {
+	state           protoimpl.MessageState `protogen:"open.v1"`
+	ChatRoomId      string                 `protobuf:"bytes,1,opt,name=chat_room_id,json=chatRoomId,proto3" json:"chat_room_id,omitempty"`
+	Limit           int64                  `protobuf:"varint,2,opt,name=limit,proto3" json:"limit,omitempty"`
+	ViewerId        string                 `protobuf:"bytes,4,opt,name=viewer_id,json=viewerId,proto3" json:"viewer_id,omitempty"`
+	CollapseThreads bool                   `protobuf:"varint,5,opt,name=collapse_threads,json=collapseThreads,proto3" json:"collapse_threads,omitempty"`
+	Before          string                 `protobuf:"bytes,6,opt,name=before,proto3" json:"before,omitempty"`
+	After           string                 `protobuf:"bytes,7,opt,name=after,proto3" json:"after,omitempty"`
+	Around          string                 `protobuf:"bytes,8,opt,name=around,proto3" json:"around,omitempty"`
+	FromSeq         int64                  `protobuf:"varint,9,opt,name=from_seq,json=fromSeq,proto3" json:"from_seq,omitempty"`
+	ToSeq           int64                  `protobuf:"varint,10,opt,name=to_seq,json=toSeq,proto3" json:"to_seq,omitempty"`
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *GetMessagesRequest) Reset() {
+	*x = GetMessagesRequest{}
+	mi := &file_gochat_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetMessagesRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetMessagesRequest) ProtoMessage() {}
+
+func (x *GetMessagesRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_gochat_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetMessagesRequest.ProtoReflect.Descriptor instead.
+func (*GetMessagesRequest) Descriptor() ([]byte, []int) {
+	return file_gochat_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *GetMessagesRequest) GetChatRoomId() string {
+	if x != nil {
+		return x.ChatRoomId
+	}
+	return ""
+}
+
+func (x *GetMessagesRequest) GetLimit() int64 {
+	if x != nil {
+		return x.Limit
+	}
+	return 0
+}
+
+func (x *GetMessagesRequest) GetViewerId() string {
+	if x != nil {
+		return x.ViewerId
+	}
+	return ""
+}
+
+func (x *GetMessagesRequest) GetCollapseThreads() bool {
+	if x != nil {
+		return x.CollapseThreads
+	}
+	return false
+}
+
+func (x *GetMessagesRequest) GetBefore() string {
+	if x != nil {
+		return x.Before
+	}
+	return ""
+}
+
+func (x *GetMessagesRequest) GetAfter() string {
+	if x != nil {
+		return x.After
+	}
+	return ""
+}
+
+func (x *GetMessagesRequest) GetAround() string {
+	if x != nil {
+		return x.Around
+	}
+	return ""
+}
+
+func (x *GetMessagesRequest) GetFromSeq() int64 {
+	if x != nil {
+		return x.FromSeq
+	}
+	return 0
+}
+
+func (x *GetMessagesRequest) GetToSeq() int64 {
+	if x != nil {
+		return x.ToSeq
+	}
+	return 0
+}
+
+type GetMessagesResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Messages      []*Message             `protobuf:"bytes,1,rep,name=messages,proto3" json:"messages,omitempty"`
+	Before        string                 `protobuf:"bytes,2,opt,name=before,proto3" json:"before,omitempty"`
+	After         string                 `protobuf:"bytes,3,opt,name=after,proto3" json:"after,omitempty"`
+	HasMoreBefore bool                   `protobuf:"varint,4,opt,name=has_more_before,json=hasMoreBefore,proto3" json:"has_more_before,omitempty"`
+	HasMoreAfter  bool                   `protobuf:"varint,5,opt,name=has_more_after,json=hasMoreAfter,proto3" json:"has_more_after,omitempty"`
+	LastSeq       int64                  `protobuf:"varint,6,opt,name=last_seq,json=lastSeq,proto3" json:"last_seq,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetMessagesResponse) Reset() {
+	*x = GetMessagesResponse{}
+	mi := &file_gochat_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetMessagesResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetMessagesResponse) ProtoMessage() {}
+
+func (x *GetMessagesResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_gochat_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetMessagesResponse.ProtoReflect.Descriptor instead.
+func (*GetMessagesResponse) Descriptor() ([]byte, []int) {
+	return file_gochat_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *GetMessagesResponse) GetMessages() []*Message {
+	if x != nil {
+		return x.Messages
+	}
+	return nil
+}
+
+func (x *GetMessagesResponse) GetBefore() string {
+	if x != nil {
+		return x.Before
+	}
+	return ""
+}
+
+func (x *GetMessagesResponse) GetAfter() string {
+	if x != nil {
+		return x.After
+	}
+	return ""
+}
+
+func (x *GetMessagesResponse) GetHasMoreBefore() bool {
+	if x != nil {
+		return x.HasMoreBefore
+	}
+	return false
+}
+
+func (x *GetMessagesResponse) GetHasMoreAfter() bool {
+	if x != nil {
+		return x.HasMoreAfter
+	}
+	return false
+}
+
+func (x *GetMessagesResponse) GetLastSeq() int64 {
+	if x != nil {
+		return x.LastSeq
+	}
+	return 0
+}
+
+type GetOrCreateChatRoomRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	UserId        string                 `protobuf:"bytes,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	FriendId      string                 `protobuf:"bytes,2,opt,name=friend_id,json=friendId,proto3" json:"friend_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetOrCreateChatRoomRequest) Reset() {
+	*x = GetOrCreateChatRoomRequest{}
+	mi := &file_gochat_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetOrCreateChatRoomRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetOrCreateChatRoomRequest) ProtoMessage() {}
+
+func (x *GetOrCreateChatRoomRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_gochat_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetOrCreateChatRoomRequest.ProtoReflect.Descriptor instead.
+func (*GetOrCreateChatRoomRequest) Descriptor() ([]byte, []int) {
+	return file_gochat_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *GetOrCreateChatRoomRequest) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+func (x *GetOrCreateChatRoomRequest) GetFriendId() string {
+	if x != nil {
+		return x.FriendId
+	}
+	return ""
+}
+
+type ChatRoom struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	ChatRoomId     string                 `protobuf:"bytes,1,opt,name=chat_room_id,json=chatRoomId,proto3" json:"chat_room_id,omitempty"`
+	UserIds        []string               `protobuf:"bytes,2,rep,name=user_ids,json=userIds,proto3" json:"user_ids,omitempty"`
+	DisappearAfter int64                  `protobuf:"varint,3,opt,name=disappear_after,json=disappearAfter,proto3" json:"disappear_after,omitempty"`
+	DisappearFrom  string                 `protobuf:"bytes,4,opt,name=disappear_from,json=disappearFrom,proto3" json:"disappear_from,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *ChatRoom) Reset() {
+	*x = ChatRoom{}
+	mi := &file_gochat_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ChatRoom) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ChatRoom) ProtoMessage() {}
+
+func (x *ChatRoom) ProtoReflect() protoreflect.Message {
+	mi := &file_gochat_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ChatRoom.ProtoReflect.Descriptor instead.
+func (*ChatRoom) Descriptor() ([]byte, []int) {
+	return file_gochat_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *ChatRoom) GetChatRoomId() string {
+	if x != nil {
+		return x.ChatRoomId
+	}
+	return ""
+}
+
+func (x *ChatRoom) GetUserIds() []string {
+	if x != nil {
+		return x.UserIds
+	}
+	return nil
+}
+
+func (x *ChatRoom) GetDisappearAfter() int64 {
+	if x != nil {
+		return x.DisappearAfter
+	}
+	return 0
+}
+
+func (x *ChatRoom) GetDisappearFrom() string {
+	if x != nil {
+		return x.DisappearFrom
+	}
+	return ""
+}
+
+type SendMessageRequest struct {
+	state            protoimpl.MessageState `protogen:"open.v1"`
+	ChatRoomId       string                 `protobuf:"bytes,1,opt,name=chat_room_id,json=chatRoomId,proto3" json:"chat_room_id,omitempty"`
+	SenderId         string                 `protobuf:"bytes,2,opt,name=sender_id,json=senderId,proto3" json:"sender_id,omitempty"`
+	ReceiverId       string                 `protobuf:"bytes,3,opt,name=receiver_id,json=receiverId,proto3" json:"receiver_id,omitempty"`
+	MessageText      string                 `protobuf:"bytes,4,opt,name=message_text,json=messageText,proto3" json:"message_text,omitempty"`
+	ReplyToMessageId string                 `protobuf:"bytes,5,opt,name=reply_to_message_id,json=replyToMessageId,proto3" json:"reply_to_message_id,omitempty"`
+	AttachmentIds    []string               `protobuf:"bytes,6,rep,name=attachment_ids,json=attachmentIds,proto3" json:"attachment_ids,omitempty"`
+	unknownFields    protoimpl.UnknownFields
+	sizeCache        protoimpl.SizeCache
+}
+
+func (x *SendMessageRequest) Reset() {
+	*x = SendMessageRequest{}
+	mi := &file_gochat_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SendMessageRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SendMessageRequest) ProtoMessage() {}
+
+func (x *SendMessageRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_gochat_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SendMessageRequest.ProtoReflect.Descriptor instead.
+func (*SendMessageRequest) Descriptor() ([]byte, []int) {
+	return file_gochat_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *SendMessageRequest) GetChatRoomId() string {
+	if x != nil {
+		return x.ChatRoomId
+	}
+	return ""
+}
+
+func (x *SendMessageRequest) GetSenderId() string {
+	if x != nil {
+		return x.SenderId
+	}
+	return ""
+}
+
+func (x *SendMessageRequest) GetReceiverId() string {
+	if x != nil {
+		return x.ReceiverId
+	}
+	return ""
+}
+
+func (x *SendMessageRequest) GetMessageText() string {
+	if x != nil {
+		return x.MessageText
+	}
+	return ""
+}
+
+func (x *SendMessageRequest) GetReplyToMessageId() string {
+	if x != nil {
+		return x.ReplyToMessageId
+	}
+	return ""
+}
+
+func (x *SendMessageRequest) GetAttachmentIds() []string {
+	if x != nil {
+		return x.AttachmentIds
+	}
+	return nil
+}
+
+type ChatClientFrame struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Types that are valid to be assigned to Frame:
+	//
+	//	*ChatClientFrame_SendMessage
+	//	*ChatClientFrame_GetMessages
+	Frame         isChatClientFrame_Frame `protobuf_oneof:"frame"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ChatClientFrame) Reset() {
+	*x = ChatClientFrame{}
+	mi := &file_gochat_proto_msgTypes[14]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ChatClientFrame) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ChatClientFrame) ProtoMessage() {}
+
+func (x *ChatClientFrame) ProtoReflect() protoreflect.Message {
+	mi := &file_gochat_proto_msgTypes[14]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ChatClientFrame.ProtoReflect.Descriptor instead.
+func (*ChatClientFrame) Descriptor() ([]byte, []int) {
+	return file_gochat_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *ChatClientFrame) GetFrame() isChatClientFrame_Frame {
+	if x != nil {
+		return x.Frame
+	}
+	return nil
+}
+
+func (x *ChatClientFrame) GetSendMessage() string {
+	if x != nil {
+		if x, ok := x.Frame.(*ChatClientFrame_SendMessage); ok {
+			return x.SendMessage
+		}
+	}
+	return ""
+}
+
+func (x *ChatClientFrame) GetGetMessages() *GetMessagesRequest {
+	if x != nil {
+		if x, ok := x.Frame.(*ChatClientFrame_GetMessages); ok {
+			return x.GetMessages
+		}
+	}
+	return nil
+}
+
+type isChatClientFrame_Frame interface {
+	isChatClientFrame_Frame()
+}
+
+type ChatClientFrame_SendMessage struct {
+	SendMessage string `protobuf:"bytes,1,opt,name=send_message,json=sendMessage,proto3,oneof"`
+}
+
+type ChatClientFrame_GetMessages struct {
+	GetMessages *GetMessagesRequest `protobuf:"bytes,2,opt,name=get_messages,json=getMessages,proto3,oneof"`
+}
+
+func (*ChatClientFrame_SendMessage) isChatClientFrame_Frame() {}
+
+func (*ChatClientFrame_GetMessages) isChatClientFrame_Frame() {}
+
+type ChatServerFrame struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Types that are valid to be assigned to Frame:
+	//
+	//	*ChatServerFrame_Event
+	//	*ChatServerFrame_Messages
+	//	*ChatServerFrame_Error
+	Frame         isChatServerFrame_Frame `protobuf_oneof:"frame"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ChatServerFrame) Reset() {
+	*x = ChatServerFrame{}
+	mi := &file_gochat_proto_msgTypes[15]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ChatServerFrame) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ChatServerFrame) ProtoMessage() {}
+
+func (x *ChatServerFrame) ProtoReflect() protoreflect.Message {
+	mi := &file_gochat_proto_msgTypes[15]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ChatServerFrame.ProtoReflect.Descriptor instead.
+func (*ChatServerFrame) Descriptor() ([]byte, []int) {
+	return file_gochat_proto_rawDescGZIP(), []int{15}
+}
+
+func (x *ChatServerFrame) GetFrame() isChatServerFrame_Frame {
+	if x != nil {
+		return x.Frame
+	}
+	return nil
+}
+
+func (x *ChatServerFrame) GetEvent() *RoomEvent {
+	if x != nil {
+		if x, ok := x.Frame.(*ChatServerFrame_Event); ok {
+			return x.Event
+		}
+	}
+	return nil
+}
+
+func (x *ChatServerFrame) GetMessages() *GetMessagesResponse {
+	if x != nil {
+		if x, ok := x.Frame.(*ChatServerFrame_Messages); ok {
+			return x.Messages
+		}
+	}
+	return nil
+}
+
+func (x *ChatServerFrame) GetError() *Error {
+	if x != nil {
+		if x, ok := x.Frame.(*ChatServerFrame_Error); ok {
+			return x.Error
+		}
+	}
+	return nil
+}
+
+type isChatServerFrame_Frame interface {
+	isChatServerFrame_Frame()
+}
+
+type ChatServerFrame_Event struct {
+	Event *RoomEvent `protobuf:"bytes,1,opt,name=event,proto3,oneof"`
+}
+
+type ChatServerFrame_Messages struct {
+	Messages *GetMessagesResponse `protobuf:"bytes,2,opt,name=messages,proto3,oneof"`
+}
+
+type ChatServerFrame_Error struct {
+	Error *Error `protobuf:"bytes,3,opt,name=error,proto3,oneof"`
+}
+
+func (*ChatServerFrame_Event) isChatServerFrame_Frame() {}
+
+func (*ChatServerFrame_Messages) isChatServerFrame_Frame() {}
+
+func (*ChatServerFrame_Error) isChatServerFrame_Frame() {}
+
+type RoomEvent struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	RoomId        string                 `protobuf:"bytes,2,opt,name=room_id,json=roomId,proto3" json:"room_id,omitempty"`
+	Data          []byte                 `protobuf:"bytes,3,opt,name=data,proto3" json:"data,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RoomEvent) Reset() {
+	*x = RoomEvent{}
+	mi := &file_gochat_proto_msgTypes[16]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RoomEvent) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RoomEvent) ProtoMessage() {}
+
+func (x *RoomEvent) ProtoReflect() protoreflect.Message {
+	mi := &file_gochat_proto_msgTypes[16]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RoomEvent.ProtoReflect.Descriptor instead.
+func (*RoomEvent) Descriptor() ([]byte, []int) {
+	return file_gochat_proto_rawDescGZIP(), []int{16}
+}
+
+func (x *RoomEvent) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *RoomEvent) GetRoomId() string {
+	if x != nil {
+		return x.RoomId
+	}
+	return ""
+}
+
+func (x *RoomEvent) GetData() []byte {
+	if x != nil {
+		return x.Data
+	}
+	return nil
+}
+
+type Error struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Action        string                 `protobuf:"bytes,1,opt,name=action,proto3" json:"action,omitempty"`
+	Error         string                 `protobuf:"bytes,2,opt,name=error,proto3" json:"error,omitempty"`
+	RetryAfterMs  int64                  `protobuf:"varint,3,opt,name=retry_after_ms,json=retryAfterMs,proto3" json:"retry_after_ms,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Error) Reset() {
+	*x = Error{}
+	mi := &file_gochat_proto_msgTypes[17]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Error) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Error) ProtoMessage() {}
+
+func (x *Error) ProtoReflect() protoreflect.Message {
+	mi := &file_gochat_proto_msgTypes[17]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Error.ProtoReflect.Descriptor instead.
+func (*Error) Descriptor() ([]byte, []int) {
+	return file_gochat_proto_rawDescGZIP(), []int{17}
+}
+
+func (x *Error) GetAction() string {
+	if x != nil {
+		return x.Action
+	}
+	return ""
+}
+
+func (x *Error) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+func (x *Error) GetRetryAfterMs() int64 {
+	if x != nil {
+		return x.RetryAfterMs
+	}
+	return 0
+}
+
+type AddFriendRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	UserId        string                 `protobuf:"bytes,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	FriendId      string                 `protobuf:"bytes,2,opt,name=friend_id,json=friendId,proto3" json:"friend_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AddFriendRequest) Reset() {
+	*x = AddFriendRequest{}
+	mi := &file_gochat_proto_msgTypes[18]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AddFriendRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AddFriendRequest) ProtoMessage() {}
+
+func (x *AddFriendRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_gochat_proto_msgTypes[18]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AddFriendRequest.ProtoReflect.Descriptor instead.
+func (*AddFriendRequest) Descriptor() ([]byte, []int) {
+	return file_gochat_proto_rawDescGZIP(), []int{18}
+}
+
+func (x *AddFriendRequest) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+func (x *AddFriendRequest) GetFriendId() string {
+	if x != nil {
+		return x.FriendId
+	}
+	return ""
+}
+
+type RespondFriendRequestRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	RequestId     string                 `protobuf:"bytes,1,opt,name=request_id,json=requestId,proto3" json:"request_id,omitempty"`
+	SenderId      string                 `protobuf:"bytes,2,opt,name=sender_id,json=senderId,proto3" json:"sender_id,omitempty"`
+	ReceiverId    string                 `protobuf:"bytes,3,opt,name=receiver_id,json=receiverId,proto3" json:"receiver_id,omitempty"`
+	Acceptance    bool                   `protobuf:"varint,4,opt,name=acceptance,proto3" json:"acceptance,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RespondFriendRequestRequest) Reset() {
+	*x = RespondFriendRequestRequest{}
+	mi := &file_gochat_proto_msgTypes[19]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RespondFriendRequestRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RespondFriendRequestRequest) ProtoMessage() {}
+
+func (x *RespondFriendRequestRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_gochat_proto_msgTypes[19]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RespondFriendRequestRequest.ProtoReflect.Descriptor instead.
+func (*RespondFriendRequestRequest) Descriptor() ([]byte, []int) {
+	return file_gochat_proto_rawDescGZIP(), []int{19}
+}
+
+func (x *RespondFriendRequestRequest) GetRequestId() string {
+	if x != nil {
+		return x.RequestId
+	}
+	return ""
+}
+
+func (x *RespondFriendRequestRequest) GetSenderId() string {
+	if x != nil {
+		return x.SenderId
+	}
+	return ""
+}
+
+func (x *RespondFriendRequestRequest) GetReceiverId() string {
+	if x != nil {
+		return x.ReceiverId
+	}
+	return ""
+}
+
+func (x *RespondFriendRequestRequest) GetAcceptance() bool {
+	if x != nil {
+		return x.Acceptance
+	}
+	return false
+}
+
+type FriendRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	RequestId     string                 `protobuf:"bytes,1,opt,name=request_id,json=requestId,proto3" json:"request_id,omitempty"`
+	SenderId      string                 `protobuf:"bytes,2,opt,name=sender_id,json=senderId,proto3" json:"sender_id,omitempty"`
+	ReceiverId    string                 `protobuf:"bytes,3,opt,name=receiver_id,json=receiverId,proto3" json:"receiver_id,omitempty"`
+	Status        string                 `protobuf:"bytes,4,opt,name=status,proto3" json:"status,omitempty"`
+	CreatedAt     *timestamppb.Timestamp `protobuf:"bytes,5,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	UpdatedAt     *timestamppb.Timestamp `protobuf:"bytes,6,opt,name=updated_at,json=updatedAt,proto3" json:"updated_at,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *FriendRequest) Reset() {
+	*x = FriendRequest{}
+	mi := &file_gochat_proto_msgTypes[20]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *FriendRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*FriendRequest) ProtoMessage() {}
+
+func (x *FriendRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_gochat_proto_msgTypes[20]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use FriendRequest.ProtoReflect.Descriptor instead.
+func (*FriendRequest) Descriptor() ([]byte, []int) {
+	return file_gochat_proto_rawDescGZIP(), []int{20}
+}
+
+func (x *FriendRequest) GetRequestId() string {
+	if x != nil {
+		return x.RequestId
+	}
+	return ""
+}
+
+func (x *FriendRequest) GetSenderId() string {
+	if x != nil {
+		return x.SenderId
+	}
+	return ""
+}
+
+func (x *FriendRequest) GetReceiverId() string {
+	if x != nil {
+		return x.ReceiverId
+	}
+	return ""
+}
+
+func (x *FriendRequest) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
+func (x *FriendRequest) GetCreatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return nil
+}
+
+func (x *FriendRequest) GetUpdatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.UpdatedAt
+	}
+	return nil
+}
+
+type GetFriendListsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	UserId        string                 `protobuf:"bytes,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetFriendListsRequest) Reset() {
+	*x = GetFriendListsRequest{}
+	mi := &file_gochat_proto_msgTypes[21]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetFriendListsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetFriendListsRequest) ProtoMessage() {}
+
+func (x *GetFriendListsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_gochat_proto_msgTypes[21]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetFriendListsRequest.ProtoReflect.Descriptor instead.
+func (*GetFriendListsRequest) Descriptor() ([]byte, []int) {
+	return file_gochat_proto_rawDescGZIP(), []int{21}
+}
+
+func (x *GetFriendListsRequest) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+type GetFriendListsResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	UserId        string                 `protobuf:"bytes,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	Friends       []string               `protobuf:"bytes,2,rep,name=friends,proto3" json:"friends,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetFriendListsResponse) Reset() {
+	*x = GetFriendListsResponse{}
+	mi := &file_gochat_proto_msgTypes[22]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetFriendListsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetFriendListsResponse) ProtoMessage() {}
+
+func (x *GetFriendListsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_gochat_proto_msgTypes[22]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetFriendListsResponse.ProtoReflect.Descriptor instead.
+func (*GetFriendListsResponse) Descriptor() ([]byte, []int) {
+	return file_gochat_proto_rawDescGZIP(), []int{22}
+}
+
+func (x *GetFriendListsResponse) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+func (x *GetFriendListsResponse) GetFriends() []string {
+	if x != nil {
+		return x.Friends
+	}
+	return nil
+}
+
+type GetFriendRequestsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	UserId        string                 `protobuf:"bytes,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetFriendRequestsRequest) Reset() {
+	*x = GetFriendRequestsRequest{}
+	mi := &file_gochat_proto_msgTypes[23]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetFriendRequestsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetFriendRequestsRequest) ProtoMessage() {}
+
+func (x *GetFriendRequestsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_gochat_proto_msgTypes[23]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetFriendRequestsRequest.ProtoReflect.Descriptor instead.
+func (*GetFriendRequestsRequest) Descriptor() ([]byte, []int) {
+	return file_gochat_proto_rawDescGZIP(), []int{23}
+}
+
+func (x *GetFriendRequestsRequest) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+type GetFriendRequestsResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Requests      []*FriendRequest       `protobuf:"bytes,1,rep,name=requests,proto3" json:"requests,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetFriendRequestsResponse) Reset() {
+	*x = GetFriendRequestsResponse{}
+	mi := &file_gochat_proto_msgTypes[24]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetFriendRequestsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetFriendRequestsResponse) ProtoMessage() {}
+
+func (x *GetFriendRequestsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_gochat_proto_msgTypes[24]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetFriendRequestsResponse.ProtoReflect.Descriptor instead.
+func (*GetFriendRequestsResponse) Descriptor() ([]byte, []int) {
+	return file_gochat_proto_rawDescGZIP(), []int{24}
+}
+
+func (x *GetFriendRequestsResponse) GetRequests() []*FriendRequest {
+	if x != nil {
+		return x.Requests
+	}
+	return nil
+}
+
+var File_gochat_proto protoreflect.FileDescriptor
+
+const file_gochat_proto_rawDesc = "" +
+	"\n" +
+	"\fgochat.proto\x12\tgochat.v1\x1a\x1fgoogle/protobuf/timestamp.proto\"x\n" +
+	"\x0fRegisterRequest\x12\x17\n" +
+	"\auser_id\x18\x01 \x01(\tR\x06userId\x12\x1a\n" +
+	"\busername\x18\x02 \x01(\tR\busername\x12\x14\n" +
+	"\x05email\x18\x03 \x01(\tR\x05email\x12\x1a\n" +
+	"\bpassword\x18\x04 \x01(\tR\bpassword\"J\n" +
+	"\fLoginRequest\x12\x1e\n" +
+	"\n" +
+	"identifier\x18\x01 \x01(\tR\n" +
+	"identifier\x12\x1a\n" +
+	"\bpassword\x18\x02 \x01(\tR\bpassword\"\xf1\x01\n" +
+	"\x04User\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x17\n" +
+	"\auser_id\x18\x02 \x01(\tR\x06userId\x12\x1a\n" +
+	"\busername\x18\x03 \x01(\tR\busername\x12\x14\n" +
+	"\x05email\x18\x04 \x01(\tR\x05email\x129\n" +
+	"\n" +
+	"created_at\x18\x05 \x01(\v2\x1a.google.protobuf.TimestampR\tcreatedAt\x129\n" +
+	"\n" +
+	"updated_at\x18\x06 \x01(\v2\x1a.google.protobuf.TimestampR\tupdatedAt\x12\x18\n" +
+	"\afriends\x18\a \x03(\tR\afriends\"\x8f\x06\n" +
+	"\aMessage\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x1b\n" +
+	"\tsender_id\x18\x02 \x01(\tR\bsenderId\x12\x1f\n" +
+	"\vreceiver_id\x18\x03 \x01(\tR\n" +
+	"receiverId\x12!\n" +
+	"\fmessage_text\x18\x04 \x01(\tR\vmessageText\x128\n" +
+	"\ttimestamp\x18\x05 \x01(\v2\x1a.google.protobuf.TimestampR\ttimestamp\x12 \n" +
+	"\fchat_room_id\x18\x06 \x01(\tR\n" +
+	"chatRoomId\x127\n" +
+	"\tedited_at\x18\a \x01(\v2\x1a.google.protobuf.TimestampR\beditedAt\x12\x18\n" +
+	"\adeleted\x18\b \x01(\bR\adeleted\x121\n" +
+	"\treactions\x18\t \x03(\v2\x13.gochat.v1.ReactionR\treactions\x12-\n" +
+	"\x13reply_to_message_id\x18\n" +
+	" \x01(\tR\x10replyToMessageId\x12$\n" +
+	"\x0ethread_root_id\x18\v \x01(\tR\fthreadRootId\x12\x1f\n" +
+	"\vreply_count\x18\f \x01(\x05R\n" +
+	"replyCount\x12>\n" +
+	"\rlast_reply_at\x18\r \x01(\v2\x1a.google.protobuf.TimestampR\vlastReplyAt\x12\x1a\n" +
+	"\bmentions\x18\x0e \x03(\tR\bmentions\x127\n" +
+	"\vattachments\x18\x0f \x03(\v2\x15.gochat.v1.AttachmentR\vattachments\x12\x10\n" +
+	"\x03seq\x18\x10 \x01(\x03R\x03seq\x129\n" +
+	"\n" +
+	"expires_at\x18\x11 \x01(\v2\x1a.google.protobuf.TimestampR\texpiresAt\x12*\n" +
+	"\x11expire_after_read\x18\x12 \x01(\x03R\x0fexpireAfterRead\x12-\n" +
+	"\x06notice\x18\x13 \x01(\v2\x15.gochat.v1.RoomNoticeR\x06notice\"p\n" +
+	"\n" +
+	"RoomNotice\x12\x12\n" +
+	"\x04type\x18\x01 \x01(\tR\x04type\x12'\n" +
+	"\x0fdisappear_after\x18\x02 \x01(\x03R\x0edisappearAfter\x12%\n" +
+	"\x0edisappear_from\x18\x03 \x01(\tR\rdisappearFrom\"Q\n" +
+	"\bReaction\x12\x14\n" +
+	"\x05emoji\x18\x01 \x01(\tR\x05emoji\x12\x14\n" +
+	"\x05count\x18\x02 \x01(\x05R\x05count\x12\x19\n" +
+	"\buser_ids\x18\x03 \x03(\tR\auserIds\"\xa3\x01\n" +
+	"\n" +
+	"Attachment\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x1b\n" +
+	"\tfile_name\x18\x02 \x01(\tR\bfileName\x12!\n" +
+	"\fcontent_type\x18\x03 \x01(\tR\vcontentType\x12\x12\n" +
+	"\x04size\x18\x04 \x01(\x03R\x04size\x121\n" +
+	"\apreview\x18\x05 \x01(\v2\x17.gochat.v1.ImagePreviewR\apreview\"\xa6\x01\n" +
+	"\fImagePreview\x12\x16\n" +
+	"\x06status\x18\x01 \x01(\tR\x06status\x12\x14\n" +
+	"\x05width\x18\x02 \x01(\x05R\x05width\x12\x16\n" +
+	"\x06height\x18\x03 \x01(\x05R\x06height\x12\x1a\n" +
+	"\bblurhash\x18\x04 \x01(\tR\bblurhash\x124\n" +
+	"\n" +
+	"thumbnails\x18\x05 \x03(\v2\x14.gochat.v1.ThumbnailR\n" +
+	"thumbnails\"p\n" +
+	"\tThumbnail\x12\x12\n" +
+	"\x04size\x18\x01 \x01(\x05R\x04size\x12\x14\n" +
+	"\x05width\x18\x02 \x01(\x05R\x05width\x12\x16\n" +
+	"\x06height\x18\x03 \x01(\x05R\x06height\x12!\n" +
+	"\fcontent_type\x18\x04 \x01(\tR\vcontentType\"\x9a\x02\n" +
+	"\x12GetMessagesRequest\x12 \n" +
+	"\fchat_room_id\x18\x01 \x01(\tR\n" +
+	"chatRoomId\x12\x14\n" +
+	"\x05limit\x18\x02 \x01(\x03R\x05limit\x12\x1b\n" +
+	"\tviewer_id\x18\x04 \x01(\tR\bviewerId\x12)\n" +
+	"\x10collapse_threads\x18\x05 \x01(\bR\x0fcollapseThreads\x12\x16\n" +
+	"\x06before\x18\x06 \x01(\tR\x06before\x12\x14\n" +
+	"\x05after\x18\a \x01(\tR\x05after\x12\x16\n" +
+	"\x06around\x18\b \x01(\tR\x06around\x12\x19\n" +
+	"\bfrom_seq\x18\t \x01(\x03R\afromSeq\x12\x15\n" +
+	"\x06to_seq\x18\n" +
+	" \x01(\x03R\x05toSeqJ\x04\b\x03\x10\x04R\x06offset\"\xdc\x01\n" +
+	"\x13GetMessagesResponse\x12.\n" +
+	"\bmessages\x18\x01 \x03(\v2\x12.gochat.v1.MessageR\bmessages\x12\x16\n" +
+	"\x06before\x18\x02 \x01(\tR\x06before\x12\x14\n" +
+	"\x05after\x18\x03 \x01(\tR\x05after\x12&\n" +
+	"\x0fhas_more_before\x18\x04 \x01(\bR\rhasMoreBefore\x12$\n" +
+	"\x0ehas_more_after\x18\x05 \x01(\bR\fhasMoreAfter\x12\x19\n" +
+	"\blast_seq\x18\x06 \x01(\x03R\alastSeq\"R\n" +
+	"\x1aGetOrCreateChatRoomRequest\x12\x17\n" +
+	"\auser_id\x18\x01 \x01(\tR\x06userId\x12\x1b\n" +
+	"\tfriend_id\x18\x02 \x01(\tR\bfriendId\"\x97\x01\n" +
+	"\bChatRoom\x12 \n" +
+	"\fchat_room_id\x18\x01 \x01(\tR\n" +
+	"chatRoomId\x12\x19\n" +
+	"\buser_ids\x18\x02 \x03(\tR\auserIds\x12'\n" +
+	"\x0fdisappear_after\x18\x03 \x01(\x03R\x0edisappearAfter\x12%\n" +
+	"\x0edisappear_from\x18\x04 \x01(\tR\rdisappearFrom\"\xed\x01\n" +
+	"\x12SendMessageRequest\x12 \n" +
+	"\fchat_room_id\x18\x01 \x01(\tR\n" +
+	"chatRoomId\x12\x1b\n" +
+	"\tsender_id\x18\x02 \x01(\tR\bsenderId\x12\x1f\n" +
+	"\vreceiver_id\x18\x03 \x01(\tR\n" +
+	"receiverId\x12!\n" +
+	"\fmessage_text\x18\x04 \x01(\tR\vmessageText\x12-\n" +
+	"\x13reply_to_message_id\x18\x05 \x01(\tR\x10replyToMessageId\x12%\n" +
+	"\x0eattachment_ids\x18\x06 \x03(\tR\rattachmentIds\"\x83\x01\n" +
+	"\x0fChatClientFrame\x12#\n" +
+	"\fsend_message\x18\x01 \x01(\tH\x00R\vsendMessage\x12B\n" +
+	"\fget_messages\x18\x02 \x01(\v2\x1d.gochat.v1.GetMessagesRequestH\x00R\vgetMessagesB\a\n" +
+	"\x05frame\"\xb0\x01\n" +
+	"\x0fChatServerFrame\x12,\n" +
+	"\x05event\x18\x01 \x01(\v2\x14.gochat.v1.RoomEventH\x00R\x05event\x12<\n" +
+	"\bmessages\x18\x02 \x01(\v2\x1e.gochat.v1.GetMessagesResponseH\x00R\bmessages\x12(\n" +
+	"\x05error\x18\x03 \x01(\v2\x10.gochat.v1.ErrorH\x00R\x05errorB\a\n" +
+	"\x05frame\"H\n" +
+	"\tRoomEvent\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x17\n" +
+	"\aroom_id\x18\x02 \x01(\tR\x06roomId\x12\x12\n" +
+	"\x04data\x18\x03 \x01(\fR\x04data\"[\n" +
+	"\x05Error\x12\x16\n" +
+	"\x06action\x18\x01 \x01(\tR\x06action\x12\x14\n" +
+	"\x05error\x18\x02 \x01(\tR\x05error\x12$\n" +
+	"\x0eretry_after_ms\x18\x03 \x01(\x03R\fretryAfterMs\"H\n" +
+	"\x10AddFriendRequest\x12\x17\n" +
+	"\auser_id\x18\x01 \x01(\tR\x06userId\x12\x1b\n" +
+	"\tfriend_id\x18\x02 \x01(\tR\bfriendId\"\x9a\x01\n" +
+	"\x1bRespondFriendRequestRequest\x12\x1d\n" +
+	"\n" +
+	"request_id\x18\x01 \x01(\tR\trequestId\x12\x1b\n" +
+	"\tsender_id\x18\x02 \x01(\tR\bsenderId\x12\x1f\n" +
+	"\vreceiver_id\x18\x03 \x01(\tR\n" +
+	"receiverId\x12\x1e\n" +
+	"\n" +
+	"acceptance\x18\x04 \x01(\bR\n" +
+	"acceptance\"\xfa\x01\n" +
+	"\rFriendRequest\x12\x1d\n" +
+	"\n" +
+	"request_id\x18\x01 \x01(\tR\trequestId\x12\x1b\n" +
+	"\tsender_id\x18\x02 \x01(\tR\bsenderId\x12\x1f\n" +
+	"\vreceiver_id\x18\x03 \x01(\tR\n" +
+	"receiverId\x12\x16\n" +
+	"\x06status\x18\x04 \x01(\tR\x06status\x129\n" +
+	"\n" +
+	"created_at\x18\x05 \x01(\v2\x1a.google.protobuf.TimestampR\tcreatedAt\x129\n" +
+	"\n" +
+	"updated_at\x18\x06 \x01(\v2\x1a.google.protobuf.TimestampR\tupdatedAt\"0\n" +
+	"\x15GetFriendListsRequest\x12\x17\n" +
+	"\auser_id\x18\x01 \x01(\tR\x06userId\"K\n" +
+	"\x16GetFriendListsResponse\x12\x17\n" +
+	"\auser_id\x18\x01 \x01(\tR\x06userId\x12\x18\n" +
+	"\afriends\x18\x02 \x03(\tR\afriends\"3\n" +
+	"\x18GetFriendRequestsRequest\x12\x17\n" +
+	"\auser_id\x18\x01 \x01(\tR\x06userId\"Q\n" +
+	"\x19GetFriendRequestsResponse\x124\n" +
+	"\brequests\x18\x01 \x03(\v2\x18.gochat.v1.FriendRequestR\brequests2y\n" +
+	"\vAuthService\x127\n" +
+	"\bRegister\x12\x1a.gochat.v1.RegisterRequest\x1a\x0f.gochat.v1.User\x121\n" +
+	"\x05Login\x12\x17.gochat.v1.LoginRequest\x1a\x0f.gochat.v1.User2\xb4\x02\n" +
+	"\vChatService\x12L\n" +
+	"\vGetMessages\x12\x1d.gochat.v1.GetMessagesRequest\x1a\x1e.gochat.v1.GetMessagesResponse\x12Q\n" +
+	"\x13GetOrCreateChatRoom\x12%.gochat.v1.GetOrCreateChatRoomRequest\x1a\x13.gochat.v1.ChatRoom\x12@\n" +
+	"\vSendMessage\x12\x1d.gochat.v1.SendMessageRequest\x1a\x12.gochat.v1.Message\x12B\n" +
+	"\x04Chat\x12\x1a.gochat.v1.ChatClientFrame\x1a\x1a.gochat.v1.ChatServerFrame(\x010\x012\xe2\x02\n" +
+	"\vUserService\x12B\n" +
+	"\tAddFriend\x12\x1b.gochat.v1.AddFriendRequest\x1a\x18.gochat.v1.FriendRequest\x12X\n" +
+	"\x14RespondFriendRequest\x12&.gochat.v1.RespondFriendRequestRequest\x1a\x18.gochat.v1.FriendRequest\x12U\n" +
+	"\x0eGetFriendLists\x12 .gochat.v1.GetFriendListsRequest\x1a!.gochat.v1.GetFriendListsResponse\x12^\n" +
+	"\x11GetFriendRequests\x12#.gochat.v1.GetFriendRequestsRequest\x1a$.gochat.v1.GetFriendRequestsResponseB\x10Z\x0ego-chat/pkg/pbb\x06proto3"
+
+var (
+	file_gochat_proto_rawDescOnce sync.Once
+	file_gochat_proto_rawDescData []byte
+)
+
+func file_gochat_proto_rawDescGZIP() []byte {
+	file_gochat_proto_rawDescOnce.Do(func() {
+		file_gochat_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_gochat_proto_rawDesc), len(file_gochat_proto_rawDesc)))
+	})
+	return file_gochat_proto_rawDescData
+}
+
+var file_gochat_proto_msgTypes = make([]protoimpl.MessageInfo, 25)
+var file_gochat_proto_goTypes = []any{
+	(*RegisterRequest)(nil),             // 0: gochat.v1.RegisterRequest
+	(*LoginRequest)(nil),                // 1: gochat.v1.LoginRequest
+	(*User)(nil),                        // 2: gochat.v1.User
+	(*Message)(nil),                     // 3: gochat.v1.Message
+	(*RoomNotice)(nil),                  // 4: gochat.v1.RoomNotice
+	(*Reaction)(nil),                    // 5: gochat.v1.Reaction
+	(*Attachment)(nil),                  // 6: gochat.v1.Attachment
+	(*ImagePreview)(nil),                // 7: gochat.v1.ImagePreview
+	(*Thumbnail)(nil),                   // 8: gochat.v1.Thumbnail
+	(*GetMessagesRequest)(nil),          // 9: gochat.v1.GetMessagesRequest
+	(*GetMessagesResponse)(nil),         // 10: gochat.v1.GetMessagesResponse
+	(*GetOrCreateChatRoomRequest)(nil),  // 11: gochat.v1.GetOrCreateChatRoomRequest
+	(*ChatRoom)(nil),                    // 12: gochat.v1.ChatRoom
+	(*SendMessageRequest)(nil),          // 13: gochat.v1.SendMessageRequest
+	(*ChatClientFrame)(nil),             // 14: gochat.v1.ChatClientFrame
+	(*ChatServerFrame)(nil),             // 15: gochat.v1.ChatServerFrame
+	(*RoomEvent)(nil),                   // 16: gochat.v1.RoomEvent
+	(*Error)(nil),                       // 17: gochat.v1.Error
+	(*AddFriendRequest)(nil),            // 18: gochat.v1.AddFriendRequest
+	(*RespondFriendRequestRequest)(nil), // 19: gochat.v1.RespondFriendRequestRequest
+	(*FriendRequest)(nil),               // 20: gochat.v1.FriendRequest
+	(*GetFriendListsRequest)(nil),       // 21: gochat.v1.GetFriendListsRequest
+	(*GetFriendListsResponse)(nil),      // 22: gochat.v1.GetFriendListsResponse
+	(*GetFriendRequestsRequest)(nil),    // 23: gochat.v1.GetFriendRequestsRequest
+	(*GetFriendRequestsResponse)(nil),   // 24: gochat.v1.GetFriendRequestsResponse
+	(*timestamppb.Timestamp)(nil),       // 25: google.protobuf.Timestamp
+}
+var file_gochat_proto_depIdxs = []int32{
+	25, // 0: gochat.v1.User.created_at:type_name -> google.protobuf.Timestamp
+	25, // 1: gochat.v1.User.updated_at:type_name -> google.protobuf.Timestamp
+	25, // 2: gochat.v1.Message.timestamp:type_name -> google.protobuf.Timestamp
+	25, // 3: gochat.v1.Message.edited_at:type_name -> google.protobuf.Timestamp
+	5,  // 4: gochat.v1.Message.reactions:type_name -> gochat.v1.Reaction
+	25, // 5: gochat.v1.Message.last_reply_at:type_name -> google.protobuf.Timestamp
+	6,  // 6: gochat.v1.Message.attachments:type_name -> gochat.v1.Attachment
+	25, // 7: gochat.v1.Message.expires_at:type_name -> google.protobuf.Timestamp
+	4,  // 8: gochat.v1.Message.notice:type_name -> gochat.v1.RoomNotice
+	7,  // 9: gochat.v1.Attachment.preview:type_name -> gochat.v1.ImagePreview
+	8,  // 10: gochat.v1.ImagePreview.thumbnails:type_name -> gochat.v1.Thumbnail
+	3,  // 11: gochat.v1.GetMessagesResponse.messages:type_name -> gochat.v1.Message
+	9,  // 12: gochat.v1.ChatClientFrame.get_messages:type_name -> gochat.v1.GetMessagesRequest
+	16, // 13: gochat.v1.ChatServerFrame.event:type_name -> gochat.v1.RoomEvent
+	10, // 14: gochat.v1.ChatServerFrame.messages:type_name -> gochat.v1.GetMessagesResponse
+	17, // 15: gochat.v1.ChatServerFrame.error:type_name -> gochat.v1.Error
+	25, // 16: gochat.v1.FriendRequest.created_at:type_name -> google.protobuf.Timestamp
+	25, // 17: gochat.v1.FriendRequest.updated_at:type_name -> google.protobuf.Timestamp
+	20, // 18: gochat.v1.GetFriendRequestsResponse.requests:type_name -> gochat.v1.FriendRequest
+	0,  // 19: gochat.v1.AuthService.Register:input_type -> gochat.v1.RegisterRequest
+	1,  // 20: gochat.v1.AuthService.Login:input_type -> gochat.v1.LoginRequest
+	9,  // 21: gochat.v1.ChatService.GetMessages:input_type -> gochat.v1.GetMessagesRequest
+	11, // 22: gochat.v1.ChatService.GetOrCreateChatRoom:input_type -> gochat.v1.GetOrCreateChatRoomRequest
+	13, // 23: gochat.v1.ChatService.SendMessage:input_type -> gochat.v1.SendMessageRequest
+	14, // 24: gochat.v1.ChatService.Chat:input_type -> gochat.v1.ChatClientFrame
+	18, // 25: gochat.v1.UserService.AddFriend:input_type -> gochat.v1.AddFriendRequest
+	19, // 26: gochat.v1.UserService.RespondFriendRequest:input_type -> gochat.v1.RespondFriendRequestRequest
+	21, // 27: gochat.v1.UserService.GetFriendLists:input_type -> gochat.v1.GetFriendListsRequest
+	23, // 28: gochat.v1.UserService.GetFriendRequests:input_type -> gochat.v1.GetFriendRequestsRequest
+	2,  // 29: gochat.v1.AuthService.Register:output_type -> gochat.v1.User
+	2,  // 30: gochat.v1.AuthService.Login:output_type -> gochat.v1.User
+	10, // 31: gochat.v1.ChatService.GetMessages:output_type -> gochat.v1.GetMessagesResponse
+	12, // 32: gochat.v1.ChatService.GetOrCreateChatRoom:output_type -> gochat.v1.ChatRoom
+	3,  // 33: gochat.v1.ChatService.SendMessage:output_type -> gochat.v1.Message
+	15, // 34: gochat.v1.ChatService.Chat:output_type -> gochat.v1.ChatServerFrame
+	20, // 35: gochat.v1.UserService.AddFriend:output_type -> gochat.v1.FriendRequest
+	20, // 36: gochat.v1.UserService.RespondFriendRequest:output_type -> gochat.v1.FriendRequest
+	22, // 37: gochat.v1.UserService.GetFriendLists:output_type -> gochat.v1.GetFriendListsResponse
+	24, // 38: gochat.v1.UserService.GetFriendRequests:output_type -> gochat.v1.GetFriendRequestsResponse
+	29, // [29:39] is the sub-list for method output_type
+	19, // [19:29] is the sub-list for method input_type
+	19, // [19:19] is the sub-list for extension type_name
+	19, // [19:19] is the sub-list for extension extendee
+	0,  // [0:19] is the sub-list for field type_name
+}
+
+func init() { file_gochat_proto_init() }
+func file_gochat_proto_init() {
+	if File_gochat_proto != nil {
+		return
+	}
+	file_gochat_proto_msgTypes[14].OneofWrappers = []any{
+		(*ChatClientFrame_SendMessage)(nil),
+		(*ChatClientFrame_GetMessages)(nil),
+	}
+	file_gochat_proto_msgTypes[15].OneofWrappers = []any{
+		(*ChatServerFrame_Event)(nil),
+		(*ChatServerFrame_Messages)(nil),
+		(*ChatServerFrame_Error)(nil),
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_gochat_proto_rawDesc), len(file_gochat_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   25,
+			NumExtensions: 0,
+			NumServices:   3,
+		},
+		GoTypes:           file_gochat_proto_goTypes,
+		DependencyIndexes: file_gochat_proto_depIdxs,
+		MessageInfos:      file_gochat_proto_msgTypes,
+	}.Build()
+	File_gochat_proto = out.File
+	file_gochat_proto_goTypes = nil
+	file_gochat_proto_depIdxs = nil
+}
@@ -0,0 +1,21 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+)
+
+// ErrBlobNotFound is returned by BlobStore.Get when no blob has the key.
+var ErrBlobNotFound = errors.New("blob not found")
+
+// BlobStore keeps uploaded content by key. Keys are chosen by the caller and
+// are plain file names, without path separators.
+type BlobStore interface {
+	// Put stores size bytes read from r under key, replacing any blob that
+	// already has it.
+	Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	Exists(ctx context.Context, key string) (bool, error)
+	Delete(ctx context.Context, key string) error
+}
@@ -0,0 +1,123 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+)
+
+type S3Config struct {
+	// Endpoint is the host and port of the S3 API, for example
+	// "s3.amazonaws.com" or "localhost:9100" for a local MinIO.
+	Endpoint  string
+	Region    string
+	Bucket    string
+	AccessKey string
+	SecretKey string
+	UseSSL    bool
+}
+
+// S3BlobStore keeps blobs as objects in a bucket of any S3-compatible service.
+type S3BlobStore struct {
+	client *minio.Client
+	bucket string
+}
+
+// NewS3BlobStore connects to config.Endpoint and creates the bucket when it
+// doesn't exist yet.
+func NewS3BlobStore(ctx context.Context, config S3Config) (*S3BlobStore, error) {
+	client, err := minio.New(config.Endpoint, &minio.Options{
+		Creds:  credentials.NewStaticV4(config.AccessKey, config.SecretKey, ""),
+		Secure: config.UseSSL,
+		Region: config.Region,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	exists, err := client.BucketExists(ctx, config.Bucket)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		err = client.MakeBucket(ctx, config.Bucket, minio.MakeBucketOptions{Region: config.Region})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return &S3BlobStore{client: client, bucket: config.Bucket}, nil
+}
+
+// Put fails content longer than size before the upload completes, the client
+// would otherwise store its first size bytes and ignore the rest.
+func (s *S3BlobStore) Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error {
+	content := &sizedReader{r: r, key: key, size: size, remaining: size}
+	_, err := s.client.PutObject(ctx, s.bucket, key, content, size, minio.PutObjectOptions{
+		ContentType: contentType,
+	})
+	return err
+}
+
+// Get stats the object before returning it, the client only reports a
+// missing object on the first read otherwise.
+func (s *S3BlobStore) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	object, err := s.client.GetObject(ctx, s.bucket, key, minio.GetObjectOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := object.Stat(); err != nil {
+		object.Close()
+		if minio.ToErrorResponse(err).Code == minio.NoSuchKey {
+			return nil, ErrBlobNotFound
+		}
+		return nil, err
+	}
+	return object, nil
+}
+
+func (s *S3BlobStore) Exists(ctx context.Context, key string) (bool, error) {
+	_, err := s.client.StatObject(ctx, s.bucket, key, minio.StatObjectOptions{})
+	if minio.ToErrorResponse(err).Code == minio.NoSuchKey {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+func (s *S3BlobStore) Delete(ctx context.Context, key string) error {
+	return s.client.RemoveObject(ctx, s.bucket, key, minio.RemoveObjectOptions{})
+}
+
+// sizedReader reads size bytes from r and fails the read that would complete
+// them if r has more.
+type sizedReader struct {
+	r         io.Reader
+	key       string
+	size      int64
+	remaining int64
+}
+
+func (s *sizedReader) Read(p []byte) (int, error) {
+	if s.remaining <= 0 {
+		return 0, io.EOF
+	}
+	if int64(len(p)) > s.remaining {
+		p = p[:s.remaining]
+	}
+
+	n, err := s.r.Read(p)
+	s.remaining -= int64(n)
+	if s.remaining == 0 {
+		var extra [1]byte
+		if m, _ := io.ReadFull(s.r, extra[:]); m > 0 {
+			// the bytes are held back too, readers filling a buffer with
+			// io.ReadFull ignore an error that comes with the last of them
+			return 0, fmt.Errorf("blob %s: content is longer than %d bytes", s.key, s.size)
+		}
+	}
+	return n, err
+}
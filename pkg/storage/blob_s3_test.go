@@ -0,0 +1,196 @@
+package storage
+
+import (
+	"bufio"
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// fakeS3 serves the handful of S3 calls S3BlobStore makes, path style and
+// without checking signatures.
+type fakeS3 struct {
+	mu      sync.Mutex
+	buckets map[string]map[string]fakeObject
+}
+
+type fakeObject struct {
+	data        []byte
+	contentType string
+}
+
+type fakeS3Error struct {
+	XMLName    xml.Name `xml:"Error"`
+	Code       string
+	Message    string
+	BucketName string
+	Key        string
+}
+
+func newFakeS3() *fakeS3 {
+	return &fakeS3{buckets: make(map[string]map[string]fakeObject)}
+}
+
+func (s *fakeS3) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	bucket, key, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/"), "/")
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if key == "" {
+		s.serveBucket(w, r, bucket)
+		return
+	}
+
+	objects, ok := s.buckets[bucket]
+	if !ok {
+		writeS3Error(w, r, http.StatusNotFound, "NoSuchBucket", bucket, key)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodPut:
+		data, err := readS3Body(r)
+		if err != nil {
+			writeS3Error(w, r, http.StatusBadRequest, "IncompleteBody", bucket, key)
+			return
+		}
+		objects[key] = fakeObject{data: data, contentType: r.Header.Get("Content-Type")}
+		w.Header().Set("ETag", etag(data))
+	case http.MethodGet, http.MethodHead:
+		object, ok := objects[key]
+		if !ok {
+			writeS3Error(w, r, http.StatusNotFound, "NoSuchKey", bucket, key)
+			return
+		}
+		w.Header().Set("ETag", etag(object.data))
+		w.Header().Set("Content-Type", object.contentType)
+		w.Header().Set("Content-Length", strconv.Itoa(len(object.data)))
+		w.Header().Set("Last-Modified", "Mon, 19 Oct 2026 12:00:00 GMT")
+		if r.Method == http.MethodGet {
+			w.Write(object.data)
+		}
+	case http.MethodDelete:
+		delete(objects, key)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		writeS3Error(w, r, http.StatusNotImplemented, "NotImplemented", bucket, key)
+	}
+}
+
+func (s *fakeS3) serveBucket(w http.ResponseWriter, r *http.Request, bucket string) {
+	switch r.Method {
+	case http.MethodHead:
+		if _, ok := s.buckets[bucket]; !ok {
+			writeS3Error(w, r, http.StatusNotFound, "NoSuchBucket", bucket, "")
+		}
+	case http.MethodPut:
+		s.buckets[bucket] = make(map[string]fakeObject)
+	default:
+		writeS3Error(w, r, http.StatusNotImplemented, "NotImplemented", bucket, "")
+	}
+}
+
+// readS3Body reads an object's content, decoding it when the client signed it
+// in aws-chunked form.
+func readS3Body(r *http.Request) ([]byte, error) {
+	if !strings.HasPrefix(r.Header.Get("X-Amz-Content-Sha256"), "STREAMING-") {
+		return io.ReadAll(r.Body)
+	}
+
+	var data []byte
+	body := bufio.NewReader(r.Body)
+	for {
+		line, err := body.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+		sizeHex, _, _ := strings.Cut(strings.TrimSpace(line), ";")
+		size, err := strconv.ParseInt(sizeHex, 16, 64)
+		if err != nil {
+			return nil, err
+		}
+		if size == 0 {
+			break
+		}
+
+		chunk := make([]byte, size+2)
+		if _, err := io.ReadFull(body, chunk); err != nil {
+			return nil, err
+		}
+		data = append(data, chunk[:size]...)
+	}
+
+	decoded := r.Header.Get("X-Amz-Decoded-Content-Length")
+	if decoded != "" && decoded != strconv.Itoa(len(data)) {
+		return nil, fmt.Errorf("got %d bytes, want %s", len(data), decoded)
+	}
+	return data, nil
+}
+
+func writeS3Error(w http.ResponseWriter, r *http.Request, status int, code string, bucket string, key string) {
+	w.Header().Set("Content-Type", "application/xml")
+	w.WriteHeader(status)
+	if r.Method != http.MethodHead {
+		xml.NewEncoder(w).Encode(fakeS3Error{Code: code, Message: code, BucketName: bucket, Key: key})
+	}
+}
+
+func etag(data []byte) string {
+	sum := md5.Sum(data)
+	return `"` + hex.EncodeToString(sum[:]) + `"`
+}
+
+func TestS3BlobStore(t *testing.T) {
+	server := httptest.NewServer(newFakeS3())
+	defer server.Close()
+
+	endpoint, err := url.Parse(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	store, err := NewS3BlobStore(context.Background(), S3Config{
+		Endpoint:  endpoint.Host,
+		Region:    "us-east-1",
+		Bucket:    "attachments",
+		AccessKey: "test",
+		SecretKey: "testsecret",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	testBlobStore(t, store)
+}
+
+// TestS3BlobStoreMinIO runs the same checks against a real S3 service when
+// S3_TEST_ENDPOINT points to one, a local MinIO for instance.
+func TestS3BlobStoreMinIO(t *testing.T) {
+	endpoint := os.Getenv("S3_TEST_ENDPOINT")
+	if endpoint == "" {
+		t.Skip("S3_TEST_ENDPOINT is not set")
+	}
+
+	store, err := NewS3BlobStore(context.Background(), S3Config{
+		Endpoint:  endpoint,
+		Region:    os.Getenv("S3_TEST_REGION"),
+		Bucket:    "gochat-test",
+		AccessKey: os.Getenv("S3_TEST_ACCESS_KEY"),
+		SecretKey: os.Getenv("S3_TEST_SECRET_KEY"),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	testBlobStore(t, store)
+}
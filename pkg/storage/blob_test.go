@@ -0,0 +1,104 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+// testBlobStore checks the BlobStore contract every implementation follows.
+func testBlobStore(t *testing.T, store BlobStore) {
+	ctx := context.Background()
+
+	put := func(key string, content string) {
+		t.Helper()
+		if err := store.Put(ctx, key, strings.NewReader(content), int64(len(content)), "text/plain"); err != nil {
+			t.Fatalf("put %s: %v", key, err)
+		}
+	}
+	expectContent := func(key string, want string) {
+		t.Helper()
+		r, err := store.Get(ctx, key)
+		if err != nil {
+			t.Fatalf("get %s: %v", key, err)
+		}
+		defer r.Close()
+		got, err := io.ReadAll(r)
+		if err != nil {
+			t.Fatalf("read %s: %v", key, err)
+		}
+		if string(got) != want {
+			t.Fatalf("got %q under %s, want %q", got, key, want)
+		}
+	}
+	expectMissing := func(key string) {
+		t.Helper()
+		exists, err := store.Exists(ctx, key)
+		if err != nil {
+			t.Fatalf("exists %s: %v", key, err)
+		}
+		if exists {
+			t.Fatalf("%s exists", key)
+		}
+		if _, err := store.Get(ctx, key); !errors.Is(err, ErrBlobNotFound) {
+			t.Fatalf("get %s returned %v, want %v", key, err, ErrBlobNotFound)
+		}
+	}
+
+	expectMissing("abc123")
+
+	put("abc123", "hello")
+	exists, err := store.Exists(ctx, "abc123")
+	if err != nil || !exists {
+		t.Fatalf("exists after put returned %v, %v", exists, err)
+	}
+	expectContent("abc123", "hello")
+
+	put("abc123", "replaced")
+	expectContent("abc123", "replaced")
+
+	if err := store.Delete(ctx, "abc123"); err != nil {
+		t.Fatal(err)
+	}
+	expectMissing("abc123")
+	if err := store.Delete(ctx, "abc123"); err != nil {
+		t.Fatalf("deleting a missing blob returned %v", err)
+	}
+
+	// a size that doesn't match the content fails the put and stores nothing
+	short := bytes.NewReader([]byte("hello"))
+	if err := store.Put(ctx, "def456", short, 10, "text/plain"); err == nil {
+		t.Fatal("put of less content than its size succeeded")
+	}
+	expectMissing("def456")
+
+	long := bytes.NewReader([]byte("hello world"))
+	if err := store.Put(ctx, "def456", long, 5, "text/plain"); err == nil {
+		t.Fatal("put of more content than its size succeeded")
+	}
+	expectMissing("def456")
+}
+
+func TestFileBlobStore(t *testing.T) {
+	store, err := NewFileBlobStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	testBlobStore(t, store)
+}
+
+func TestFileBlobStoreRejectsPathKeys(t *testing.T) {
+	store, err := NewFileBlobStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, key := range []string{"", "ab", "../etc", `a\b\c`, ".hidden"} {
+		if err := store.Put(context.Background(), key, strings.NewReader("x"), 1, "text/plain"); err == nil {
+			t.Fatalf("put accepted key %q", key)
+		}
+	}
+}
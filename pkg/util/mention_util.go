@@ -0,0 +1,28 @@
+package util
+
+import (
+	"regexp"
+	"strings"
+)
+
+// mentionPattern matches "@user_id" at the start of the text or after a
+// character that can't be part of a word, so email addresses aren't mentions.
+var mentionPattern = regexp.MustCompile(`(?:^|[^\w@])@([\w.-]+)`)
+
+// ParseMentions returns the user IDs mentioned in text, in order of first
+// appearance and without duplicates.
+func ParseMentions(text string) []string {
+	var mentions []string
+	seen := make(map[string]bool)
+
+	for _, match := range mentionPattern.FindAllStringSubmatch(text, -1) {
+		// punctuation ending a sentence isn't part of the user ID
+		userID := strings.TrimRight(match[1], ".-")
+		if userID == "" || seen[userID] {
+			continue
+		}
+		seen[userID] = true
+		mentions = append(mentions, userID)
+	}
+	return mentions
+}
@@ -0,0 +1,93 @@
+package util
+
+import (
+	"log"
+	"net/url"
+	"os"
+	"strings"
+)
+
+// defaultAllowedOrigin keeps the local frontend working when ALLOWED_ORIGINS is unset.
+const defaultAllowedOrigin = "http://localhost:3000"
+
+// OriginPolicy decides which browser origins may call the API and open
+// websocket connections. Entries are either exact origins such as
+// https://chat.example.com or wildcard subdomains such as https://*.example.com.
+type OriginPolicy struct {
+	exact     map[string]bool
+	wildcards []originPattern
+	devMode   bool
+}
+
+type originPattern struct {
+	scheme string
+	suffix string
+	port   string
+}
+
+func NewOriginPolicy(allowed []string, devMode bool) *OriginPolicy {
+	policy := &OriginPolicy{
+		exact:   make(map[string]bool),
+		devMode: devMode,
+	}
+
+	for _, origin := range allowed {
+		origin = strings.TrimSuffix(strings.TrimSpace(origin), "/")
+		if origin == "" {
+			continue
+		}
+
+		if !strings.Contains(origin, "://*.") {
+			policy.exact[strings.ToLower(origin)] = true
+			continue
+		}
+
+		u, err := url.Parse(strings.Replace(origin, "*.", "", 1))
+		if err != nil {
+			log.Printf("Ignoring invalid allowed origin %q: %v", origin, err)
+			continue
+		}
+		policy.wildcards = append(policy.wildcards, originPattern{
+			scheme: u.Scheme,
+			suffix: "." + strings.ToLower(u.Hostname()),
+			port:   u.Port(),
+		})
+	}
+
+	return policy
+}
+
+// NewOriginPolicyFromEnv reads a comma separated ALLOWED_ORIGINS list. With
+// ORIGINS_DEV_MODE=true any localhost origin is accepted as well.
+func NewOriginPolicyFromEnv() *OriginPolicy {
+	allowed := []string{defaultAllowedOrigin}
+	if env := os.Getenv("ALLOWED_ORIGINS"); env != "" {
+		allowed = strings.Split(env, ",")
+	}
+
+	return NewOriginPolicy(allowed, os.Getenv("ORIGINS_DEV_MODE") == "true")
+}
+
+func (p *OriginPolicy) Allowed(origin string) bool {
+	if p.exact[strings.ToLower(origin)] {
+		return true
+	}
+
+	u, err := url.Parse(origin)
+	if err != nil || u.Host == "" {
+		return false
+	}
+	host := strings.ToLower(u.Hostname())
+
+	if p.devMode && (host == "localhost" || host == "127.0.0.1" || host == "::1") {
+		return true
+	}
+
+	for _, pattern := range p.wildcards {
+		if u.Scheme == pattern.scheme && u.Port() == pattern.port && strings.HasSuffix(host, pattern.suffix) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,123 @@
+package util
+
+import (
+	"html"
+	"strings"
+	"unicode"
+)
+
+// SearchTerms returns the lower case words a text search query matches,
+// without duplicates. Quoted phrases contribute their words, negated words
+// ("-word") are left out since matching messages don't contain them.
+func SearchTerms(query string) []string {
+	var terms []string
+	seen := make(map[string]bool)
+
+	for i, part := range strings.Split(query, `"`) {
+		// odd parts are inside quotes
+		tokens := []string{part}
+		if i%2 == 0 {
+			tokens = strings.Fields(part)
+		}
+
+		for _, token := range tokens {
+			if i%2 == 0 && strings.HasPrefix(token, "-") {
+				continue
+			}
+
+			runes := []rune(token)
+			for _, span := range wordSpans(runes) {
+				term := strings.ToLower(string(runes[span[0]:span[1]]))
+				if !seen[term] {
+					seen[term] = true
+					terms = append(terms, term)
+				}
+			}
+		}
+	}
+	return terms
+}
+
+// HighlightSnippet returns an excerpt of text of about length characters
+// around the first word matching one of terms, HTML escaped and with the
+// matching words wrapped in <mark> tags. It returns "" when no word matches.
+func HighlightSnippet(text string, terms []string, length int) string {
+	match := make(map[string]bool, len(terms))
+	for _, term := range terms {
+		match[term] = true
+	}
+
+	runes := []rune(text)
+	words := wordSpans(runes)
+
+	first := -1
+	for i, word := range words {
+		if match[strings.ToLower(string(runes[word[0]:word[1]]))] {
+			first = i
+			break
+		}
+	}
+	if first < 0 {
+		return ""
+	}
+
+	// the first match sits a third into the excerpt, which starts and ends
+	// on whole words where it can
+	start := max(words[first][0]-length/3, 0)
+	end := min(start+length, len(runes))
+	for _, word := range words[:first] {
+		if word[0] < start && start < word[1] {
+			start = word[1]
+		}
+	}
+	for _, word := range words[first+1:] {
+		if word[0] < end && end < word[1] {
+			end = word[0]
+		}
+	}
+	// a match longer than the excerpt is kept whole rather than cut unmarked
+	end = max(end, words[first][1])
+
+	var b strings.Builder
+	pos := start
+	for _, word := range words {
+		if word[0] < start || word[1] > end || !match[strings.ToLower(string(runes[word[0]:word[1]]))] {
+			continue
+		}
+		b.WriteString(html.EscapeString(string(runes[pos:word[0]])))
+		b.WriteString("<mark>")
+		b.WriteString(html.EscapeString(string(runes[word[0]:word[1]])))
+		b.WriteString("</mark>")
+		pos = word[1]
+	}
+	b.WriteString(html.EscapeString(string(runes[pos:end])))
+
+	snippet := strings.TrimSpace(b.String())
+	if start > 0 {
+		snippet = "…" + snippet
+	}
+	if end < len(runes) {
+		snippet += "…"
+	}
+	return snippet
+}
+
+// wordSpans returns the start and end of the words in runes, split the way
+// MongoDB's text index splits them: on anything but letters and digits.
+func wordSpans(runes []rune) [][2]int {
+	var spans [][2]int
+	start := -1
+	for i, r := range runes {
+		inWord := unicode.IsLetter(r) || unicode.IsDigit(r) || unicode.IsMark(r)
+		if inWord && start < 0 {
+			start = i
+		} else if !inWord && start >= 0 {
+			spans = append(spans, [2]int{start, i})
+			start = -1
+		}
+	}
+	if start >= 0 {
+		spans = append(spans, [2]int{start, len(runes)})
+	}
+	return spans
+}
@@ -0,0 +1,23 @@
+package util
+
+import "testing"
+
+func TestHighlightSnippet(t *testing.T) {
+	tests := []struct {
+		text   string
+		terms  []string
+		length int
+		want   string
+	}{
+		{"hello there world", []string{"there"}, 100, "hello <mark>there</mark> world"},
+		{"no match here", []string{"missing"}, 100, ""},
+		{"a <b> tag", []string{"tag"}, 100, "a &lt;b&gt; <mark>tag</mark>"},
+		{"supercalifragilisticexpialidocious hello", []string{"supercalifragilisticexpialidocious"}, 10, "<mark>supercalifragilisticexpialidocious</mark>…"},
+	}
+
+	for _, tt := range tests {
+		if got := HighlightSnippet(tt.text, tt.terms, tt.length); got != tt.want {
+			t.Errorf("HighlightSnippet(%q, %q, %d) = %q, want %q", tt.text, tt.terms, tt.length, got, tt.want)
+		}
+	}
+}
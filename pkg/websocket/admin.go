@@ -0,0 +1,95 @@
+package websocket
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+var ErrHubStopped = errors.New("hub is not running")
+
+// ConnectionInfo identifies a subscriber and the connection behind it.
+type ConnectionInfo struct {
+	ID          string
+	UserID      string
+	RoomID      string
+	RemoteAddr  string
+	Transport   string
+	Protocol    string
+	ConnectedAt time.Time
+}
+
+type ConnectionSnapshot struct {
+	ConnectionInfo
+	Queue QueueStats
+}
+
+type HubSnapshot struct {
+	Connections []ConnectionSnapshot
+	Rooms       map[string]int
+	Users       map[string]int
+}
+
+// Snapshot reports every subscriber of this Hub. It is assembled by the Hub
+// goroutine itself, so it never races with registrations or deliveries.
+func (h *Hub) Snapshot(ctx context.Context) (snapshot HubSnapshot, err error) {
+	err = h.do(ctx, func() {
+		snapshot = HubSnapshot{
+			Connections: make([]ConnectionSnapshot, 0, len(h.clients)),
+			Rooms:       make(map[string]int),
+			Users:       make(map[string]int),
+		}
+
+		for sub := range h.clients {
+			info := sub.Info()
+			snapshot.Connections = append(snapshot.Connections, ConnectionSnapshot{
+				ConnectionInfo: info,
+				Queue:          sub.Stats(),
+			})
+			snapshot.Rooms[info.RoomID]++
+			snapshot.Users[info.UserID]++
+		}
+	})
+	return snapshot, err
+}
+
+// Kick disconnects the connection with connectionID, or every connection of
+// userID when connectionID is empty, and returns how many were closed.
+func (h *Hub) Kick(ctx context.Context, connectionID string, userID string) (kicked int, err error) {
+	err = h.do(ctx, func() {
+		for sub := range h.clients {
+			info := sub.Info()
+			if connectionID != "" && info.ID != connectionID {
+				continue
+			}
+			if connectionID == "" && info.UserID != userID {
+				continue
+			}
+			h.disconnect(sub, websocket.ClosePolicyViolation, "disconnected by an administrator")
+			kicked++
+		}
+	})
+	return kicked, err
+}
+
+// do runs fn on the Hub goroutine and waits for it to finish.
+func (h *Hub) do(ctx context.Context, fn func()) error {
+	done := make(chan struct{})
+	task := func() {
+		fn()
+		close(done)
+	}
+
+	select {
+	case h.tasks <- task:
+	case <-h.done:
+		return ErrHubStopped
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
+	<-done
+	return nil
+}
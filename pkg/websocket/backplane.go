@@ -0,0 +1,151 @@
+package websocket
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// RoomEvent is a payload addressed to every client connected to a chat room,
+// whichever server instance that client happens to be attached to. Events
+// with a UserID go to all of that user's connections instead, in any room.
+type RoomEvent struct {
+	ID     string `bson:"event_id"`
+	RoomID string `bson:"room_id"`
+	UserID string `bson:"user_id"`
+	// Key marks events that supersede each other, such as repeated presence
+	// updates, so queues under PolicyCoalesce keep only the latest one.
+	Key  string `bson:"key"`
+	Data []byte `bson:"data"`
+}
+
+// Backplane carries room events between Hubs. Every Hub publishes through it
+// and delivers whatever it receives from its subscription, including its own
+// events, so a single-instance deployment and a multi-instance one behave the same.
+type Backplane interface {
+	Publish(ctx context.Context, event RoomEvent) error
+	Subscribe(ctx context.Context) (<-chan RoomEvent, error)
+	Close() error
+}
+
+// Backplane subscriptions that fail are retried after a delay that doubles
+// from minRetryDelay up to maxRetryDelay.
+var (
+	minRetryDelay = time.Second
+	maxRetryDelay = 30 * time.Second
+)
+
+func nextRetryDelay(delay time.Duration) time.Duration {
+	return min(delay*2, maxRetryDelay)
+}
+
+// ErrBackplaneClosed is returned by a MemoryBackplane once Close was called.
+var ErrBackplaneClosed = errors.New("hub backplane is closed")
+
+// MemoryBackplane fans events out to Hubs living in the same process. A
+// subscriber that falls a whole buffer behind holds up publishers until it
+// catches up or its subscription ends, no event is dropped.
+type MemoryBackplane struct {
+	mu          sync.RWMutex
+	subscribers map[*memorySubscription]struct{}
+	closed      bool
+}
+
+// memorySubscription is closed once its context is done. Publishers hold the
+// read lock while sending so the channel isn't closed under them, and give up
+// on the send when done fires, so closing never waits on a full buffer.
+type memorySubscription struct {
+	mu     sync.RWMutex
+	events chan RoomEvent
+	done   <-chan struct{}
+	closed bool
+}
+
+func NewMemoryBackplane() *MemoryBackplane {
+	return &MemoryBackplane{
+		subscribers: make(map[*memorySubscription]struct{}),
+	}
+}
+
+// Publish waits for room in every subscription, or returns the context's
+// error if it ends first.
+func (b *MemoryBackplane) Publish(ctx context.Context, event RoomEvent) error {
+	b.mu.RLock()
+	if b.closed {
+		b.mu.RUnlock()
+		return ErrBackplaneClosed
+	}
+	subs := make([]*memorySubscription, 0, len(b.subscribers))
+	for sub := range b.subscribers {
+		subs = append(subs, sub)
+	}
+	b.mu.RUnlock()
+
+	for _, sub := range subs {
+		if err := sub.send(ctx, event); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *memorySubscription) send(ctx context.Context, event RoomEvent) error {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if s.closed {
+		return nil
+	}
+	select {
+	case s.events <- event:
+		return nil
+	case <-s.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (b *MemoryBackplane) Subscribe(ctx context.Context) (<-chan RoomEvent, error) {
+	sub := &memorySubscription{
+		events: make(chan RoomEvent, 256),
+		done:   ctx.Done(),
+	}
+
+	b.mu.Lock()
+	if b.closed {
+		b.mu.Unlock()
+		return nil, ErrBackplaneClosed
+	}
+	b.subscribers[sub] = struct{}{}
+	b.mu.Unlock()
+
+	go func() {
+		<-ctx.Done()
+		b.unsubscribe(sub)
+	}()
+
+	return sub.events, nil
+}
+
+// Close refuses further publishes and subscriptions. Existing subscriptions
+// still end with their own context.
+func (b *MemoryBackplane) Close() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.closed = true
+	return nil
+}
+
+func (b *MemoryBackplane) unsubscribe(sub *memorySubscription) {
+	b.mu.Lock()
+	delete(b.subscribers, sub)
+	b.mu.Unlock()
+
+	sub.mu.Lock()
+	defer sub.mu.Unlock()
+	sub.closed = true
+	close(sub.events)
+}
@@ -0,0 +1,186 @@
+package websocket
+
+import (
+	"context"
+	"errors"
+	"log"
+	"os"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// MongoBackplane shares room events between server instances by inserting
+// them into the HubEvents collection and tailing it with a change stream.
+// Change streams need MongoDB to run as a replica set.
+type MongoBackplane struct {
+	events  eventLog
+	tailing sync.WaitGroup
+}
+
+// eventLog is the part of the HubEvents collection the backplane relies on.
+type eventLog interface {
+	Insert(ctx context.Context, doc bson.M) error
+	// Watch streams inserted documents, starting right after resumeAfter when
+	// it is set and from now otherwise.
+	Watch(ctx context.Context, resumeAfter bson.Raw) (changeStream, error)
+}
+
+// changeStream is satisfied by *mongo.ChangeStream.
+type changeStream interface {
+	Next(ctx context.Context) bool
+	Decode(val interface{}) error
+	ResumeToken() bson.Raw
+	Err() error
+	Close(ctx context.Context) error
+}
+
+type mongoEventLog struct {
+	collection *mongo.Collection
+}
+
+func (l mongoEventLog) Insert(ctx context.Context, doc bson.M) error {
+	_, err := l.collection.InsertOne(ctx, doc)
+	return err
+}
+
+func (l mongoEventLog) Watch(ctx context.Context, resumeAfter bson.Raw) (changeStream, error) {
+	pipeline := mongo.Pipeline{
+		bson.D{{"$match", bson.D{{"operationType", "insert"}}}},
+	}
+
+	opts := options.ChangeStream()
+	if resumeAfter != nil {
+		opts.SetResumeAfter(resumeAfter)
+	}
+	return l.collection.Watch(ctx, pipeline, opts)
+}
+
+func NewMongoBackplane(ctx context.Context, client *mongo.Client) (*MongoBackplane, error) {
+	collection := client.Database(os.Getenv("MONGO_DATABASE")).Collection("HubEvents")
+
+	// events are only useful to instances that are listening right now
+	_, err := collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{"created_at", 1}},
+		Options: options.Index().SetExpireAfterSeconds(60),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &MongoBackplane{events: mongoEventLog{collection: collection}}, nil
+}
+
+func (b *MongoBackplane) Publish(ctx context.Context, event RoomEvent) error {
+	return b.events.Insert(ctx, bson.M{
+		"event_id":   event.ID,
+		"room_id":    event.RoomID,
+		"user_id":    event.UserID,
+		"key":        event.Key,
+		"data":       event.Data,
+		"created_at": time.Now(),
+	})
+}
+
+// Subscribe tails the event log until ctx is done. A stream that fails is
+// watched again from the last event received, so a dropped connection or a
+// replica set election doesn't cost the Hub any events.
+func (b *MongoBackplane) Subscribe(ctx context.Context) (<-chan RoomEvent, error) {
+	stream, err := b.events.Watch(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	events := make(chan RoomEvent, 256)
+	b.tailing.Add(1)
+	go b.tail(ctx, stream, events)
+
+	return events, nil
+}
+
+func (b *MongoBackplane) tail(ctx context.Context, stream changeStream, events chan<- RoomEvent) {
+	defer b.tailing.Done()
+	defer close(events)
+
+	var token bson.Raw
+	delay := minRetryDelay
+
+	for {
+		for stream.Next(ctx) {
+			token = stream.ResumeToken()
+			delay = minRetryDelay
+
+			var change struct {
+				FullDocument RoomEvent `bson:"fullDocument"`
+			}
+			if err := stream.Decode(&change); err != nil {
+				log.Println("Failed to decode hub event: ", err)
+				continue
+			}
+
+			select {
+			case events <- change.FullDocument:
+			case <-ctx.Done():
+				stream.Close(context.Background())
+				return
+			}
+		}
+
+		err := stream.Err()
+		stream.Close(context.Background())
+		if ctx.Err() != nil {
+			return
+		}
+		if err == nil {
+			// the stream was invalidated, by the collection being dropped for
+			// instance, and can't be resumed past that point
+			log.Println("Hub event stream was invalidated, watching for new events")
+			token = nil
+		} else {
+			log.Printf("Hub event stream stopped, resuming in %s: %v", delay, err)
+		}
+
+		for {
+			select {
+			case <-time.After(delay):
+			case <-ctx.Done():
+				return
+			}
+			delay = nextRetryDelay(delay)
+
+			stream, err = b.events.Watch(ctx, token)
+			if err == nil {
+				break
+			}
+			if ctx.Err() != nil {
+				return
+			}
+			if token != nil && resumeTokenLost(err) {
+				log.Println("Hub event stream can't resume, events since the last one received are lost: ", err)
+				token = nil
+				continue
+			}
+			log.Printf("Failed to watch hub events, retrying in %s: %v", delay, err)
+		}
+	}
+}
+
+// resumeTokenLost reports whether the server no longer has the history a
+// resume token points into, the oplog having rolled over since.
+func resumeTokenLost(err error) bool {
+	var serverErr mongo.ServerError
+	if !errors.As(err, &serverErr) {
+		return false
+	}
+	// ChangeStreamHistoryLost, ChangeStreamFatalError
+	return serverErr.HasErrorCode(286) || serverErr.HasErrorCode(280)
+}
+
+// Close waits for the subscriptions, whose contexts must be done, to stop.
+func (b *MongoBackplane) Close() error {
+	b.tailing.Wait()
+	return nil
+}
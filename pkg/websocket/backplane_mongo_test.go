@@ -0,0 +1,253 @@
+package websocket
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+var errStreamBroken = errors.New("connection reset")
+
+// fakeEventLog stands in for the HubEvents collection. Its resume tokens are
+// positions in the log, and breakStreams fails every open stream the way a
+// dropped connection would.
+type fakeEventLog struct {
+	mu       sync.Mutex
+	docs     []bson.Raw
+	inserted chan struct{}
+	broken   chan struct{}
+	// failWatches makes that many Watch calls fail before one succeeds.
+	failWatches int
+	resumes     []int
+}
+
+func newFakeEventLog() *fakeEventLog {
+	return &fakeEventLog{inserted: make(chan struct{}), broken: make(chan struct{})}
+}
+
+func (l *fakeEventLog) Insert(ctx context.Context, doc bson.M) error {
+	raw, err := bson.Marshal(doc)
+	if err != nil {
+		return err
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.docs = append(l.docs, raw)
+	close(l.inserted)
+	l.inserted = make(chan struct{})
+	return nil
+}
+
+func (l *fakeEventLog) Watch(ctx context.Context, resumeAfter bson.Raw) (changeStream, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.failWatches > 0 {
+		l.failWatches--
+		return nil, errStreamBroken
+	}
+
+	pos := len(l.docs)
+	if resumeAfter != nil {
+		pos = int(resumeAfter.Lookup("pos").Int32())
+		l.resumes = append(l.resumes, pos)
+	}
+	return &fakeStream{log: l, pos: pos, broken: l.broken}, nil
+}
+
+func (l *fakeEventLog) breakStreams() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	close(l.broken)
+	l.broken = make(chan struct{})
+}
+
+func (l *fakeEventLog) resumedFrom() []int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return append([]int(nil), l.resumes...)
+}
+
+type fakeStream struct {
+	log    *fakeEventLog
+	pos    int
+	cur    bson.Raw
+	broken chan struct{}
+	err    error
+}
+
+func (s *fakeStream) Next(ctx context.Context) bool {
+	for {
+		s.log.mu.Lock()
+		if s.pos < len(s.log.docs) {
+			s.cur = s.log.docs[s.pos]
+			s.pos++
+			s.log.mu.Unlock()
+			return true
+		}
+		inserted := s.log.inserted
+		s.log.mu.Unlock()
+
+		select {
+		case <-inserted:
+		case <-s.broken:
+			s.err = errStreamBroken
+			return false
+		case <-ctx.Done():
+			s.err = ctx.Err()
+			return false
+		}
+	}
+}
+
+func (s *fakeStream) Decode(val interface{}) error {
+	raw, err := bson.Marshal(bson.M{"fullDocument": s.cur})
+	if err != nil {
+		return err
+	}
+	return bson.Unmarshal(raw, val)
+}
+
+func (s *fakeStream) ResumeToken() bson.Raw {
+	raw, _ := bson.Marshal(bson.M{"pos": int32(s.pos)})
+	return raw
+}
+
+func (s *fakeStream) Err() error                      { return s.err }
+func (s *fakeStream) Close(ctx context.Context) error { return nil }
+
+func fastRetries(t *testing.T) {
+	t.Helper()
+
+	minDelay, maxDelay := minRetryDelay, maxRetryDelay
+	minRetryDelay, maxRetryDelay = time.Millisecond, 10*time.Millisecond
+	t.Cleanup(func() { minRetryDelay, maxRetryDelay = minDelay, maxDelay })
+}
+
+func expectEvent(t *testing.T, events <-chan RoomEvent, data string) {
+	t.Helper()
+
+	select {
+	case event, ok := <-events:
+		if !ok {
+			t.Fatalf("subscription closed before event %q", data)
+		}
+		if string(event.Data) != data {
+			t.Fatalf("got event %q, want %q", event.Data, data)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("event %q was not received", data)
+	}
+}
+
+// Events published while the change stream is down are received once it is
+// watched again from the last resume token.
+func TestMongoBackplaneResumesAfterStreamFailure(t *testing.T) {
+	fastRetries(t)
+
+	eventLog := newFakeEventLog()
+	backplane := &MongoBackplane{events: eventLog}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	events, err := backplane.Subscribe(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	publish := func(data string) {
+		t.Helper()
+		if err := backplane.Publish(ctx, RoomEvent{RoomID: "room1", Data: []byte(data)}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	publish("1")
+	publish("2")
+	expectEvent(t, events, "1")
+	expectEvent(t, events, "2")
+
+	eventLog.mu.Lock()
+	eventLog.failWatches = 2
+	eventLog.mu.Unlock()
+	eventLog.breakStreams()
+	publish("3")
+	publish("4")
+
+	expectEvent(t, events, "3")
+	expectEvent(t, events, "4")
+	if resumes := eventLog.resumedFrom(); len(resumes) != 1 || resumes[0] != 2 {
+		t.Fatalf("resumed from %v, want [2]", resumes)
+	}
+
+	cancel()
+	select {
+	case _, ok := <-events:
+		if ok {
+			t.Fatal("unexpected event after the subscription ended")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("subscription was not closed after its context ended")
+	}
+	backplane.Close()
+}
+
+// closingBackplane ends its first subscription straight away, as a backplane
+// that lost its connection for good would.
+type closingBackplane struct {
+	Backplane
+	mu         sync.Mutex
+	subscribed int
+}
+
+func (b *closingBackplane) Subscribe(ctx context.Context) (<-chan RoomEvent, error) {
+	b.mu.Lock()
+	b.subscribed++
+	first := b.subscribed == 1
+	b.mu.Unlock()
+
+	if first {
+		events := make(chan RoomEvent)
+		close(events)
+		return events, nil
+	}
+	return b.Backplane.Subscribe(ctx)
+}
+
+func TestHubResubscribesWhenSubscriptionCloses(t *testing.T) {
+	fastRetries(t)
+
+	backplane := &closingBackplane{Backplane: NewMemoryBackplane()}
+	hub := startHub(t, backplane)
+
+	sub := newTestSubscriber("a1", "alice", "room1")
+	if !hub.add(sub) {
+		t.Fatal("hub stopped instead of resubscribing")
+	}
+
+	deadline := time.After(5 * time.Second)
+	for {
+		backplane.mu.Lock()
+		subscribed := backplane.subscribed
+		backplane.mu.Unlock()
+		if subscribed >= 2 {
+			break
+		}
+		select {
+		case <-deadline:
+			t.Fatal("hub did not resubscribe")
+		case <-time.After(time.Millisecond):
+		}
+	}
+
+	if err := hub.Publish(context.Background(), RoomEvent{RoomID: "room1", Data: []byte(`{"action":"send_message"}`)}); err != nil {
+		t.Fatal(err)
+	}
+	sub.expect(t, `{"action":"send_message"}`)
+}
@@ -0,0 +1,284 @@
+package websocket
+
+import (
+	"context"
+	"errors"
+	"os"
+	"strconv"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// testSubscriber records what its Hub delivers to it.
+type testSubscriber struct {
+	info   ConnectionInfo
+	events chan RoomEvent
+}
+
+func newTestSubscriber(id string, userID string, roomID string) *testSubscriber {
+	return &testSubscriber{
+		info:   ConnectionInfo{ID: id, UserID: userID, RoomID: roomID},
+		events: make(chan RoomEvent, 16),
+	}
+}
+
+func (s *testSubscriber) RoomID() string       { return s.info.RoomID }
+func (s *testSubscriber) Info() ConnectionInfo { return s.info }
+func (s *testSubscriber) Codec() Codec         { return jsonCodec{} }
+func (s *testSubscriber) Stats() QueueStats    { return QueueStats{} }
+func (s *testSubscriber) Close(int, string)    {}
+
+func (s *testSubscriber) Deliver(event RoomEvent) bool {
+	select {
+	case s.events <- event:
+		return true
+	default:
+		return false
+	}
+}
+
+func (s *testSubscriber) expect(t *testing.T, data string) {
+	t.Helper()
+
+	select {
+	case event := <-s.events:
+		if string(event.Data) != data {
+			t.Fatalf("got event %q, want %q", event.Data, data)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("event %q was not delivered to %s", data, s.info.ID)
+	}
+}
+
+func (s *testSubscriber) expectNothing(t *testing.T) {
+	t.Helper()
+
+	select {
+	case event := <-s.events:
+		t.Fatalf("unexpected event %q delivered to %s", event.Data, s.info.ID)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func startHub(t *testing.T, backplane Backplane) *Hub {
+	t.Helper()
+
+	hub := NewHub(backplane, HubConfig{})
+	go hub.Run()
+	t.Cleanup(func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		hub.Shutdown(ctx)
+	})
+	return hub
+}
+
+// testCrossHub runs two Hubs over backplanes of the same transport, as two
+// server instances would, and checks events published on one reach the
+// subscribers of the other.
+func testCrossHub(t *testing.T, backplaneA Backplane, backplaneB Backplane) {
+	hubA := startHub(t, backplaneA)
+	hubB := startHub(t, backplaneB)
+
+	inRoom := newTestSubscriber("b1", "bob", "room1")
+	otherRoom := newTestSubscriber("b2", "carol", "room2")
+	if !hubB.add(inRoom) || !hubB.add(otherRoom) {
+		t.Fatal("hub B stopped before its subscribers registered")
+	}
+	local := newTestSubscriber("a1", "alice", "room1")
+	if !hubA.add(local) {
+		t.Fatal("hub A stopped before its subscriber registered")
+	}
+
+	ctx := context.Background()
+
+	if err := hubA.Publish(ctx, RoomEvent{RoomID: "room1", Data: []byte(`{"action":"send_message"}`)}); err != nil {
+		t.Fatal(err)
+	}
+	inRoom.expect(t, `{"action":"send_message"}`)
+	local.expect(t, `{"action":"send_message"}`)
+	otherRoom.expectNothing(t)
+
+	if err := hubA.PublishToUser(ctx, "carol", []byte(`{"action":"notification"}`)); err != nil {
+		t.Fatal(err)
+	}
+	otherRoom.expect(t, `{"action":"notification"}`)
+	inRoom.expectNothing(t)
+	local.expectNothing(t)
+}
+
+// Two Hubs sharing one MemoryBackplane only covers the fan-out within a
+// process, the tests below give each Hub a backplane of its own.
+func TestMemoryBackplaneCrossHub(t *testing.T) {
+	backplane := NewMemoryBackplane()
+	testCrossHub(t, backplane, backplane)
+}
+
+// Each instance has its own MongoBackplane over a shared event log, so events
+// only reach the other Hub through the log and its change streams.
+func TestMongoBackplaneCrossInstance(t *testing.T) {
+	eventLog := newFakeEventLog()
+	testCrossHub(t, &MongoBackplane{events: eventLog}, &MongoBackplane{events: eventLog})
+}
+
+// Events published on one instance while the other's change stream is down
+// still reach its subscribers once the stream resumes.
+func TestMongoBackplaneCrossInstanceStreamFailure(t *testing.T) {
+	fastRetries(t)
+
+	eventLog := newFakeEventLog()
+	hubA := startHub(t, &MongoBackplane{events: eventLog})
+	hubB := startHub(t, &MongoBackplane{events: eventLog})
+
+	remote := newTestSubscriber("b1", "bob", "room1")
+	if !hubB.add(remote) {
+		t.Fatal("hub B stopped before its subscriber registered")
+	}
+
+	ctx := context.Background()
+	if err := hubA.Publish(ctx, RoomEvent{RoomID: "room1", Data: []byte("1")}); err != nil {
+		t.Fatal(err)
+	}
+	remote.expect(t, "1")
+
+	eventLog.mu.Lock()
+	eventLog.failWatches = 3
+	eventLog.mu.Unlock()
+	eventLog.breakStreams()
+
+	for _, data := range []string{"2", "3"} {
+		if err := hubA.Publish(ctx, RoomEvent{RoomID: "room1", Data: []byte(data)}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	remote.expect(t, "2")
+	remote.expect(t, "3")
+	remote.expectNothing(t)
+}
+
+// TestMongoBackplaneCrossHub needs a replica set, MONGO_TEST_URI points to
+// it. Its HubEvents collection is used in the gochat_test database.
+func TestMongoBackplaneCrossHub(t *testing.T) {
+	uri := os.Getenv("MONGO_TEST_URI")
+	if uri == "" {
+		t.Skip("MONGO_TEST_URI is not set")
+	}
+	t.Setenv("MONGO_DATABASE", "gochat_test")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	client, err := mongo.Connect(ctx, options.Client().ApplyURI(uri))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { client.Disconnect(context.Background()) })
+
+	backplaneA, err := NewMongoBackplane(ctx, client)
+	if err != nil {
+		t.Fatal(err)
+	}
+	backplaneB, err := NewMongoBackplane(ctx, client)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testCrossHub(t, backplaneA, backplaneB)
+}
+
+// Flooding a subscriber far past its buffer holds the publisher back
+// instead of losing events.
+func TestMemoryBackplaneFloodLosesNothing(t *testing.T) {
+	backplane := NewMemoryBackplane()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	events, err := backplane.Subscribe(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	const total = 5000
+	published := make(chan error, 1)
+	go func() {
+		for i := 0; i < total; i++ {
+			if err := backplane.Publish(context.Background(), RoomEvent{RoomID: "room1", Data: []byte(strconv.Itoa(i))}); err != nil {
+				published <- err
+				return
+			}
+		}
+		published <- nil
+	}()
+
+	for i := 0; i < total; i++ {
+		if i%500 == 0 {
+			// let the buffer fill up
+			time.Sleep(10 * time.Millisecond)
+		}
+		expectEvent(t, events, strconv.Itoa(i))
+	}
+	if err := <-published; err != nil {
+		t.Fatal(err)
+	}
+}
+
+// A publisher waiting on a full subscriber gives up when its own context
+// ends, with an error, or when the subscription ends, so the subscription
+// can always be closed.
+func TestMemoryBackplaneFullSubscriber(t *testing.T) {
+	backplane := NewMemoryBackplane()
+
+	subCtx, unsubscribe := context.WithCancel(context.Background())
+	events, err := backplane.Subscribe(subCtx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < cap(events); i++ {
+		if err := backplane.Publish(context.Background(), RoomEvent{RoomID: "room1"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if err := backplane.Publish(ctx, RoomEvent{RoomID: "room1"}); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("publish to a full subscriber returned %v, want %v", err, context.DeadlineExceeded)
+	}
+
+	published := make(chan error, 1)
+	go func() {
+		published <- backplane.Publish(context.Background(), RoomEvent{RoomID: "room1"})
+	}()
+	select {
+	case err := <-published:
+		t.Fatalf("publish to a full subscriber returned %v before it had room", err)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	unsubscribe()
+
+	select {
+	case err := <-published:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("publish still blocked after the subscription ended")
+	}
+
+	deadline := time.After(5 * time.Second)
+	for {
+		select {
+		case _, ok := <-events:
+			if !ok {
+				return
+			}
+		case <-deadline:
+			t.Fatal("subscription was not closed after its context ended")
+		}
+	}
+}
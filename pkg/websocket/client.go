@@ -2,10 +2,6 @@ package websocket
 
 import (
 	"context"
-	"encoding/json"
-	"go-chat/dto"
-	"go-chat/model"
-	"go-chat/repository"
 	"log"
 	"net/http"
 	"time"
@@ -14,6 +10,11 @@ import (
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
+const (
+	// writeWait bounds how long the close frame may take to go out.
+	writeWait = 10 * time.Second
+)
+
 var (
 	newline = []byte{'\n'}
 	space   = []byte{' '}
@@ -22,30 +23,68 @@ var (
 var upgrader = websocket.Upgrader{
 	ReadBufferSize:  1024,
 	WriteBufferSize: 1024,
-	CheckOrigin: func(r *http.Request) bool {
-		return true
-	},
+	Subprotocols:    []string{ProtocolJSON, ProtocolMsgpack},
 }
 
 type Client struct {
-	hub            *Hub
-	conn           *websocket.Conn
-	send           chan []byte
-	chatRepository repository.ChatRepository
-	roomID         string
-	SenderID       string
-	ReceiverID     string
+	id          string
+	hub         *Hub
+	conn        *websocket.Conn
+	connectedAt time.Time
+	send        *outbox
+	router      *Router
+	codec       Codec
+	limiter     *connLimiter
+	roomID      string
+	SenderID    string
+	ReceiverID  string
+	// set once the client is being closed, only touched by readPump
+	disconnecting bool
 }
 
-func (c *Client) readPump() {
-	var (
-		msgData     map[string]interface{}
-		textMessage string
-	)
+func (c *Client) RoomID() string {
+	return c.roomID
+}
+
+func (c *Client) Info() ConnectionInfo {
+	return ConnectionInfo{
+		ID:          c.id,
+		UserID:      c.SenderID,
+		RoomID:      c.roomID,
+		RemoteAddr:  c.conn.RemoteAddr().String(),
+		Transport:   "websocket",
+		Protocol:    c.codec.Protocol(),
+		ConnectedAt: c.connectedAt,
+	}
+}
+
+func (c *Client) Codec() Codec {
+	return c.codec
+}
+
+func (c *Client) Deliver(event RoomEvent) bool {
+	return c.send.push(event)
+}
+
+func (c *Client) Close(code int, reason string) {
+	c.send.close(code, reason)
+}
+
+func (c *Client) Stats() QueueStats {
+	return c.send.queueStats()
+}
+
+// reply queues a response meant for this client only, already encoded with
+// its codec.
+func (c *Client) reply(data []byte) bool {
+	return c.send.push(RoomEvent{RoomID: c.roomID, Data: data})
+}
 
+func (c *Client) readPump() {
 	defer func() {
-		c.hub.unregister <- c
+		c.hub.remove(c)
 		c.conn.Close()
+		c.hub.inflight.finish(1)
 	}()
 
 	for {
@@ -57,114 +96,126 @@ func (c *Client) readPump() {
 			break
 		}
 
-		if err := json.Unmarshal(message, &msgData); err != nil {
+		var msgData map[string]interface{}
+		if err := c.codec.Unmarshal(message, &msgData); err != nil {
 			log.Println("Failed to parse message:", err)
 			continue
 		}
 
-		if action, ok := msgData["action"].(string); ok && action == "get_messages" {
-			roomID, ok := msgData["room_id"].(string)
-			if !ok {
-				log.Println("room_id is not a string")
-				continue
-			}
-
-			limit, ok := msgData["limit"].(float64)
-			if !ok {
-				log.Println("limit is not a number")
-				continue
-			}
-
-			offset, ok := msgData["offset"].(float64)
-			if !ok {
-				log.Println("offset is not a number")
-				continue
-			}
-
-			messageRequest := dto.GetMessagesRequest{
-				RoomID: roomID,
-				Limit:  int64(limit),
-				Offset: int64(offset),
-			}
-
-			messages, err := c.chatRepository.GetMessages(context.Background(), messageRequest.RoomID, messageRequest.Limit, messageRequest.Offset)
-			if err != nil {
-				log.Println("Failed to retrieve messages: ", err)
-				return
-			}
-
-			if len(messages) == 0 {
-				response := map[string]interface{}{
-					"action": "messages",
-					"data":   model.Message{},
-				}
-				responseBytes, _ := json.Marshal(response)
-				c.send <- responseBytes
-				continue
-			} else {
-				response := map[string]interface{}{
-					"action": "messages",
-					"data":   messages,
-				}
-				responseBytes, _ := json.Marshal(response)
-				c.send <- responseBytes
-				continue
-			}
+		if c.disconnecting {
+			continue
 		}
 
-		if msgData["action"] == "send_message" {
-			textMessage = msgData["message_text"].(string)
-
-			err = c.chatRepository.SaveMessage(context.Background(), model.Message{
-				MessageID:   primitive.NewObjectID(),
-				SenderID:    c.SenderID,
-				ReceiverID:  c.ReceiverID,
-				MessageText: textMessage,
-				Timestamp:   time.Now(),
-				ChatRoomID:  c.roomID,
-			})
-			if err != nil {
-				log.Println("Failed to save message: ", err)
-				return
-			}
-			c.hub.broadcast <- message
-		}
+		action, _ := msgData["action"].(string)
+		c.router.dispatch(&Context{
+			Context:    context.Background(),
+			Action:     action,
+			Data:       msgData,
+			UserID:     c.SenderID,
+			ReceiverID: c.ReceiverID,
+			RoomID:     c.roomID,
+			client:     c,
+		})
 	}
 }
 
+// errorFrame builds an error reply for action. retryAfter is included when the
+// client may try again later.
+func (c *Client) errorFrame(action string, reason string, retryAfter time.Duration) []byte {
+	frame := map[string]interface{}{
+		"action": "error",
+		"for":    action,
+		"error":  reason,
+	}
+	if retryAfter > 0 {
+		frame["retry_after_ms"] = retryAfter.Milliseconds()
+	}
+
+	data, _ := c.codec.Marshal(frame)
+	return data
+}
+
 func (c *Client) writePump() {
 	defer func() {
 		c.conn.Close()
+		c.hub.inflight.finish(1)
 	}()
 	for {
 		select {
-		case message, ok := <-c.send:
-			if !ok {
-				c.conn.WriteMessage(websocket.CloseMessage, []byte{})
-				return
+		case <-c.send.ready:
+			events, gap, closed := c.send.drain()
+
+			if gap > 0 {
+				marker, _ := c.codec.Marshal(resyncMarker(c.roomID, gap))
+				if err := c.conn.WriteMessage(c.codec.MessageType(), marker); err != nil {
+					return
+				}
 			}
 
-			w, err := c.conn.NextWriter(websocket.TextMessage)
-			if err != nil {
+			if err := c.writeEvents(events); err != nil {
 				return
 			}
-			w.Write(message)
 
-			n := len(c.send)
-			for i := 0; i < n; i++ {
-				w.Write(newline)
-				w.Write(<-c.send)
+			if closed {
+				code, reason := c.send.closeStatus()
+				closeMessage := websocket.FormatCloseMessage(code, reason)
+				c.conn.WriteControl(websocket.CloseMessage, closeMessage, time.Now().Add(writeWait))
+				return
 			}
+		}
+	}
+}
 
-			if err := w.Close(); err != nil {
-				return
+// writeEvents batches text payloads as newline-delimited JSON in a single
+// frame. Binary payloads can't be split that way and get one frame each.
+func (c *Client) writeEvents(events []RoomEvent) error {
+	if len(events) == 0 {
+		return nil
+	}
+
+	if c.codec.MessageType() != websocket.TextMessage {
+		for _, event := range events {
+			if err := c.conn.WriteMessage(c.codec.MessageType(), event.Data); err != nil {
+				return err
 			}
 		}
+		return nil
+	}
+
+	w, err := c.conn.NextWriter(websocket.TextMessage)
+	if err != nil {
+		return err
+	}
+	for i, event := range events {
+		if i > 0 {
+			w.Write(newline)
+		}
+		w.Write(event.Data)
+	}
+	return w.Close()
+}
+
+// checkOrigin only lets browsers upgrade from origins allowed by the Hub's
+// config, so other sites can't open connections with the user's cookies.
+// Requests without an Origin header don't come from a browser and are allowed.
+func (h *Hub) checkOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+
+	if h.config.AllowOrigin == nil || !h.config.AllowOrigin(origin) {
+		log.Printf("Rejected websocket upgrade from origin %q, remote address %s", origin, r.RemoteAddr)
+		return false
 	}
+	return true
 }
 
-func ServeWs(hub *Hub, w http.ResponseWriter, r *http.Request, chatRepository repository.ChatRepository) {
-	conn, err := upgrader.Upgrade(w, r, nil)
+func ServeWs(hub *Hub, router *Router, w http.ResponseWriter, r *http.Request) {
+	wsUpgrader := upgrader
+	wsUpgrader.CheckOrigin = hub.checkOrigin
+
+	conn, err := wsUpgrader.Upgrade(w, r, nil)
 	if err != nil {
 		log.Println("Websocket upgrade error:", err)
 		http.Error(w, "Failed to upgrade Websocket", http.StatusInternalServerError)
@@ -184,16 +235,37 @@ func ServeWs(hub *Hub, w http.ResponseWriter, r *http.Request, chatRepository re
 	}
 
 	client := &Client{
-		hub:            hub,
-		conn:           conn,
-		send:           make(chan []byte, 256),
-		chatRepository: chatRepository,
-		roomID:         roomID,
-		SenderID:       senderID,
-		ReceiverID:     receiverID,
+		id:          primitive.NewObjectID().Hex(),
+		hub:         hub,
+		conn:        conn,
+		connectedAt: time.Now(),
+		send:        newOutbox(hub.config.Backpressure),
+		router:      router,
+		codec:       codecFor(conn.Subprotocol()),
+		limiter:     newConnLimiter(hub.config.RateLimits, hub.users, senderID),
+		roomID:      roomID,
+		SenderID:    senderID,
+		ReceiverID:  receiverID,
+	}
+
+	// one for each pump, so Hub.Shutdown waits for them to drain
+	if !hub.inflight.add(2) {
+		refuseShuttingDown(conn)
+		return
+	}
+
+	if !client.hub.add(client) {
+		hub.inflight.finish(2)
+		refuseShuttingDown(conn)
+		return
 	}
-	client.hub.register <- client
 
 	go client.writePump()
 	go client.readPump()
 }
+
+func refuseShuttingDown(conn *websocket.Conn) {
+	closeMessage := websocket.FormatCloseMessage(websocket.CloseGoingAway, "server shutting down")
+	conn.WriteControl(websocket.CloseMessage, closeMessage, time.Now().Add(writeWait))
+	conn.Close()
+}
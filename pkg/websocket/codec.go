@@ -0,0 +1,95 @@
+package websocket
+
+import (
+	"encoding/json"
+
+	"github.com/gorilla/websocket"
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+const (
+	ProtocolJSON    = "gochat.json.v1"
+	ProtocolMsgpack = "gochat.msgpack.v1"
+)
+
+// Codec is the wire format of a single connection, negotiated through the
+// Sec-WebSocket-Protocol header. Payloads travel through the Hub and the
+// backplane as JSON and are transcoded once per codec on delivery.
+type Codec interface {
+	Protocol() string
+	MessageType() int
+	Marshal(v interface{}) ([]byte, error)
+	Unmarshal(data []byte, v interface{}) error
+}
+
+var codecs = map[string]Codec{
+	ProtocolJSON:    jsonCodec{},
+	ProtocolMsgpack: msgpackCodec{},
+}
+
+// codecFor falls back to JSON for clients that don't ask for a subprotocol.
+func codecFor(protocol string) Codec {
+	if codec, ok := codecs[protocol]; ok {
+		return codec
+	}
+	return jsonCodec{}
+}
+
+func transcode(codec Codec, data []byte) ([]byte, error) {
+	if codec.Protocol() == ProtocolJSON {
+		return data, nil
+	}
+
+	var v interface{}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return nil, err
+	}
+	return codec.Marshal(v)
+}
+
+type jsonCodec struct{}
+
+func (jsonCodec) Protocol() string {
+	return ProtocolJSON
+}
+
+func (jsonCodec) MessageType() int {
+	return websocket.TextMessage
+}
+
+func (jsonCodec) Marshal(v interface{}) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+func (jsonCodec) Unmarshal(data []byte, v interface{}) error {
+	return json.Unmarshal(data, v)
+}
+
+type msgpackCodec struct{}
+
+func (msgpackCodec) Protocol() string {
+	return ProtocolMsgpack
+}
+
+func (msgpackCodec) MessageType() int {
+	return websocket.BinaryMessage
+}
+
+func (msgpackCodec) Marshal(v interface{}) ([]byte, error) {
+	return msgpack.Marshal(v)
+}
+
+// Unmarshal goes through JSON so that decoded values have the same types
+// (float64 numbers, map[string]interface{} objects) as on the JSON protocol.
+func (msgpackCodec) Unmarshal(data []byte, v interface{}) error {
+	var raw interface{}
+	if err := msgpack.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	normalized, err := json.Marshal(raw)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(normalized, v)
+}
@@ -1,40 +1,298 @@
 package websocket
 
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	// historySize is how many recent events per room the Hub keeps so that
+	// reconnecting subscribers can resume where they left off.
+	historySize = 100
+
+	// ReconnectAfter is the delay suggested to clients disconnected by a shutdown.
+	ReconnectAfter = 5 * time.Second
+)
+
+// Subscriber is anything the Hub delivers room events to, regardless of the
+// transport behind it.
+type Subscriber interface {
+	RoomID() string
+	Info() ConnectionInfo
+	Codec() Codec
+	// Deliver queues event without blocking and reports false when the
+	// subscriber is too slow to keep and has to be dropped.
+	Deliver(event RoomEvent) bool
+	Stats() QueueStats
+	// Close stops the subscriber once its queued events are flushed. code and
+	// reason follow WebSocket close frame semantics.
+	Close(code int, reason string)
+}
+
+// resumable is implemented by subscribers that reconnect with the ID of the
+// last event they saw.
+type resumable interface {
+	LastEventID() string
+}
+
+type HubConfig struct {
+	Backpressure BackpressurePolicy
+	RateLimits   RateLimits
+	// AllowOrigin is checked against the Origin header of upgrade requests.
+	AllowOrigin func(origin string) bool
+}
+
 type Hub struct {
-	clients    map[*Client]bool
-	broadcast  chan []byte
-	register   chan *Client
-	unregister chan *Client
+	clients    map[Subscriber]bool
+	register   chan Subscriber
+	unregister chan Subscriber
+	tasks      chan func()
+	backplane  Backplane
+	config     HubConfig
+	users      *userLimiters
+	history    map[string][]RoomEvent
+	quit       chan struct{}
+	done       chan struct{}
+	inflight   inflight
 }
 
-func NewHub() *Hub {
+func NewHub(backplane Backplane, config HubConfig) *Hub {
 	return &Hub{
-		clients:    make(map[*Client]bool),
-		broadcast:  make(chan []byte),
-		register:   make(chan *Client),
-		unregister: make(chan *Client),
+		clients:    make(map[Subscriber]bool),
+		register:   make(chan Subscriber),
+		unregister: make(chan Subscriber),
+		tasks:      make(chan func()),
+		backplane:  backplane,
+		config:     config,
+		users:      newUserLimiters(config.RateLimits.PerUser),
+		history:    make(map[string][]RoomEvent),
+		quit:       make(chan struct{}),
+		done:       make(chan struct{}),
+	}
+}
+
+// Publish sends a room event through the backplane. It is delivered to local
+// subscribers once it comes back on the Hub's own subscription.
+func (h *Hub) Publish(ctx context.Context, event RoomEvent) error {
+	if event.ID == "" {
+		event.ID = primitive.NewObjectID().Hex()
+	}
+	return h.backplane.Publish(ctx, event)
+}
+
+// Shutdown disconnects every subscriber with a going away close frame, then
+// waits until their queued events are flushed and any in-flight work started
+// by their connections has finished, or until ctx expires.
+func (h *Hub) Shutdown(ctx context.Context) error {
+	close(h.quit)
+
+	select {
+	case <-h.done:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
+	drained := make(chan struct{})
+	go func() {
+		h.inflight.wait()
+		close(drained)
+	}()
+
+	select {
+	case <-drained:
+	case <-ctx.Done():
+		return ctx.Err()
 	}
+
+	return h.backplane.Close()
+}
+
+// PublishToUser sends data to every connection of userID across all instances.
+func (h *Hub) PublishToUser(ctx context.Context, userID string, data []byte) error {
+	return h.Publish(ctx, RoomEvent{UserID: userID, Data: data})
 }
 
 func (h *Hub) Run() {
+	defer close(h.done)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// while the subscription is down the Hub keeps serving its clients and
+	// retries it in the background
+	var events <-chan RoomEvent
+	var retry <-chan time.Time
+	delay := minRetryDelay
+	subscribe := func() {
+		var err error
+		events, err = h.backplane.Subscribe(ctx)
+		if err != nil {
+			log.Printf("Failed to subscribe to hub backplane, retrying in %s: %v", delay, err)
+			retry = time.After(delay)
+			delay = nextRetryDelay(delay)
+			return
+		}
+		retry = nil
+	}
+	subscribe()
+
 	for {
 		select {
-		case client := <-h.register:
-			h.clients[client] = true
-		case client := <-h.unregister:
-			if _, ok := h.clients[client]; ok {
-				delete(h.clients, client)
-				close(client.send)
+		case <-h.quit:
+			reason := fmt.Sprintf("server shutting down, reconnect after %s", ReconnectAfter)
+			for sub := range h.clients {
+				h.disconnect(sub, websocket.CloseGoingAway, reason)
+			}
+			return
+		case sub := <-h.register:
+			h.clients[sub] = true
+			if r, ok := sub.(resumable); ok && r.LastEventID() != "" {
+				h.replay(sub, r.LastEventID())
+			}
+		case sub := <-h.unregister:
+			h.drop(sub)
+		case task := <-h.tasks:
+			task()
+		case <-retry:
+			subscribe()
+		case event, ok := <-events:
+			if !ok {
+				log.Printf("Hub backplane subscription closed unexpectedly, resubscribing in %s", delay)
+				events = nil
+				retry = time.After(delay)
+				delay = nextRetryDelay(delay)
+				continue
 			}
-		case message := <-h.broadcast:
-			for client := range h.clients {
-				select {
-				case client.send <- message:
-				default:
-					close(client.send)
-					delete(h.clients, client)
+			delay = minRetryDelay
+			if event.UserID == "" {
+				h.remember(event)
+			}
+			encoded := make(map[string][]byte)
+			for sub := range h.clients {
+				if !addressedTo(event, sub) {
+					continue
 				}
+				h.deliver(sub, event, encoded)
 			}
 		}
 	}
 }
+
+func addressedTo(event RoomEvent, sub Subscriber) bool {
+	if event.UserID != "" {
+		return sub.Info().UserID == event.UserID
+	}
+	return sub.RoomID() == event.RoomID
+}
+
+// deliver hands the event to sub in its own wire format. encoded caches the
+// payload per protocol so each broadcast is encoded once per format.
+func (h *Hub) deliver(sub Subscriber, event RoomEvent, encoded map[string][]byte) bool {
+	protocol := sub.Codec().Protocol()
+
+	data, ok := encoded[protocol]
+	if !ok {
+		var err error
+		data, err = transcode(sub.Codec(), event.Data)
+		if err != nil {
+			log.Println("Failed to encode event: ", err)
+			return true
+		}
+		encoded[protocol] = data
+	}
+
+	event.Data = data
+	if !sub.Deliver(event) {
+		stats := sub.Stats()
+		log.Printf("Disconnecting slow subscriber in room %s: %d queued, %d dropped", sub.RoomID(), stats.Depth, stats.Dropped)
+		h.disconnect(sub, websocket.ClosePolicyViolation, "send queue full")
+		return false
+	}
+	return true
+}
+
+// add registers sub, it reports false once the Hub has stopped.
+func (h *Hub) add(sub Subscriber) bool {
+	select {
+	case h.register <- sub:
+		return true
+	case <-h.done:
+		return false
+	}
+}
+
+func (h *Hub) remove(sub Subscriber) {
+	select {
+	case h.unregister <- sub:
+	case <-h.done:
+	}
+}
+
+func (h *Hub) drop(sub Subscriber) {
+	h.disconnect(sub, websocket.CloseNormalClosure, "")
+}
+
+func (h *Hub) disconnect(sub Subscriber, code int, reason string) {
+	if _, ok := h.clients[sub]; ok {
+		delete(h.clients, sub)
+		sub.Close(code, reason)
+	}
+}
+
+func (h *Hub) remember(event RoomEvent) {
+	history := append(h.history[event.RoomID], event)
+	if len(history) > historySize {
+		history = history[len(history)-historySize:]
+	}
+	h.history[event.RoomID] = history
+}
+
+// replay delivers the events newer than lastEventID. Event IDs are ObjectID
+// hex strings, so comparing them as strings orders them by creation time.
+func (h *Hub) replay(sub Subscriber, lastEventID string) {
+	for _, event := range h.history[sub.RoomID()] {
+		if event.ID <= lastEventID {
+			continue
+		}
+		if !h.deliver(sub, event, make(map[string][]byte)) {
+			return
+		}
+	}
+}
+
+// inflight counts the connection goroutines Shutdown has to wait for. Unlike a
+// bare sync.WaitGroup it refuses new work once waiting has begun.
+type inflight struct {
+	mu      sync.Mutex
+	wg      sync.WaitGroup
+	closing bool
+}
+
+func (i *inflight) add(n int) bool {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	if i.closing {
+		return false
+	}
+	i.wg.Add(n)
+	return true
+}
+
+func (i *inflight) finish(n int) {
+	i.wg.Add(-n)
+}
+
+func (i *inflight) wait() {
+	i.mu.Lock()
+	i.closing = true
+	i.mu.Unlock()
+
+	i.wg.Wait()
+}
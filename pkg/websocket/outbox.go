@@ -0,0 +1,159 @@
+package websocket
+
+import (
+	"fmt"
+	"sync"
+)
+
+// sendQueueSize is how many events may wait for a slow subscriber before its
+// backpressure policy kicks in.
+const sendQueueSize = 256
+
+// BackpressurePolicy decides what happens when a subscriber's send queue is full.
+type BackpressurePolicy string
+
+const (
+	// PolicyDisconnect closes the subscriber with a policy violation close frame.
+	PolicyDisconnect BackpressurePolicy = "disconnect"
+	// PolicyDropOldest discards the oldest queued events and tells the client
+	// to resync before sending it anything newer.
+	PolicyDropOldest BackpressurePolicy = "drop_oldest"
+	// PolicyCoalesce replaces a queued event with a newer one carrying the same
+	// Key, and falls back to PolicyDropOldest when nothing can be merged.
+	PolicyCoalesce BackpressurePolicy = "coalesce"
+)
+
+func ParseBackpressurePolicy(name string) (BackpressurePolicy, error) {
+	switch policy := BackpressurePolicy(name); policy {
+	case "":
+		return PolicyDisconnect, nil
+	case PolicyDisconnect, PolicyDropOldest, PolicyCoalesce:
+		return policy, nil
+	default:
+		return "", fmt.Errorf("unknown backpressure policy %q", name)
+	}
+}
+
+// QueueStats describes a subscriber's send queue.
+type QueueStats struct {
+	Depth     int    `json:"depth"`
+	HighWater int    `json:"high_water"`
+	Dropped   uint64 `json:"dropped"`
+	Coalesced uint64 `json:"coalesced"`
+}
+
+// outbox is the send queue between the Hub and a subscriber's writer. ready
+// is signalled whenever there is something to drain.
+type outbox struct {
+	mu          sync.Mutex
+	items       []RoomEvent
+	policy      BackpressurePolicy
+	ready       chan struct{}
+	closed      bool
+	closeCode   int
+	closeReason string
+	// gap counts events dropped since the writer last sent a resync marker
+	gap   int
+	stats QueueStats
+}
+
+func newOutbox(policy BackpressurePolicy) *outbox {
+	return &outbox{
+		policy: policy,
+		ready:  make(chan struct{}, 1),
+	}
+}
+
+// push queues event without blocking. It reports false when the event could
+// not be queued and the subscriber has to be disconnected.
+func (o *outbox) push(event RoomEvent) bool {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if o.closed {
+		return false
+	}
+
+	if o.policy == PolicyCoalesce && event.Key != "" {
+		for i := range o.items {
+			if o.items[i].Key == event.Key {
+				o.items[i] = event
+				o.stats.Coalesced++
+				return true
+			}
+		}
+	}
+
+	if len(o.items) >= sendQueueSize {
+		if o.policy == PolicyDisconnect {
+			o.stats.Dropped++
+			return false
+		}
+		o.items = o.items[1:]
+		o.gap++
+		o.stats.Dropped++
+	}
+
+	o.items = append(o.items, event)
+	o.stats.Depth = len(o.items)
+	if o.stats.Depth > o.stats.HighWater {
+		o.stats.HighWater = o.stats.Depth
+	}
+	o.signal()
+	return true
+}
+
+// drain takes everything queued. gap is the number of events dropped since
+// the previous drain, closed is set once close has been called.
+func (o *outbox) drain() (events []RoomEvent, gap int, closed bool) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	events, o.items = o.items, nil
+	gap, o.gap = o.gap, 0
+	o.stats.Depth = 0
+	return events, gap, o.closed
+}
+
+func (o *outbox) close(code int, reason string) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if o.closed {
+		return
+	}
+	o.closed = true
+	o.closeCode = code
+	o.closeReason = reason
+	o.signal()
+}
+
+func (o *outbox) closeStatus() (code int, reason string) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	return o.closeCode, o.closeReason
+}
+
+func (o *outbox) queueStats() QueueStats {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	return o.stats
+}
+
+func (o *outbox) signal() {
+	select {
+	case o.ready <- struct{}{}:
+	default:
+	}
+}
+
+// resyncMarker is sent in place of the events a slow subscriber missed.
+func resyncMarker(roomID string, gap int) map[string]interface{} {
+	return map[string]interface{}{
+		"action":  "resync_needed",
+		"room_id": roomID,
+		"dropped": gap,
+	}
+}
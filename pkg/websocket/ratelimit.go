@@ -0,0 +1,209 @@
+package websocket
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// Limit is a token bucket refilled at Rate tokens per second and holding up to Burst.
+type Limit struct {
+	Rate  rate.Limit
+	Burst int
+}
+
+// RateLimits caps how often each websocket action may be called. Actions with
+// no entry are not limited.
+type RateLimits struct {
+	PerConnection map[string]Limit
+	PerUser       map[string]Limit
+	// MaxViolations rejected calls within ViolationWindow disconnect the connection.
+	MaxViolations   int
+	ViolationWindow time.Duration
+}
+
+func DefaultRateLimits() RateLimits {
+	return RateLimits{
+		PerConnection: map[string]Limit{
+			"send_message": {Rate: 5, Burst: 10},
+			"get_messages": {Rate: 2, Burst: 5},
+			"typing":       {Rate: 1, Burst: 3},
+		},
+		PerUser: map[string]Limit{
+			"send_message": {Rate: 10, Burst: 20},
+			"get_messages": {Rate: 5, Burst: 10},
+			"typing":       {Rate: 3, Burst: 5},
+		},
+		MaxViolations:   10,
+		ViolationWindow: time.Minute,
+	}
+}
+
+// ParseLimit reads a limit written as "rate/burst", "5/10" allows 5 calls a
+// second with bursts of up to 10.
+func ParseLimit(value string) (Limit, error) {
+	rateValue, burstValue, ok := strings.Cut(value, "/")
+	if !ok {
+		return Limit{}, fmt.Errorf("limit %q is not rate/burst", value)
+	}
+
+	r, err := strconv.ParseFloat(rateValue, 64)
+	if err != nil || r <= 0 {
+		return Limit{}, fmt.Errorf("invalid rate in limit %q", value)
+	}
+	burst, err := strconv.Atoi(burstValue)
+	if err != nil || burst < 1 {
+		return Limit{}, fmt.Errorf("invalid burst in limit %q", value)
+	}
+
+	return Limit{Rate: rate.Limit(r), Burst: burst}, nil
+}
+
+// userLimiters holds the per user buckets shared by all of a user's
+// connections on this instance.
+type userLimiters struct {
+	mu        sync.Mutex
+	limits    map[string]Limit
+	buckets   map[string]map[string]*rate.Limiter
+	lastPrune time.Time
+}
+
+func newUserLimiters(limits map[string]Limit) *userLimiters {
+	return &userLimiters{
+		limits:    limits,
+		buckets:   make(map[string]map[string]*rate.Limiter),
+		lastPrune: time.Now(),
+	}
+}
+
+func (u *userLimiters) reserve(userID string, action string, now time.Time) time.Duration {
+	limit, ok := u.limits[action]
+	if !ok {
+		return 0
+	}
+
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	u.prune(now)
+
+	actions, ok := u.buckets[userID]
+	if !ok {
+		actions = make(map[string]*rate.Limiter)
+		u.buckets[userID] = actions
+	}
+	bucket, ok := actions[action]
+	if !ok {
+		bucket = rate.NewLimiter(limit.Rate, limit.Burst)
+		actions[action] = bucket
+	}
+
+	_, wait := reserve(bucket, now)
+	return wait
+}
+
+// prune forgets buckets that have refilled completely, they are no different
+// from the fresh bucket the user would get on their next call.
+func (u *userLimiters) prune(now time.Time) {
+	if now.Sub(u.lastPrune) < time.Minute {
+		return
+	}
+	u.lastPrune = now
+
+	for userID, actions := range u.buckets {
+		for action, bucket := range actions {
+			if bucket.TokensAt(now) >= float64(bucket.Burst()) {
+				delete(actions, action)
+			}
+		}
+		if len(actions) == 0 {
+			delete(u.buckets, userID)
+		}
+	}
+}
+
+// connLimiter is owned by a single readPump, so it needs no locking.
+type connLimiter struct {
+	limits     RateLimits
+	users      *userLimiters
+	userID     string
+	buckets    map[string]*rate.Limiter
+	violations []time.Time
+}
+
+func newConnLimiter(limits RateLimits, users *userLimiters, userID string) *connLimiter {
+	return &connLimiter{
+		limits:  limits,
+		users:   users,
+		userID:  userID,
+		buckets: make(map[string]*rate.Limiter),
+	}
+}
+
+// allow takes a token for action from both the connection and the user
+// bucket. When either is empty neither is spent, and it returns how long to
+// wait before retrying.
+func (l *connLimiter) allow(action string) (bool, time.Duration) {
+	now := time.Now()
+
+	var held *rate.Reservation
+	if limit, ok := l.limits.PerConnection[action]; ok {
+		bucket, ok := l.buckets[action]
+		if !ok {
+			bucket = rate.NewLimiter(limit.Rate, limit.Burst)
+			l.buckets[action] = bucket
+		}
+		r, wait := reserve(bucket, now)
+		if wait > 0 {
+			return false, wait
+		}
+		held = r
+	}
+
+	if wait := l.users.reserve(l.userID, action, now); wait > 0 {
+		// the call didn't happen, so the connection gets its token back
+		if held != nil {
+			held.CancelAt(now)
+		}
+		return false, wait
+	}
+
+	return true, 0
+}
+
+// violation records a rejected call and reports whether the connection has
+// now been rejected often enough to be disconnected.
+func (l *connLimiter) violation() bool {
+	now := time.Now()
+
+	recent := l.violations[:0]
+	for _, at := range l.violations {
+		if now.Sub(at) < l.limits.ViolationWindow {
+			recent = append(recent, at)
+		}
+	}
+	l.violations = append(recent, now)
+
+	return l.limits.MaxViolations > 0 && len(l.violations) >= l.limits.MaxViolations
+}
+
+// reserve takes a token if one is available and returns its reservation,
+// otherwise it leaves the bucket untouched and returns the time until the next
+// token.
+func reserve(bucket *rate.Limiter, now time.Time) (*rate.Reservation, time.Duration) {
+	r := bucket.ReserveN(now, 1)
+	if !r.OK() {
+		return nil, time.Minute
+	}
+
+	wait := r.DelayFrom(now)
+	if wait > 0 {
+		r.CancelAt(now)
+		return nil, wait
+	}
+	return r, 0
+}
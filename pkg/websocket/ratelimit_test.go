@@ -0,0 +1,30 @@
+package websocket
+
+import (
+	"testing"
+	"time"
+)
+
+// A call the user bucket rejects must not cost the connection a token.
+func TestConnLimiterRefundsConnectionToken(t *testing.T) {
+	limits := RateLimits{
+		PerConnection: map[string]Limit{"send_message": {Rate: 0.001, Burst: 2}},
+		PerUser:       map[string]Limit{"send_message": {Rate: 0.001, Burst: 1}},
+	}
+	users := newUserLimiters(limits.PerUser)
+
+	other := newConnLimiter(limits, users, "alice")
+	if ok, _ := other.allow("send_message"); !ok {
+		t.Fatal("first call of the user was rejected")
+	}
+
+	conn := newConnLimiter(limits, users, "alice")
+	if ok, wait := conn.allow("send_message"); ok || wait <= 0 {
+		t.Fatalf("call over the user limit was allowed, ok %v wait %s", ok, wait)
+	}
+
+	tokens := conn.buckets["send_message"].TokensAt(time.Now())
+	if tokens < 1.99 {
+		t.Fatalf("connection bucket has %.2f tokens after a rejected call, want 2", tokens)
+	}
+}
@@ -0,0 +1,175 @@
+package websocket
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// HandlerFunc handles one action sent by a client. A returned error is logged
+// and the client gets an "internal_error" frame for that action, errors meant
+// for the client are sent with Context.Error instead.
+type HandlerFunc func(ctx *Context) error
+
+// Middleware wraps a handler, for checks shared by several actions.
+type Middleware func(next HandlerFunc) HandlerFunc
+
+// Router dispatches incoming client messages to handlers by their "action".
+type Router struct {
+	handlers   map[string]HandlerFunc
+	middleware []Middleware
+}
+
+func NewRouter() *Router {
+	return &Router{handlers: make(map[string]HandlerFunc)}
+}
+
+// Use adds middleware run around every handler, in the order given.
+func (r *Router) Use(middleware ...Middleware) {
+	r.middleware = append(r.middleware, middleware...)
+}
+
+// Handle registers handler for action, wrapped in the router's middleware and
+// then in the middleware given here.
+func (r *Router) Handle(action string, handler HandlerFunc, middleware ...Middleware) {
+	for i := len(middleware) - 1; i >= 0; i-- {
+		handler = middleware[i](handler)
+	}
+	r.handlers[action] = handler
+}
+
+func (r *Router) dispatch(ctx *Context) {
+	handler, ok := r.handlers[ctx.Action]
+	if !ok {
+		handler = unknownAction
+	}
+
+	for i := len(r.middleware) - 1; i >= 0; i-- {
+		handler = r.middleware[i](handler)
+	}
+
+	if err := handler(ctx); err != nil {
+		log.Printf("Failed to handle %q from %s: %v", ctx.Action, ctx.UserID, err)
+		ctx.Error("internal_error", 0)
+	}
+}
+
+func unknownAction(ctx *Context) error {
+	ctx.Error("unknown_action", 0)
+	return nil
+}
+
+// Context is a single client message on its way through the router.
+type Context struct {
+	context.Context
+	Action string
+	// Data is the decoded message, including its "action".
+	Data       map[string]interface{}
+	UserID     string
+	ReceiverID string
+	RoomID     string
+	client     *Client
+}
+
+// String returns the string field key of the message.
+func (c *Context) String(key string) (string, bool) {
+	value, ok := c.Data[key].(string)
+	return value, ok
+}
+
+// Int returns the numeric field key of the message.
+func (c *Context) Int(key string) (int64, bool) {
+	switch value := c.Data[key].(type) {
+	case float64:
+		return int64(value), true
+	case int64:
+		return value, true
+	}
+	return 0, false
+}
+
+// Strings returns the field key of the message when it's a list of strings.
+func (c *Context) Strings(key string) ([]string, bool) {
+	values, ok := c.Data[key].([]interface{})
+	if !ok {
+		return nil, false
+	}
+
+	strs := make([]string, 0, len(values))
+	for _, value := range values {
+		str, ok := value.(string)
+		if !ok {
+			return nil, false
+		}
+		strs = append(strs, str)
+	}
+	return strs, true
+}
+
+// Reply sends v to this connection only, encoded with its codec.
+func (c *Context) Reply(v interface{}) error {
+	data, err := c.client.codec.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	if !c.client.reply(data) {
+		return errors.New("send queue full")
+	}
+	return nil
+}
+
+// Broadcast publishes v to everyone in the room, this connection included.
+func (c *Context) Broadcast(v interface{}) error {
+	// the hub carries JSON whatever protocol the sender speaks
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return c.client.hub.Publish(c, RoomEvent{RoomID: c.RoomID, Data: data})
+}
+
+// Error replies with an error frame for the current action. retryAfter is
+// included when the client may try again later.
+func (c *Context) Error(reason string, retryAfter time.Duration) {
+	c.client.reply(c.client.errorFrame(c.Action, reason, retryAfter))
+}
+
+// Disconnect closes the connection once its queued replies are sent. Messages
+// still arriving from it are ignored.
+func (c *Context) Disconnect(code int, reason string) {
+	c.client.Close(code, reason)
+	c.client.disconnecting = true
+}
+
+// Logger logs every action handled and how long it took.
+func Logger(next HandlerFunc) HandlerFunc {
+	return func(ctx *Context) error {
+		start := time.Now()
+		err := next(ctx)
+		log.Printf("Handled %q from %s in room %s in %s", ctx.Action, ctx.UserID, ctx.RoomID, time.Since(start))
+		return err
+	}
+}
+
+// RateLimit enforces the Hub's per connection and per user rate limits, and
+// disconnects clients that keep exceeding them.
+func RateLimit(next HandlerFunc) HandlerFunc {
+	return func(ctx *Context) error {
+		limiter := ctx.client.limiter
+
+		if ok, retryAfter := limiter.allow(ctx.Action); !ok {
+			ctx.Error("rate_limited", retryAfter)
+			if limiter.violation() {
+				log.Printf("Disconnecting %s from room %s: rate limit exceeded", ctx.UserID, ctx.RoomID)
+				ctx.Disconnect(websocket.ClosePolicyViolation, "rate limit exceeded")
+			}
+			return nil
+		}
+		return next(ctx)
+	}
+}
@@ -0,0 +1,145 @@
+package websocket
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const sseKeepAlive = 30 * time.Second
+
+// ServeSSE streams a room's events as Server-Sent Events. It is receive-only:
+// SSE clients send messages through the REST API.
+func ServeSSE(hub *Hub, w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "Streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+
+	params := r.URL.Query()
+	roomID := params.Get("roomID")
+	userID := params.Get("userID")
+
+	if roomID == "" || userID == "" {
+		log.Println("Missing required query parameters")
+		http.Error(w, "Missing required query parameters", http.StatusBadRequest)
+		return
+	}
+
+	// browsers send the header on reconnect, the query parameter lets other
+	// clients resume on their first request
+	lastEventID := r.Header.Get("Last-Event-ID")
+	if lastEventID == "" {
+		lastEventID = params.Get("lastEventID")
+	}
+
+	stream, err := hub.OpenStream(ConnectionInfo{
+		ID:          primitive.NewObjectID().Hex(),
+		UserID:      userID,
+		RoomID:      roomID,
+		RemoteAddr:  r.RemoteAddr,
+		Transport:   "sse",
+		Protocol:    ProtocolJSON,
+		ConnectedAt: time.Now(),
+	}, lastEventID)
+	if err != nil {
+		http.Error(w, "Server shutting down", http.StatusServiceUnavailable)
+		return
+	}
+	defer stream.Detach()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+	log.Println("SSE stream opened")
+
+	ticker := time.NewTicker(sseKeepAlive)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stream.Ready():
+			events, gap, closed := stream.Drain()
+
+			if gap > 0 {
+				if err := writeSSEResync(w, stream, gap); err != nil {
+					return
+				}
+			}
+
+			for _, event := range events {
+				if err := writeSSEEvent(w, event); err != nil {
+					return
+				}
+			}
+
+			if closed {
+				code, reason := stream.CloseStatus()
+				writeSSEClose(w, code, reason)
+				flusher.Flush()
+				return
+			}
+			flusher.Flush()
+		case <-ticker.C:
+			if _, err := fmt.Fprint(w, ": keep-alive\n\n"); err != nil {
+				return
+			}
+			flusher.Flush()
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
+
+func writeSSEEvent(w http.ResponseWriter, event RoomEvent) error {
+	var buf bytes.Buffer
+
+	fmt.Fprintf(&buf, "id: %s\n", event.ID)
+	for _, line := range bytes.Split(event.Data, newline) {
+		fmt.Fprintf(&buf, "data: %s\n", line)
+	}
+	buf.Write(newline)
+
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+func writeSSEResync(w http.ResponseWriter, stream *Stream, gap int) error {
+	data, err := json.Marshal(stream.ResyncMarker(gap))
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintf(w, "event: resync\ndata: %s\n\n", data)
+	return err
+}
+
+// writeSSEClose tells the client why the stream ended. On a going away close
+// the retry field moves the browser's reconnect back to ReconnectAfter.
+func writeSSEClose(w http.ResponseWriter, code int, reason string) error {
+	data, err := json.Marshal(map[string]interface{}{
+		"code":   code,
+		"reason": reason,
+	})
+	if err != nil {
+		return err
+	}
+
+	var buf bytes.Buffer
+	if code == websocket.CloseGoingAway {
+		fmt.Fprintf(&buf, "retry: %d\n", ReconnectAfter.Milliseconds())
+	}
+	fmt.Fprintf(&buf, "event: close\ndata: %s\n\n", data)
+
+	_, err = w.Write(buf.Bytes())
+	return err
+}
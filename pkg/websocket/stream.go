@@ -0,0 +1,104 @@
+package websocket
+
+import "time"
+
+// Stream is a Hub subscriber for transports that pull their events instead
+// of having a write pump in this package, such as SSE and gRPC. Events are
+// always JSON encoded.
+type Stream struct {
+	hub         *Hub
+	info        ConnectionInfo
+	lastEventID string
+	send        *outbox
+	limiter     *connLimiter
+}
+
+// OpenStream registers a new stream. lastEventID, when set, replays the room
+// events published after it. Callers must Detach the stream when done.
+func (h *Hub) OpenStream(info ConnectionInfo, lastEventID string) (*Stream, error) {
+	if !h.inflight.add(1) {
+		return nil, ErrHubStopped
+	}
+
+	stream := &Stream{
+		hub:         h,
+		info:        info,
+		lastEventID: lastEventID,
+		send:        newOutbox(h.config.Backpressure),
+		limiter:     newConnLimiter(h.config.RateLimits, h.users, info.UserID),
+	}
+
+	if !h.add(stream) {
+		h.inflight.finish(1)
+		return nil, ErrHubStopped
+	}
+	return stream, nil
+}
+
+func (s *Stream) RoomID() string {
+	return s.info.RoomID
+}
+
+func (s *Stream) Info() ConnectionInfo {
+	return s.info
+}
+
+func (s *Stream) Codec() Codec {
+	return jsonCodec{}
+}
+
+func (s *Stream) LastEventID() string {
+	return s.lastEventID
+}
+
+func (s *Stream) Deliver(event RoomEvent) bool {
+	return s.send.push(event)
+}
+
+func (s *Stream) Close(code int, reason string) {
+	s.send.close(code, reason)
+}
+
+func (s *Stream) Stats() QueueStats {
+	return s.send.queueStats()
+}
+
+// Allow applies the Hub's per connection and per user rate limits to an
+// action the stream's client sent, like the RateLimit middleware does for
+// websocket clients. When it's rejected, retryAfter is how long to wait and
+// exceeded reports whether the client kept at it and should be disconnected.
+// It must only be called from the goroutine reading the client's actions.
+func (s *Stream) Allow(action string) (ok bool, retryAfter time.Duration, exceeded bool) {
+	if ok, retryAfter = s.limiter.allow(action); ok {
+		return true, 0, false
+	}
+	return false, retryAfter, s.limiter.violation()
+}
+
+// Ready is signalled when Drain has something to return.
+func (s *Stream) Ready() <-chan struct{} {
+	return s.send.ready
+}
+
+// Drain takes the queued events. gap is the number of events dropped by the
+// backpressure policy since the last drain, closed is set once the Hub has
+// closed the stream and nothing more will follow.
+func (s *Stream) Drain() (events []RoomEvent, gap int, closed bool) {
+	return s.send.drain()
+}
+
+// CloseStatus is the close code and reason given by the Hub.
+func (s *Stream) CloseStatus() (code int, reason string) {
+	return s.send.closeStatus()
+}
+
+// ResyncMarker is the payload telling a client it missed gap events.
+func (s *Stream) ResyncMarker(gap int) map[string]interface{} {
+	return resyncMarker(s.info.RoomID, gap)
+}
+
+// Detach unregisters the stream once its transport has stopped reading it.
+func (s *Stream) Detach() {
+	s.hub.remove(s)
+	s.hub.inflight.finish(1)
+}
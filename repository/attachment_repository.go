@@ -0,0 +1,241 @@
+package repository
+
+import (
+	"context"
+	"go-chat/constant"
+	"go-chat/model"
+	"log"
+	"os"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type AttachmentRepository interface {
+	SaveAttachment(ctx context.Context, attachment model.Attachment) (err error)
+	GetAttachment(ctx context.Context, attachmentID primitive.ObjectID) (attachment model.Attachment, err error)
+	GetAttachments(ctx context.Context, attachmentIDs []primitive.ObjectID) (attachments []model.Attachment, err error)
+	GetProcessedImage(ctx context.Context, sha256 string) (attachment model.Attachment, err error)
+	GetPendingPreviews(ctx context.Context, createdBefore time.Time, limit int64) (attachments []model.Attachment, err error)
+	SetPreview(ctx context.Context, attachmentID primitive.ObjectID, preview model.ImagePreview) (err error)
+	DeleteAttachment(ctx context.Context, attachmentID primitive.ObjectID) (err error)
+	CountAttachmentsWithContent(ctx context.Context, sha256 string) (count int64, err error)
+	LockContentRelease(ctx context.Context, sha256 string) (locked bool, err error)
+	UnlockContentRelease(ctx context.Context, sha256 string) (err error)
+	IsContentReleasing(ctx context.Context, sha256 string) (releasing bool, err error)
+	EnsureIndexes(ctx context.Context) (err error)
+}
+
+type AttachmentRepositoryImpl struct {
+	mongo *mongo.Client
+}
+
+func NewAttachmentRepository(mongo *mongo.Client) AttachmentRepository {
+	return &AttachmentRepositoryImpl{
+		mongo: mongo,
+	}
+}
+
+func (a *AttachmentRepositoryImpl) SaveAttachment(ctx context.Context, attachment model.Attachment) (err error) {
+	collection := a.mongo.Database(os.Getenv("MONGO_DATABASE")).Collection("Attachments")
+
+	_, err = collection.InsertOne(ctx, attachment)
+	if err != nil {
+		log.Println(err)
+		return err
+	}
+
+	return nil
+}
+
+func (a *AttachmentRepositoryImpl) GetAttachment(ctx context.Context, attachmentID primitive.ObjectID) (attachment model.Attachment, err error) {
+	collection := a.mongo.Database(os.Getenv("MONGO_DATABASE")).Collection("Attachments")
+
+	err = collection.FindOne(ctx, bson.M{"_id": attachmentID}).Decode(&attachment)
+	if err == mongo.ErrNoDocuments {
+		return attachment, nil
+	} else if err != nil {
+		log.Println(err)
+		return attachment, err
+	}
+
+	return attachment, nil
+}
+
+// GetAttachments returns the attachments found among attachmentIDs, in no
+// particular order.
+func (a *AttachmentRepositoryImpl) GetAttachments(ctx context.Context, attachmentIDs []primitive.ObjectID) (attachments []model.Attachment, err error) {
+	collection := a.mongo.Database(os.Getenv("MONGO_DATABASE")).Collection("Attachments")
+
+	cur, err := collection.Find(ctx, bson.M{"_id": bson.M{"$in": attachmentIDs}})
+	if err != nil {
+		log.Println(err)
+		return []model.Attachment{}, err
+	}
+	defer cur.Close(ctx)
+
+	if err := cur.All(ctx, &attachments); err != nil {
+		log.Println(err)
+		return []model.Attachment{}, err
+	}
+
+	return attachments, nil
+}
+
+// GetProcessedImage returns an image with the given content whose preview is
+// ready, if there is one.
+func (a *AttachmentRepositoryImpl) GetProcessedImage(ctx context.Context, sha256 string) (attachment model.Attachment, err error) {
+	collection := a.mongo.Database(os.Getenv("MONGO_DATABASE")).Collection("Attachments")
+
+	filter := bson.M{
+		"sha256":         sha256,
+		"preview.status": constant.PREVIEW_READY,
+	}
+
+	err = collection.FindOne(ctx, filter).Decode(&attachment)
+	if err == mongo.ErrNoDocuments {
+		return attachment, nil
+	} else if err != nil {
+		log.Println(err)
+		return attachment, err
+	}
+
+	return attachment, nil
+}
+
+// GetPendingPreviews returns images uploaded before createdBefore whose
+// preview is still pending, oldest first.
+func (a *AttachmentRepositoryImpl) GetPendingPreviews(ctx context.Context, createdBefore time.Time, limit int64) (attachments []model.Attachment, err error) {
+	collection := a.mongo.Database(os.Getenv("MONGO_DATABASE")).Collection("Attachments")
+
+	filter := bson.M{
+		"preview.status": constant.PREVIEW_PENDING,
+		"created_at":     bson.M{"$lt": createdBefore},
+	}
+	opts := options.Find().SetSort(bson.D{{"created_at", 1}}).SetLimit(limit)
+
+	cur, err := collection.Find(ctx, filter, opts)
+	if err != nil {
+		log.Println(err)
+		return []model.Attachment{}, err
+	}
+	defer cur.Close(ctx)
+
+	if err := cur.All(ctx, &attachments); err != nil {
+		log.Println(err)
+		return []model.Attachment{}, err
+	}
+
+	return attachments, nil
+}
+
+func (a *AttachmentRepositoryImpl) SetPreview(ctx context.Context, attachmentID primitive.ObjectID, preview model.ImagePreview) (err error) {
+	collection := a.mongo.Database(os.Getenv("MONGO_DATABASE")).Collection("Attachments")
+
+	_, err = collection.UpdateOne(ctx, bson.M{"_id": attachmentID}, bson.M{
+		"$set": bson.M{"preview": preview},
+	})
+	if err != nil {
+		log.Println(err)
+		return err
+	}
+
+	return nil
+}
+
+func (a *AttachmentRepositoryImpl) DeleteAttachment(ctx context.Context, attachmentID primitive.ObjectID) (err error) {
+	collection := a.mongo.Database(os.Getenv("MONGO_DATABASE")).Collection("Attachments")
+
+	_, err = collection.DeleteOne(ctx, bson.M{"_id": attachmentID})
+	if err != nil {
+		log.Println(err)
+		return err
+	}
+
+	return nil
+}
+
+// CountAttachmentsWithContent counts the attachments sharing the blob stored
+// under sha256.
+func (a *AttachmentRepositoryImpl) CountAttachmentsWithContent(ctx context.Context, sha256 string) (count int64, err error) {
+	collection := a.mongo.Database(os.Getenv("MONGO_DATABASE")).Collection("Attachments")
+
+	count, err = collection.CountDocuments(ctx, bson.M{"sha256": sha256})
+	if err != nil {
+		log.Println(err)
+		return 0, err
+	}
+
+	return count, nil
+}
+
+// LockContentRelease marks the blobs stored under sha256 as being deleted,
+// locked is false when another release holds the mark already.
+func (a *AttachmentRepositoryImpl) LockContentRelease(ctx context.Context, sha256 string) (locked bool, err error) {
+	collection := a.mongo.Database(os.Getenv("MONGO_DATABASE")).Collection("ContentReleases")
+
+	_, err = collection.InsertOne(ctx, bson.M{"_id": sha256, "created_at": time.Now()})
+	if mongo.IsDuplicateKeyError(err) {
+		return false, nil
+	} else if err != nil {
+		log.Println(err)
+		return false, err
+	}
+
+	return true, nil
+}
+
+func (a *AttachmentRepositoryImpl) UnlockContentRelease(ctx context.Context, sha256 string) (err error) {
+	collection := a.mongo.Database(os.Getenv("MONGO_DATABASE")).Collection("ContentReleases")
+
+	_, err = collection.DeleteOne(ctx, bson.M{"_id": sha256})
+	if err != nil {
+		log.Println(err)
+		return err
+	}
+
+	return nil
+}
+
+func (a *AttachmentRepositoryImpl) IsContentReleasing(ctx context.Context, sha256 string) (releasing bool, err error) {
+	collection := a.mongo.Database(os.Getenv("MONGO_DATABASE")).Collection("ContentReleases")
+
+	count, err := collection.CountDocuments(ctx, bson.M{"_id": sha256})
+	if err != nil {
+		log.Println(err)
+		return false, err
+	}
+
+	return count > 0, nil
+}
+
+// EnsureIndexes creates the index attachments are found by content with, and
+// the one expiring the release marks of a server that died holding them. It's
+// a no-op when they already exist.
+func (a *AttachmentRepositoryImpl) EnsureIndexes(ctx context.Context) (err error) {
+	collection := a.mongo.Database(os.Getenv("MONGO_DATABASE")).Collection("Attachments")
+
+	_, err = collection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{"sha256", 1}}},
+	})
+	if err != nil {
+		log.Println(err)
+		return err
+	}
+
+	releases := a.mongo.Database(os.Getenv("MONGO_DATABASE")).Collection("ContentReleases")
+
+	_, err = releases.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{"created_at", 1}},
+		Options: options.Index().SetExpireAfterSeconds(300),
+	})
+	if err != nil {
+		log.Println(err)
+		return err
+	}
+
+	return nil
+}
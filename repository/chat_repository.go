@@ -14,11 +14,34 @@ import (
 )
 
 type ChatRepository interface {
-	SaveMessage(ctx context.Context, message model.Message) (err error)
-	GetMessages(ctx context.Context, roomID string, limit int64, offset int64) (messages []model.Message, err error)
+	SaveMessage(ctx context.Context, message model.Message) (seq int64, err error)
+	GetMessagesBefore(ctx context.Context, roomID string, viewerID string, collapseThreads bool, cursor *model.MessageCursor, limit int64) (messages []model.Message, err error)
+	GetMessagesAfter(ctx context.Context, roomID string, viewerID string, collapseThreads bool, cursor model.MessageCursor, limit int64) (messages []model.Message, err error)
+	GetMessagesBySeq(ctx context.Context, roomID string, viewerID string, collapseThreads bool, fromSeq int64, toSeq int64) (messages []model.Message, err error)
+	GetLastSeq(ctx context.Context, roomID string) (seq int64, err error)
+	StartReadTimers(ctx context.Context, roomID string, readerID string, seq int64, readAt time.Time) (err error)
+	GetExpiredMessages(ctx context.Context, now time.Time, limit int64) (messages []model.Message, err error)
+	DeleteExpiredMessage(ctx context.Context, messageID primitive.ObjectID, now time.Time) (deleted bool, err error)
+	GetThreadReplies(ctx context.Context, rootID string, viewerID string, limit int64, offset int64) (messages []model.Message, err error)
+	GetMentions(ctx context.Context, userID string, limit int64, offset int64) (messages []model.Message, err error)
+	SearchMessages(ctx context.Context, text string, roomIDs []string, senderID string, from time.Time, to time.Time, viewerID string, limit int64, offset int64) (messages []model.Message, err error)
+	GetMessage(ctx context.Context, messageID primitive.ObjectID) (message model.Message, err error)
+	EditMessage(ctx context.Context, message model.Message, messageText string, mentions []string, editedAt time.Time) (edited bool, err error)
+	HideMessage(ctx context.Context, messageID primitive.ObjectID, userID string) (err error)
+	DeleteMessage(ctx context.Context, messageID primitive.ObjectID, deletedBy string, deletedAt time.Time) (deleted bool, err error)
+	AddReaction(ctx context.Context, messageID primitive.ObjectID, emoji string, userID string) (message model.Message, changed bool, err error)
+	RemoveReaction(ctx context.Context, messageID primitive.ObjectID, emoji string, userID string) (message model.Message, changed bool, err error)
+	UpdateThread(ctx context.Context, rootID primitive.ObjectID, repliedAt time.Time, followerIDs []string) (root model.Message, err error)
+	RemoveThreadReply(ctx context.Context, rootID primitive.ObjectID, reply model.Message) (root model.Message, err error)
+	SetAttachmentPreview(ctx context.Context, attachmentID string, preview model.ImagePreview) (err error)
+	HasVisibleAttachment(ctx context.Context, attachmentID string, viewerID string) (visible bool, err error)
+	IsAttachmentReferenced(ctx context.Context, attachmentID string) (referenced bool, err error)
 	CreateChatRoom(ctx context.Context, userID1 string, userID2 string) (chatRoom model.ChatRoom, err error)
 	GetChatRoom(ctx context.Context, userID1 string, userID2 string) (chatRoom model.ChatRoom, err error)
-	// Create Notification
+	GetChatRoomByID(ctx context.Context, roomID primitive.ObjectID) (chatRoom model.ChatRoom, err error)
+	SetDisappearingTimer(ctx context.Context, roomID primitive.ObjectID, disappearAfter int64, disappearFrom string) (err error)
+	GetUserChatRoomIDs(ctx context.Context, userID string) (roomIDs []string, err error)
+	EnsureIndexes(ctx context.Context) (err error)
 }
 
 type ChatRepositoryImpl struct {
@@ -31,32 +54,293 @@ func NewChatRepository(mongo *mongo.Client) ChatRepository {
 	}
 }
 
-func (c *ChatRepositoryImpl) SaveMessage(ctx context.Context, message model.Message) (err error) {
+// SaveMessage stores message with the room's next sequence number, which it
+// returns. A number taken by a message that then fails to be stored is left
+// unused.
+func (c *ChatRepositoryImpl) SaveMessage(ctx context.Context, message model.Message) (seq int64, err error) {
+	seq, err = c.nextSeq(ctx, message.ChatRoomID)
+	if err != nil {
+		log.Println(err)
+		return 0, err
+	}
+
 	collection := c.mongo.Database(os.Getenv("MONGO_DATABASE")).Collection("Messages")
-	res, err := collection.InsertOne(ctx, bson.M{
+	doc := bson.M{
+		"_id":          message.MessageID,
 		"sender_id":    message.SenderID,
 		"receiver_id":  message.ReceiverID,
 		"message_text": message.MessageText,
 		"timestamp":    message.Timestamp,
 		"chat_room_id": message.ChatRoomID,
-	})
+		"seq":          seq,
+	}
+	if message.ReplyToMessageID != "" {
+		doc["reply_to_message_id"] = message.ReplyToMessageID
+		doc["thread_root_id"] = message.ThreadRootID
+	}
+	if len(message.Mentions) > 0 {
+		doc["mentions"] = message.Mentions
+	}
+	if len(message.Attachments) > 0 {
+		doc["attachments"] = message.Attachments
+	}
+	if !message.ExpiresAt.IsZero() {
+		doc["expires_at"] = message.ExpiresAt
+	}
+	if message.ExpireAfterRead > 0 {
+		doc["expire_after_read"] = message.ExpireAfterRead
+	}
+	if message.Notice != nil {
+		doc["notice"] = message.Notice
+	}
+
+	_, err = collection.InsertOne(ctx, doc)
 	if err != nil {
-		return err
+		return 0, err
 	}
-	message.MessageID = res.InsertedID.(primitive.ObjectID)
-	return nil
+	return seq, nil
 }
 
-func (c *ChatRepositoryImpl) GetMessages(ctx context.Context, roomID string, limit int64, offset int64) (messages []model.Message, err error) {
-	collection := c.mongo.Database(os.Getenv("MONGO_DATABASE")).Collection("Messages")
+// nextSeq increments the message counter of roomID and returns it. Counters
+// live apart from the rooms, so each instance of the server takes numbers
+// from the same one, whatever kind of room it is.
+func (c *ChatRepositoryImpl) nextSeq(ctx context.Context, roomID string) (seq int64, err error) {
+	collection := c.mongo.Database(os.Getenv("MONGO_DATABASE")).Collection("MessageSequences")
+
+	opts := options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)
+
+	var counter struct {
+		Seq int64 `bson:"seq"`
+	}
+	err = collection.FindOneAndUpdate(ctx, bson.M{"_id": roomID}, bson.M{"$inc": bson.M{"seq": 1}}, opts).Decode(&counter)
+	if err != nil {
+		return 0, err
+	}
+	return counter.Seq, nil
+}
+
+// GetLastSeq returns the sequence number last handed out in roomID, 0 when
+// it has no numbered messages.
+func (c *ChatRepositoryImpl) GetLastSeq(ctx context.Context, roomID string) (seq int64, err error) {
+	collection := c.mongo.Database(os.Getenv("MONGO_DATABASE")).Collection("MessageSequences")
+
+	var counter struct {
+		Seq int64 `bson:"seq"`
+	}
+	err = collection.FindOne(ctx, bson.M{"_id": roomID}).Decode(&counter)
+	if err == mongo.ErrNoDocuments {
+		return 0, nil
+	} else if err != nil {
+		log.Println(err)
+		return 0, err
+	}
+	return counter.Seq, nil
+}
+
+// GetMessagesBefore returns the messages of roomID sent before cursor, newest
+// first, or the newest ones when cursor is nil. See roomMessagesFilter for
+// viewerID and collapseThreads.
+func (c *ChatRepositoryImpl) GetMessagesBefore(ctx context.Context, roomID string, viewerID string, collapseThreads bool, cursor *model.MessageCursor, limit int64) (messages []model.Message, err error) {
+	opts := options.Find().SetLimit(limit).SetSort(bson.D{{"seq", -1}, {"timestamp", -1}, {"_id", -1}})
+
+	filter := roomMessagesFilter(roomID, viewerID, collapseThreads)
+	if cursor != nil {
+		filter = append(filter, bson.E{"$or", cursorFilter(*cursor, "$lt")})
+	}
+
+	return c.findMessages(ctx, filter, opts)
+}
 
+// GetMessagesAfter returns the messages of roomID sent after cursor, oldest
+// first.
+func (c *ChatRepositoryImpl) GetMessagesAfter(ctx context.Context, roomID string, viewerID string, collapseThreads bool, cursor model.MessageCursor, limit int64) (messages []model.Message, err error) {
+	opts := options.Find().SetLimit(limit).SetSort(bson.D{{"seq", 1}, {"timestamp", 1}, {"_id", 1}})
+
+	filter := append(roomMessagesFilter(roomID, viewerID, collapseThreads), bson.E{"$or", cursorFilter(cursor, "$gt")})
+
+	return c.findMessages(ctx, filter, opts)
+}
+
+// cursorFilter matches the messages before ($lt) or after ($gt) cursor.
+// Messages without a sequence number are older than all those with one, a
+// missing seq compares as null.
+func cursorFilter(cursor model.MessageCursor, op string) bson.A {
+	if cursor.Seq > 0 {
+		if op == "$gt" {
+			return bson.A{bson.M{"seq": bson.M{"$gt": cursor.Seq}}}
+		}
+		return bson.A{bson.M{"seq": bson.M{"$lt": cursor.Seq}}, bson.M{"seq": nil}}
+	}
+
+	filter := bson.A{
+		bson.M{"seq": nil, "timestamp": bson.M{op: cursor.Timestamp}},
+		bson.M{"seq": nil, "timestamp": cursor.Timestamp, "_id": bson.M{op: cursor.MessageID}},
+	}
+	if op == "$gt" {
+		filter = append(filter, bson.M{"seq": bson.M{"$gt": 0}})
+	}
+	return filter
+}
+
+// GetMessagesBySeq returns the messages of roomID numbered fromSeq to toSeq,
+// newest first. Numbers the viewer can't see are missing from it.
+func (c *ChatRepositoryImpl) GetMessagesBySeq(ctx context.Context, roomID string, viewerID string, collapseThreads bool, fromSeq int64, toSeq int64) (messages []model.Message, err error) {
+	opts := options.Find().SetSort(bson.D{{"seq", -1}})
+
+	filter := append(roomMessagesFilter(roomID, viewerID, collapseThreads), bson.E{"seq", bson.M{"$gte": fromSeq, "$lte": toSeq}})
+
+	return c.findMessages(ctx, filter, opts)
+}
+
+// roomMessagesFilter matches the messages of roomID as viewerID sees them,
+// without the ones they deleted for themselves. collapseThreads leaves out
+// replies, so only thread roots stand for their threads.
+func roomMessagesFilter(roomID string, viewerID string, collapseThreads bool) bson.D {
+	filter := bson.D{{"chat_room_id", roomID}, notExpired()}
+	if viewerID != "" {
+		filter = append(filter, bson.E{"deleted_for", bson.M{"$ne": viewerID}})
+	}
+	if collapseThreads {
+		filter = append(filter, bson.E{"thread_root_id", bson.M{"$exists": false}})
+	}
+	return filter
+}
+
+// GetThreadReplies returns the replies in the thread started by rootID,
+// oldest first.
+func (c *ChatRepositoryImpl) GetThreadReplies(ctx context.Context, rootID string, viewerID string, limit int64, offset int64) (messages []model.Message, err error) {
+	opts := options.FindOptions{
+		Limit: &limit,
+		Skip:  &offset,
+		Sort:  bson.D{{"timestamp", 1}},
+	}
+
+	filter := bson.D{{"thread_root_id", rootID}, notExpired()}
+	if viewerID != "" {
+		filter = append(filter, bson.E{"deleted_for", bson.M{"$ne": viewerID}})
+	}
+
+	return c.findMessages(ctx, filter, &opts)
+}
+
+// GetMentions returns the messages mentioning userID in any room, newest
+// first.
+func (c *ChatRepositoryImpl) GetMentions(ctx context.Context, userID string, limit int64, offset int64) (messages []model.Message, err error) {
 	opts := options.FindOptions{
 		Limit: &limit,
-		Skip: &offset,
+		Skip:  &offset,
 		Sort:  bson.D{{"timestamp", -1}},
 	}
 
-	cur, err := collection.Find(ctx, bson.D{{"chat_room_id", roomID}}, &opts)
+	filter := bson.D{
+		{"mentions", userID},
+		{"deleted_for", bson.M{"$ne": userID}},
+		{"deleted_at", bson.M{"$exists": false}},
+		notExpired(),
+	}
+
+	return c.findMessages(ctx, filter, &opts)
+}
+
+// SearchMessages returns the messages in roomIDs matching the text search
+// query text, best matches first, or all of them newest first when text is
+// empty. Zero from and to leave the date range open.
+func (c *ChatRepositoryImpl) SearchMessages(ctx context.Context, text string, roomIDs []string, senderID string, from time.Time, to time.Time, viewerID string, limit int64, offset int64) (messages []model.Message, err error) {
+	opts := options.Find().SetLimit(limit).SetSkip(offset).SetSort(bson.D{{"timestamp", -1}})
+
+	filter := bson.D{
+		{"chat_room_id", bson.M{"$in": roomIDs}},
+		{"deleted_for", bson.M{"$ne": viewerID}},
+		{"deleted_at", bson.M{"$exists": false}},
+		notExpired(),
+	}
+	if text != "" {
+		filter = append(filter, bson.E{"$text", bson.M{"$search": text}})
+		score := bson.M{"$meta": "textScore"}
+		opts.SetProjection(bson.M{"score": score}).SetSort(bson.D{{"score", score}, {"timestamp", -1}})
+	}
+	if senderID != "" {
+		filter = append(filter, bson.E{"sender_id", senderID})
+	}
+
+	timestamp := bson.M{}
+	if !from.IsZero() {
+		timestamp["$gte"] = from
+	}
+	if !to.IsZero() {
+		timestamp["$lt"] = to
+	}
+	if len(timestamp) > 0 {
+		filter = append(filter, bson.E{"timestamp", timestamp})
+	}
+
+	return c.findMessages(ctx, filter, opts)
+}
+
+// notExpired leaves out the messages past their expiry the sweeper didn't
+// remove yet, along with those that never expire.
+func notExpired() bson.E {
+	return bson.E{"expires_at", bson.M{"$not": bson.M{"$lte": time.Now()}}}
+}
+
+// StartReadTimers starts the timers of the messages of roomID up to seq that
+// disappear once read and that readerID didn't send, they expire their
+// expire_after_read seconds after readAt. Timers already started are kept.
+func (c *ChatRepositoryImpl) StartReadTimers(ctx context.Context, roomID string, readerID string, seq int64, readAt time.Time) (err error) {
+	collection := c.mongo.Database(os.Getenv("MONGO_DATABASE")).Collection("Messages")
+
+	filter := bson.M{
+		"chat_room_id":      roomID,
+		"seq":               bson.M{"$lte": seq},
+		"sender_id":         bson.M{"$ne": readerID},
+		"expire_after_read": bson.M{"$exists": true},
+		"expires_at":        bson.M{"$exists": false},
+	}
+	update := mongo.Pipeline{
+		{{"$set", bson.M{"expires_at": bson.M{"$add": bson.A{readAt, bson.M{"$multiply": bson.A{"$expire_after_read", 1000}}}}}}},
+	}
+
+	_, err = collection.UpdateMany(ctx, filter, update)
+	if err != nil {
+		log.Println(err)
+		return err
+	}
+
+	return nil
+}
+
+// GetExpiredMessages returns the messages whose expiry passed at now, those
+// that expired first first.
+func (c *ChatRepositoryImpl) GetExpiredMessages(ctx context.Context, now time.Time, limit int64) (messages []model.Message, err error) {
+	opts := options.Find().SetLimit(limit).SetSort(bson.D{{"expires_at", 1}})
+
+	return c.findMessages(ctx, bson.D{{"expires_at", bson.M{"$lte": now}}}, opts)
+}
+
+// DeleteExpiredMessage deletes a message past its expiry at now. It reports
+// false when the message is already gone, such as when another instance's
+// sweeper got to it first.
+func (c *ChatRepositoryImpl) DeleteExpiredMessage(ctx context.Context, messageID primitive.ObjectID, now time.Time) (deleted bool, err error) {
+	collection := c.mongo.Database(os.Getenv("MONGO_DATABASE")).Collection("Messages")
+
+	filter := bson.M{
+		"_id":        messageID,
+		"expires_at": bson.M{"$lte": now},
+	}
+
+	res, err := collection.DeleteOne(ctx, filter)
+	if err != nil {
+		log.Println(err)
+		return false, err
+	}
+
+	return res.DeletedCount > 0, nil
+}
+
+func (c *ChatRepositoryImpl) findMessages(ctx context.Context, filter bson.D, opts *options.FindOptions) (messages []model.Message, err error) {
+	collection := c.mongo.Database(os.Getenv("MONGO_DATABASE")).Collection("Messages")
+
+	cur, err := collection.Find(ctx, filter, opts)
 	if err != nil {
 		log.Println(err)
 		return []model.Message{}, err
@@ -80,6 +364,277 @@ func (c *ChatRepositoryImpl) GetMessages(ctx context.Context, roomID string, lim
 	return messages, cur.Err()
 }
 
+func (c *ChatRepositoryImpl) GetMessage(ctx context.Context, messageID primitive.ObjectID) (message model.Message, err error) {
+	collection := c.mongo.Database(os.Getenv("MONGO_DATABASE")).Collection("Messages")
+
+	err = collection.FindOne(ctx, bson.M{"_id": messageID}).Decode(&message)
+	if err == mongo.ErrNoDocuments {
+		return message, nil
+	} else if err != nil {
+		log.Println(err)
+		return message, err
+	}
+
+	return message, nil
+}
+
+// EditMessage replaces the text of message and keeps the current one in its
+// edit history. It reports false when the stored text no longer matches
+// message, because another edit got there first.
+func (c *ChatRepositoryImpl) EditMessage(ctx context.Context, message model.Message, messageText string, mentions []string, editedAt time.Time) (edited bool, err error) {
+	collection := c.mongo.Database(os.Getenv("MONGO_DATABASE")).Collection("Messages")
+
+	filter := bson.M{
+		"_id":          message.MessageID,
+		"message_text": message.MessageText,
+		"deleted_at":   bson.M{"$exists": false},
+	}
+	set := bson.M{
+		"message_text": messageText,
+		"edited_at":    editedAt,
+	}
+	update := bson.M{
+		"$set": set,
+		"$push": bson.M{
+			"edit_history": model.MessageVersion{
+				MessageText: message.MessageText,
+				ReplacedAt:  editedAt,
+			},
+		},
+	}
+	if len(mentions) > 0 {
+		set["mentions"] = mentions
+	} else {
+		update["$unset"] = bson.M{"mentions": ""}
+	}
+
+	res, err := collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		log.Println(err)
+		return false, err
+	}
+
+	return res.ModifiedCount > 0, nil
+}
+
+func (c *ChatRepositoryImpl) HideMessage(ctx context.Context, messageID primitive.ObjectID, userID string) (err error) {
+	collection := c.mongo.Database(os.Getenv("MONGO_DATABASE")).Collection("Messages")
+
+	_, err = collection.UpdateOne(ctx, bson.M{"_id": messageID}, bson.M{
+		"$addToSet": bson.M{"deleted_for": userID},
+	})
+	if err != nil {
+		log.Println(err)
+		return err
+	}
+
+	return nil
+}
+
+// DeleteMessage replaces the message with a tombstone. It reports false when
+// the message was already deleted.
+func (c *ChatRepositoryImpl) DeleteMessage(ctx context.Context, messageID primitive.ObjectID, deletedBy string, deletedAt time.Time) (deleted bool, err error) {
+	collection := c.mongo.Database(os.Getenv("MONGO_DATABASE")).Collection("Messages")
+
+	filter := bson.M{
+		"_id":        messageID,
+		"deleted_at": bson.M{"$exists": false},
+	}
+	update := bson.M{
+		"$set": bson.M{
+			"message_text": "",
+			"deleted_at":   deletedAt,
+			"deleted_by":   deletedBy,
+		},
+		"$unset": bson.M{"edit_history": "", "reactions": "", "mentions": "", "attachments": ""},
+	}
+
+	res, err := collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		log.Println(err)
+		return false, err
+	}
+
+	return res.ModifiedCount > 0, nil
+}
+
+// AddReaction records userID's emoji reaction and returns the updated
+// message. It reports false when the user already reacted with that emoji or
+// the message is deleted.
+func (c *ChatRepositoryImpl) AddReaction(ctx context.Context, messageID primitive.ObjectID, emoji string, userID string) (message model.Message, changed bool, err error) {
+	field := "reactions." + emoji
+
+	filter := bson.M{
+		"_id":        messageID,
+		"deleted_at": bson.M{"$exists": false},
+		field:        bson.M{"$ne": userID},
+	}
+	update := bson.M{"$addToSet": bson.M{field: userID}}
+
+	return c.updateReactions(ctx, filter, update)
+}
+
+// RemoveReaction takes back userID's emoji reaction and returns the updated
+// message. It reports false when there was no such reaction.
+func (c *ChatRepositoryImpl) RemoveReaction(ctx context.Context, messageID primitive.ObjectID, emoji string, userID string) (message model.Message, changed bool, err error) {
+	field := "reactions." + emoji
+
+	filter := bson.M{
+		"_id": messageID,
+		field: userID,
+	}
+	update := bson.M{"$pull": bson.M{field: userID}}
+
+	return c.updateReactions(ctx, filter, update)
+}
+
+func (c *ChatRepositoryImpl) updateReactions(ctx context.Context, filter bson.M, update bson.M) (message model.Message, changed bool, err error) {
+	collection := c.mongo.Database(os.Getenv("MONGO_DATABASE")).Collection("Messages")
+
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	err = collection.FindOneAndUpdate(ctx, filter, update, opts).Decode(&message)
+	if err == mongo.ErrNoDocuments {
+		return message, false, nil
+	} else if err != nil {
+		log.Println(err)
+		return message, false, err
+	}
+
+	return message, true, nil
+}
+
+// UpdateThread counts a new reply in the thread started by rootID, adds
+// followerIDs to its followers and returns the updated root.
+func (c *ChatRepositoryImpl) UpdateThread(ctx context.Context, rootID primitive.ObjectID, repliedAt time.Time, followerIDs []string) (root model.Message, err error) {
+	collection := c.mongo.Database(os.Getenv("MONGO_DATABASE")).Collection("Messages")
+
+	update := bson.M{
+		"$inc":      bson.M{"reply_count": 1},
+		"$max":      bson.M{"last_reply_at": repliedAt},
+		"$addToSet": bson.M{"thread_followers": bson.M{"$each": followerIDs}},
+	}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	err = collection.FindOneAndUpdate(ctx, bson.M{"_id": rootID}, update, opts).Decode(&root)
+	if err != nil {
+		log.Println(err)
+		return root, err
+	}
+
+	return root, nil
+}
+
+// RemoveThreadReply uncounts a reply deleted for everyone or expired from the
+// thread started by rootID and returns the updated root, a zero one when the
+// root is gone. When it was the latest reply, last_reply_at goes back to the
+// latest one left, unless a newer reply moved it in the meantime.
+func (c *ChatRepositoryImpl) RemoveThreadReply(ctx context.Context, rootID primitive.ObjectID, reply model.Message) (root model.Message, err error) {
+	collection := c.mongo.Database(os.Getenv("MONGO_DATABASE")).Collection("Messages")
+
+	filter := bson.M{"_id": rootID, "reply_count": bson.M{"$gt": 0}}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	err = collection.FindOneAndUpdate(ctx, filter, bson.M{"$inc": bson.M{"reply_count": -1}}, opts).Decode(&root)
+	if err == mongo.ErrNoDocuments {
+		return root, nil
+	} else if err != nil {
+		log.Println(err)
+		return root, err
+	}
+
+	if !root.LastReplyAt.Equal(reply.Timestamp) {
+		return root, nil
+	}
+
+	latestFilter := bson.D{
+		{"thread_root_id", rootID.Hex()},
+		{"_id", bson.M{"$ne": reply.MessageID}},
+		{"deleted_at", bson.M{"$exists": false}},
+		notExpired(),
+	}
+	latestOpts := options.FindOne().SetSort(bson.D{{"timestamp", -1}}).SetProjection(bson.M{"timestamp": 1})
+
+	var latest model.Message
+	err = collection.FindOne(ctx, latestFilter, latestOpts).Decode(&latest)
+	if err != nil && err != mongo.ErrNoDocuments {
+		log.Println(err)
+		return root, err
+	}
+
+	update := bson.M{"$set": bson.M{"last_reply_at": latest.Timestamp}}
+	if latest.Timestamp.IsZero() {
+		update = bson.M{"$unset": bson.M{"last_reply_at": ""}}
+	}
+
+	res, err := collection.UpdateOne(ctx, bson.M{"_id": rootID, "last_reply_at": reply.Timestamp}, update)
+	if err != nil {
+		log.Println(err)
+		return root, err
+	}
+
+	if res.MatchedCount > 0 {
+		root.LastReplyAt = latest.Timestamp
+	}
+	return root, nil
+}
+
+// SetAttachmentPreview updates the preview of an attachment on every message
+// referencing it.
+func (c *ChatRepositoryImpl) SetAttachmentPreview(ctx context.Context, attachmentID string, preview model.ImagePreview) (err error) {
+	collection := c.mongo.Database(os.Getenv("MONGO_DATABASE")).Collection("Messages")
+
+	filter := bson.M{"attachments.attachment_id": attachmentID}
+	update := bson.M{"$set": bson.M{"attachments.$[attachment].preview": preview}}
+	opts := options.Update().SetArrayFilters(options.ArrayFilters{
+		Filters: []interface{}{bson.M{"attachment.attachment_id": attachmentID}},
+	})
+
+	_, err = collection.UpdateMany(ctx, filter, update, opts)
+	if err != nil {
+		log.Println(err)
+		return err
+	}
+
+	return nil
+}
+
+// HasVisibleAttachment reports whether a message viewerID can still see
+// carries the attachment: one that isn't deleted, for everyone or for them,
+// nor expired.
+func (c *ChatRepositoryImpl) HasVisibleAttachment(ctx context.Context, attachmentID string, viewerID string) (visible bool, err error) {
+	collection := c.mongo.Database(os.Getenv("MONGO_DATABASE")).Collection("Messages")
+
+	filter := bson.D{
+		{"attachments.attachment_id", attachmentID},
+		{"deleted_at", bson.M{"$exists": false}},
+		{"deleted_for", bson.M{"$ne": viewerID}},
+		notExpired(),
+	}
+
+	count, err := collection.CountDocuments(ctx, filter, options.Count().SetLimit(1))
+	if err != nil {
+		log.Println(err)
+		return false, err
+	}
+
+	return count > 0, nil
+}
+
+// IsAttachmentReferenced reports whether any message still carries the
+// attachment, whoever may see it.
+func (c *ChatRepositoryImpl) IsAttachmentReferenced(ctx context.Context, attachmentID string) (referenced bool, err error) {
+	collection := c.mongo.Database(os.Getenv("MONGO_DATABASE")).Collection("Messages")
+
+	count, err := collection.CountDocuments(ctx, bson.M{"attachments.attachment_id": attachmentID}, options.Count().SetLimit(1))
+	if err != nil {
+		log.Println(err)
+		return false, err
+	}
+
+	return count > 0, nil
+}
+
 func (c *ChatRepositoryImpl) CreateChatRoom(ctx context.Context, userID1 string, userID2 string) (chatRoom model.ChatRoom, err error) {
 	collection := c.mongo.Database(os.Getenv("MONGO_DATABASE")).Collection("ChatRoom")
 
@@ -106,6 +661,73 @@ func (c *ChatRepositoryImpl) CreateChatRoom(ctx context.Context, userID1 string,
 	return chatRoom, nil
 }
 
+// GetUserChatRoomIDs returns the IDs of the chat rooms userID is a member of.
+func (c *ChatRepositoryImpl) GetUserChatRoomIDs(ctx context.Context, userID string) (roomIDs []string, err error) {
+	collection := c.mongo.Database(os.Getenv("MONGO_DATABASE")).Collection("ChatRoom")
+
+	opts := options.Find().SetProjection(bson.M{"_id": 1})
+
+	cur, err := collection.Find(ctx, bson.M{"user_ids": userID}, opts)
+	if err != nil {
+		log.Println(err)
+		return []string{}, err
+	}
+	defer cur.Close(ctx)
+
+	var chatRooms []model.ChatRoom
+	if err := cur.All(ctx, &chatRooms); err != nil {
+		log.Println(err)
+		return []string{}, err
+	}
+
+	for _, chatRoom := range chatRooms {
+		roomIDs = append(roomIDs, chatRoom.ChatRoomID.Hex())
+	}
+	return roomIDs, nil
+}
+
+// EnsureIndexes creates the indexes the message queries rely on, it's a no-op
+// for those that already exist.
+func (c *ChatRepositoryImpl) EnsureIndexes(ctx context.Context) (err error) {
+	collection := c.mongo.Database(os.Getenv("MONGO_DATABASE")).Collection("Messages")
+
+	_, err = collection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		// pages of a room's history are ranges of it, in the cursors' order
+		{
+			Keys: bson.D{{"chat_room_id", 1}, {"seq", -1}, {"timestamp", -1}, {"_id", -1}},
+		},
+		// catches a number handed out twice, messages from before numbering
+		// have none
+		{
+			Keys: bson.D{{"chat_room_id", 1}, {"seq", 1}},
+			Options: options.Index().SetUnique(true).
+				SetPartialFilterExpression(bson.M{"seq": bson.M{"$exists": true}}),
+		},
+		// downloads and releases look up the messages carrying an attachment
+		{
+			Keys:    bson.D{{"attachments.attachment_id", 1}},
+			Options: options.Index().SetSparse(true),
+		},
+		// the sweeper looks for disappearing messages past their expiry
+		{
+			Keys:    bson.D{{"expires_at", 1}},
+			Options: options.Index().SetPartialFilterExpression(bson.M{"expires_at": bson.M{"$exists": true}}),
+		},
+		// chats mix languages, so words are matched as written rather than
+		// stemmed as English
+		{
+			Keys:    bson.D{{"message_text", "text"}},
+			Options: options.Index().SetName("message_text_search").SetDefaultLanguage("none"),
+		},
+	})
+	if err != nil {
+		log.Println(err)
+		return err
+	}
+
+	return nil
+}
+
 func (c *ChatRepositoryImpl) GetChatRoom(ctx context.Context, userID1 string, userID2 string) (chatRoom model.ChatRoom, err error) {
 	collection := c.mongo.Database(os.Getenv("MONGO_DATABASE")).Collection("ChatRoom")
 
@@ -121,3 +743,45 @@ func (c *ChatRepositoryImpl) GetChatRoom(ctx context.Context, userID1 string, us
 
 	return chatRoom, nil
 }
+
+// SetDisappearingTimer sets the timer of roomID's new messages, zero
+// disappearAfter turns it off.
+func (c *ChatRepositoryImpl) SetDisappearingTimer(ctx context.Context, roomID primitive.ObjectID, disappearAfter int64, disappearFrom string) (err error) {
+	collection := c.mongo.Database(os.Getenv("MONGO_DATABASE")).Collection("ChatRoom")
+
+	update := bson.M{
+		"$set": bson.M{
+			"disappear_after": disappearAfter,
+			"disappear_from":  disappearFrom,
+			"updated_at":      time.Now(),
+		},
+	}
+	if disappearAfter == 0 {
+		update = bson.M{
+			"$set":   bson.M{"updated_at": time.Now()},
+			"$unset": bson.M{"disappear_after": "", "disappear_from": ""},
+		}
+	}
+
+	_, err = collection.UpdateOne(ctx, bson.M{"_id": roomID}, update)
+	if err != nil {
+		log.Println(err)
+		return err
+	}
+
+	return nil
+}
+
+func (c *ChatRepositoryImpl) GetChatRoomByID(ctx context.Context, roomID primitive.ObjectID) (chatRoom model.ChatRoom, err error) {
+	collection := c.mongo.Database(os.Getenv("MONGO_DATABASE")).Collection("ChatRoom")
+
+	err = collection.FindOne(ctx, bson.M{"_id": roomID}).Decode(&chatRoom)
+	if err == mongo.ErrNoDocuments {
+		return chatRoom, nil
+	} else if err != nil {
+		log.Println(err)
+		return chatRoom, err
+	}
+
+	return chatRoom, nil
+}
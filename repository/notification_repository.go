@@ -0,0 +1,163 @@
+package repository
+
+import (
+	"context"
+	"go-chat/model"
+	"log"
+	"os"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type NotificationRepository interface {
+	CreateNotification(ctx context.Context, notification model.Notification, payload interface{}) (model.Notification, error)
+	GetNotifications(ctx context.Context, userID string, before primitive.ObjectID, limit int64, unreadOnly bool) (notifications []model.Notification, err error)
+	CountUnread(ctx context.Context, userID string) (count int64, err error)
+	MarkAsRead(ctx context.Context, userID string, notificationID primitive.ObjectID) (err error)
+	MarkAllAsRead(ctx context.Context, userID string) (updated int64, err error)
+	DeleteNotification(ctx context.Context, userID string, notificationID primitive.ObjectID) (err error)
+}
+
+type NotificationRepositoryImpl struct {
+	mongo *mongo.Client
+}
+
+func NewNotificationRepository(mongo *mongo.Client) NotificationRepository {
+	return &NotificationRepositoryImpl{mongo: mongo}
+}
+
+func (n *NotificationRepositoryImpl) CreateNotification(ctx context.Context, notification model.Notification, payload interface{}) (model.Notification, error) {
+	collection := n.mongo.Database(os.Getenv("MONGO_DATABASE")).Collection("Notifications")
+
+	rawPayload, err := bson.Marshal(payload)
+	if err != nil {
+		return model.Notification{}, err
+	}
+	notification.Payload = rawPayload
+
+	res, err := collection.InsertOne(ctx, bson.M{
+		"user_id":           notification.UserID,
+		"notification_type": notification.NotificationType,
+		"content":           notification.Content,
+		"payload":           notification.Payload,
+		"timestamp":         notification.Timestamp,
+		"read":              notification.Read,
+	})
+	if err != nil {
+		return model.Notification{}, err
+	}
+
+	notification.NotificationID = res.InsertedID.(primitive.ObjectID)
+	return notification, nil
+}
+
+// GetNotifications returns the user's notifications newest first. Only the
+// ones older than before are returned unless before is the nil ObjectID.
+func (n *NotificationRepositoryImpl) GetNotifications(ctx context.Context, userID string, before primitive.ObjectID, limit int64, unreadOnly bool) (notifications []model.Notification, err error) {
+	collection := n.mongo.Database(os.Getenv("MONGO_DATABASE")).Collection("Notifications")
+
+	filter := bson.D{{"user_id", userID}}
+	if before != primitive.NilObjectID {
+		filter = append(filter, bson.E{"_id", bson.D{{"$lt", before}}})
+	}
+	if unreadOnly {
+		filter = append(filter, bson.E{"read", false})
+	}
+
+	opts := options.FindOptions{
+		Limit: &limit,
+		Sort:  bson.D{{"_id", -1}},
+	}
+
+	cur, err := collection.Find(ctx, filter, &opts)
+	if err != nil {
+		log.Println(err)
+		return []model.Notification{}, err
+	}
+	defer cur.Close(ctx)
+
+	for cur.Next(ctx) {
+		var notification model.Notification
+		err := cur.Decode(&notification)
+		if err != nil {
+			log.Println("fail to decode")
+			return []model.Notification{}, err
+		}
+		notifications = append(notifications, notification)
+	}
+	if err := cur.Err(); err != nil {
+		log.Println(err)
+		return nil, err
+	}
+
+	return notifications, nil
+}
+
+func (n *NotificationRepositoryImpl) CountUnread(ctx context.Context, userID string) (count int64, err error) {
+	collection := n.mongo.Database(os.Getenv("MONGO_DATABASE")).Collection("Notifications")
+
+	filter := bson.D{{"user_id", userID}, {"read", false}}
+
+	count, err = collection.CountDocuments(ctx, filter)
+	if err != nil {
+		log.Println(err)
+		return 0, err
+	}
+
+	return count, nil
+}
+
+func (n *NotificationRepositoryImpl) MarkAsRead(ctx context.Context, userID string, notificationID primitive.ObjectID) (err error) {
+	collection := n.mongo.Database(os.Getenv("MONGO_DATABASE")).Collection("Notifications")
+
+	filter := bson.D{{"_id", notificationID}, {"user_id", userID}}
+	update := bson.D{{"$set", bson.D{{"read", true}}}}
+
+	result, err := collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		log.Println("Failed to update data in database: ", err)
+		return err
+	}
+
+	if result.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+
+	return nil
+}
+
+func (n *NotificationRepositoryImpl) MarkAllAsRead(ctx context.Context, userID string) (updated int64, err error) {
+	collection := n.mongo.Database(os.Getenv("MONGO_DATABASE")).Collection("Notifications")
+
+	filter := bson.D{{"user_id", userID}, {"read", false}}
+	update := bson.D{{"$set", bson.D{{"read", true}}}}
+
+	result, err := collection.UpdateMany(ctx, filter, update)
+	if err != nil {
+		log.Println("Failed to update data in database: ", err)
+		return 0, err
+	}
+
+	return result.ModifiedCount, nil
+}
+
+func (n *NotificationRepositoryImpl) DeleteNotification(ctx context.Context, userID string, notificationID primitive.ObjectID) (err error) {
+	collection := n.mongo.Database(os.Getenv("MONGO_DATABASE")).Collection("Notifications")
+
+	filter := bson.D{{"_id", notificationID}, {"user_id", userID}}
+
+	result, err := collection.DeleteOne(ctx, filter)
+	if err != nil {
+		log.Println("Failed to delete data in database: ", err)
+		return err
+	}
+
+	if result.DeletedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+
+	return nil
+}
@@ -0,0 +1,245 @@
+package repository
+
+import (
+	"context"
+	"go-chat/constant"
+	"go-chat/model"
+	"log"
+	"os"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type ScheduleRepository interface {
+	SaveScheduledMessage(ctx context.Context, message model.ScheduledMessage) (err error)
+	GetScheduledMessage(ctx context.Context, scheduledMessageID primitive.ObjectID) (message model.ScheduledMessage, err error)
+	GetScheduledMessages(ctx context.Context, senderID string, roomID string) (messages []model.ScheduledMessage, err error)
+	CountScheduledMessages(ctx context.Context, senderID string) (count int64, err error)
+	UpdateScheduledMessage(ctx context.Context, message model.ScheduledMessage) (updated bool, err error)
+	CancelScheduledMessage(ctx context.Context, scheduledMessageID primitive.ObjectID) (cancelled bool, err error)
+	ClaimDueMessage(ctx context.Context, now time.Time, lockedUntil time.Time) (message model.ScheduledMessage, claimed bool, err error)
+	FailScheduledMessage(ctx context.Context, scheduledMessageID primitive.ObjectID, reason string) (err error)
+	DeleteScheduledMessage(ctx context.Context, scheduledMessageID primitive.ObjectID) (err error)
+	IsAttachmentScheduled(ctx context.Context, attachmentID string) (scheduled bool, err error)
+	EnsureIndexes(ctx context.Context) (err error)
+}
+
+type ScheduleRepositoryImpl struct {
+	mongo *mongo.Client
+}
+
+func NewScheduleRepository(mongo *mongo.Client) ScheduleRepository {
+	return &ScheduleRepositoryImpl{
+		mongo: mongo,
+	}
+}
+
+func (s *ScheduleRepositoryImpl) SaveScheduledMessage(ctx context.Context, message model.ScheduledMessage) (err error) {
+	collection := s.mongo.Database(os.Getenv("MONGO_DATABASE")).Collection("ScheduledMessages")
+
+	_, err = collection.InsertOne(ctx, message)
+	if err != nil {
+		log.Println(err)
+		return err
+	}
+
+	return nil
+}
+
+func (s *ScheduleRepositoryImpl) GetScheduledMessage(ctx context.Context, scheduledMessageID primitive.ObjectID) (message model.ScheduledMessage, err error) {
+	collection := s.mongo.Database(os.Getenv("MONGO_DATABASE")).Collection("ScheduledMessages")
+
+	err = collection.FindOne(ctx, bson.M{"_id": scheduledMessageID}).Decode(&message)
+	if err == mongo.ErrNoDocuments {
+		return message, nil
+	} else if err != nil {
+		log.Println(err)
+		return message, err
+	}
+
+	return message, nil
+}
+
+// GetScheduledMessages returns the messages senderID scheduled, in roomID
+// unless it's empty, soonest first.
+func (s *ScheduleRepositoryImpl) GetScheduledMessages(ctx context.Context, senderID string, roomID string) (messages []model.ScheduledMessage, err error) {
+	collection := s.mongo.Database(os.Getenv("MONGO_DATABASE")).Collection("ScheduledMessages")
+
+	filter := bson.M{"sender_id": senderID}
+	if roomID != "" {
+		filter["chat_room_id"] = roomID
+	}
+
+	opts := options.Find().SetSort(bson.D{{"send_at", 1}, {"_id", 1}})
+
+	cur, err := collection.Find(ctx, filter, opts)
+	if err != nil {
+		log.Println(err)
+		return []model.ScheduledMessage{}, err
+	}
+	defer cur.Close(ctx)
+
+	messages = []model.ScheduledMessage{}
+	if err := cur.All(ctx, &messages); err != nil {
+		log.Println(err)
+		return []model.ScheduledMessage{}, err
+	}
+
+	return messages, nil
+}
+
+func (s *ScheduleRepositoryImpl) CountScheduledMessages(ctx context.Context, senderID string) (count int64, err error) {
+	collection := s.mongo.Database(os.Getenv("MONGO_DATABASE")).Collection("ScheduledMessages")
+
+	count, err = collection.CountDocuments(ctx, bson.M{"sender_id": senderID})
+	if err != nil {
+		log.Println(err)
+		return 0, err
+	}
+
+	return count, nil
+}
+
+// UpdateScheduledMessage stores the content and time of message and makes it
+// pending again, unless the scheduler claimed it in the meantime.
+func (s *ScheduleRepositoryImpl) UpdateScheduledMessage(ctx context.Context, message model.ScheduledMessage) (updated bool, err error) {
+	collection := s.mongo.Database(os.Getenv("MONGO_DATABASE")).Collection("ScheduledMessages")
+
+	filter := bson.M{
+		"_id":    message.ScheduledMessageID,
+		"status": bson.M{"$ne": constant.SCHEDULED_SENDING},
+	}
+	update := bson.M{
+		"$set": bson.M{
+			"message_text":   message.MessageText,
+			"attachment_ids": message.AttachmentIDs,
+			"send_at":        message.SendAt,
+			"status":         constant.SCHEDULED_PENDING,
+			"attempts":       0,
+			"updated_at":     message.UpdatedAt,
+		},
+		"$unset": bson.M{"error": "", "locked_until": ""},
+	}
+
+	res, err := collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		log.Println(err)
+		return false, err
+	}
+
+	return res.MatchedCount > 0, nil
+}
+
+// CancelScheduledMessage deletes a scheduled message unless the scheduler
+// claimed it in the meantime.
+func (s *ScheduleRepositoryImpl) CancelScheduledMessage(ctx context.Context, scheduledMessageID primitive.ObjectID) (cancelled bool, err error) {
+	collection := s.mongo.Database(os.Getenv("MONGO_DATABASE")).Collection("ScheduledMessages")
+
+	filter := bson.M{
+		"_id":    scheduledMessageID,
+		"status": bson.M{"$ne": constant.SCHEDULED_SENDING},
+	}
+
+	res, err := collection.DeleteOne(ctx, filter)
+	if err != nil {
+		log.Println(err)
+		return false, err
+	}
+
+	return res.DeletedCount > 0, nil
+}
+
+// ClaimDueMessage marks the earliest message due at now as being sent until
+// lockedUntil and returns it. Messages whose claim ran out, because the
+// instance sending them stopped or failed to, are claimed again.
+func (s *ScheduleRepositoryImpl) ClaimDueMessage(ctx context.Context, now time.Time, lockedUntil time.Time) (message model.ScheduledMessage, claimed bool, err error) {
+	collection := s.mongo.Database(os.Getenv("MONGO_DATABASE")).Collection("ScheduledMessages")
+
+	filter := bson.M{"$or": bson.A{
+		bson.M{"status": constant.SCHEDULED_PENDING, "send_at": bson.M{"$lte": now}},
+		bson.M{"status": constant.SCHEDULED_SENDING, "locked_until": bson.M{"$lte": now}},
+	}}
+	update := bson.M{
+		"$set": bson.M{"status": constant.SCHEDULED_SENDING, "locked_until": lockedUntil},
+		"$inc": bson.M{"attempts": 1},
+	}
+	opts := options.FindOneAndUpdate().
+		SetSort(bson.D{{"send_at", 1}}).
+		SetReturnDocument(options.After)
+
+	err = collection.FindOneAndUpdate(ctx, filter, update, opts).Decode(&message)
+	if err == mongo.ErrNoDocuments {
+		return message, false, nil
+	} else if err != nil {
+		log.Println(err)
+		return message, false, err
+	}
+
+	return message, true, nil
+}
+
+func (s *ScheduleRepositoryImpl) FailScheduledMessage(ctx context.Context, scheduledMessageID primitive.ObjectID, reason string) (err error) {
+	collection := s.mongo.Database(os.Getenv("MONGO_DATABASE")).Collection("ScheduledMessages")
+
+	update := bson.M{
+		"$set":   bson.M{"status": constant.SCHEDULED_FAILED, "error": reason},
+		"$unset": bson.M{"locked_until": ""},
+	}
+
+	_, err = collection.UpdateOne(ctx, bson.M{"_id": scheduledMessageID}, update)
+	if err != nil {
+		log.Println(err)
+		return err
+	}
+
+	return nil
+}
+
+func (s *ScheduleRepositoryImpl) DeleteScheduledMessage(ctx context.Context, scheduledMessageID primitive.ObjectID) (err error) {
+	collection := s.mongo.Database(os.Getenv("MONGO_DATABASE")).Collection("ScheduledMessages")
+
+	_, err = collection.DeleteOne(ctx, bson.M{"_id": scheduledMessageID})
+	if err != nil {
+		log.Println(err)
+		return err
+	}
+
+	return nil
+}
+
+// IsAttachmentScheduled reports whether a scheduled message, sent or not yet,
+// carries the attachment.
+func (s *ScheduleRepositoryImpl) IsAttachmentScheduled(ctx context.Context, attachmentID string) (scheduled bool, err error) {
+	collection := s.mongo.Database(os.Getenv("MONGO_DATABASE")).Collection("ScheduledMessages")
+
+	count, err := collection.CountDocuments(ctx, bson.M{"attachment_ids": attachmentID}, options.Count().SetLimit(1))
+	if err != nil {
+		log.Println(err)
+		return false, err
+	}
+
+	return count > 0, nil
+}
+
+// EnsureIndexes creates the indexes the scheduler and the users' lists rely
+// on, it's a no-op for those that already exist.
+func (s *ScheduleRepositoryImpl) EnsureIndexes(ctx context.Context) (err error) {
+	collection := s.mongo.Database(os.Getenv("MONGO_DATABASE")).Collection("ScheduledMessages")
+
+	_, err = collection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{"status", 1}, {"send_at", 1}}},
+		{Keys: bson.D{{"status", 1}, {"locked_until", 1}}},
+		{Keys: bson.D{{"sender_id", 1}, {"send_at", 1}}},
+		{Keys: bson.D{{"attachment_ids", 1}}, Options: options.Index().SetSparse(true)},
+	})
+	if err != nil {
+		log.Println(err)
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,163 @@
+package repository
+
+import (
+	"context"
+	"go-chat/model"
+	"log"
+	"os"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type UploadRepository interface {
+	SaveUpload(ctx context.Context, upload model.Upload) (err error)
+	GetUpload(ctx context.Context, uploadID primitive.ObjectID) (upload model.Upload, err error)
+	GetActiveUploads(ctx context.Context, uploaderID string, now time.Time) (uploads []model.Upload, err error)
+	GetExpiredUploads(ctx context.Context, now time.Time, limit int64) (uploads []model.Upload, err error)
+	AppendChunk(ctx context.Context, uploadID primitive.ObjectID, offset int64, chunk model.UploadChunk, expiresAt time.Time) (appended bool, err error)
+	CompleteUpload(ctx context.Context, uploadID primitive.ObjectID, attachmentID string) (completed bool, err error)
+	DeleteUpload(ctx context.Context, uploadID primitive.ObjectID) (err error)
+}
+
+type UploadRepositoryImpl struct {
+	mongo *mongo.Client
+}
+
+func NewUploadRepository(mongo *mongo.Client) UploadRepository {
+	return &UploadRepositoryImpl{
+		mongo: mongo,
+	}
+}
+
+func (u *UploadRepositoryImpl) SaveUpload(ctx context.Context, upload model.Upload) (err error) {
+	collection := u.mongo.Database(os.Getenv("MONGO_DATABASE")).Collection("Uploads")
+
+	_, err = collection.InsertOne(ctx, upload)
+	if err != nil {
+		log.Println(err)
+		return err
+	}
+
+	return nil
+}
+
+func (u *UploadRepositoryImpl) GetUpload(ctx context.Context, uploadID primitive.ObjectID) (upload model.Upload, err error) {
+	collection := u.mongo.Database(os.Getenv("MONGO_DATABASE")).Collection("Uploads")
+
+	err = collection.FindOne(ctx, bson.M{"_id": uploadID}).Decode(&upload)
+	if err == mongo.ErrNoDocuments {
+		return upload, nil
+	} else if err != nil {
+		log.Println(err)
+		return upload, err
+	}
+
+	return upload, nil
+}
+
+// GetActiveUploads returns the uploads of uploaderID that are neither
+// complete nor expired.
+func (u *UploadRepositoryImpl) GetActiveUploads(ctx context.Context, uploaderID string, now time.Time) (uploads []model.Upload, err error) {
+	collection := u.mongo.Database(os.Getenv("MONGO_DATABASE")).Collection("Uploads")
+
+	filter := bson.M{
+		"uploader_id":   uploaderID,
+		"attachment_id": bson.M{"$exists": false},
+		"expires_at":    bson.M{"$gt": now},
+	}
+
+	cur, err := collection.Find(ctx, filter)
+	if err != nil {
+		log.Println(err)
+		return []model.Upload{}, err
+	}
+	defer cur.Close(ctx)
+
+	if err := cur.All(ctx, &uploads); err != nil {
+		log.Println(err)
+		return []model.Upload{}, err
+	}
+
+	return uploads, nil
+}
+
+func (u *UploadRepositoryImpl) GetExpiredUploads(ctx context.Context, now time.Time, limit int64) (uploads []model.Upload, err error) {
+	collection := u.mongo.Database(os.Getenv("MONGO_DATABASE")).Collection("Uploads")
+
+	opts := options.Find().SetSort(bson.D{{"expires_at", 1}}).SetLimit(limit)
+
+	cur, err := collection.Find(ctx, bson.M{"expires_at": bson.M{"$lte": now}}, opts)
+	if err != nil {
+		log.Println(err)
+		return []model.Upload{}, err
+	}
+	defer cur.Close(ctx)
+
+	if err := cur.All(ctx, &uploads); err != nil {
+		log.Println(err)
+		return []model.Upload{}, err
+	}
+
+	return uploads, nil
+}
+
+// AppendChunk adds chunk to the upload unless its offset moved past offset
+// in the meantime, such as when the same chunk is sent twice at once.
+func (u *UploadRepositoryImpl) AppendChunk(ctx context.Context, uploadID primitive.ObjectID, offset int64, chunk model.UploadChunk, expiresAt time.Time) (appended bool, err error) {
+	collection := u.mongo.Database(os.Getenv("MONGO_DATABASE")).Collection("Uploads")
+
+	filter := bson.M{
+		"_id":    uploadID,
+		"offset": offset,
+	}
+	update := bson.M{
+		"$push": bson.M{"chunks": chunk},
+		"$inc":  bson.M{"offset": chunk.Size},
+		"$set":  bson.M{"expires_at": expiresAt},
+	}
+
+	res, err := collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		log.Println(err)
+		return false, err
+	}
+
+	return res.ModifiedCount > 0, nil
+}
+
+func (u *UploadRepositoryImpl) CompleteUpload(ctx context.Context, uploadID primitive.ObjectID, attachmentID string) (completed bool, err error) {
+	collection := u.mongo.Database(os.Getenv("MONGO_DATABASE")).Collection("Uploads")
+
+	filter := bson.M{
+		"_id":           uploadID,
+		"attachment_id": bson.M{"$exists": false},
+	}
+	update := bson.M{
+		"$set":   bson.M{"attachment_id": attachmentID},
+		"$unset": bson.M{"chunks": ""},
+	}
+
+	res, err := collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		log.Println(err)
+		return false, err
+	}
+
+	return res.ModifiedCount > 0, nil
+}
+
+func (u *UploadRepositoryImpl) DeleteUpload(ctx context.Context, uploadID primitive.ObjectID) (err error) {
+	collection := u.mongo.Database(os.Getenv("MONGO_DATABASE")).Collection("Uploads")
+
+	_, err = collection.DeleteOne(ctx, bson.M{"_id": uploadID})
+	if err != nil {
+		log.Println(err)
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,69 @@
+package service
+
+import (
+	"context"
+	"go-chat/dto"
+	"go-chat/pkg/websocket"
+	"log"
+	"time"
+)
+
+type AdminService interface {
+	GetHubState(ctx context.Context) (resp dto.HubStateResponse, err error)
+	KickConnections(ctx context.Context, req dto.KickConnectionRequest) (resp dto.KickConnectionResponse, err error)
+}
+
+type AdminServiceImpl struct {
+	hub *websocket.Hub
+}
+
+func NewAdminService(hub *websocket.Hub) AdminService {
+	return &AdminServiceImpl{hub: hub}
+}
+
+func (a *AdminServiceImpl) GetHubState(ctx context.Context) (resp dto.HubStateResponse, err error) {
+	snapshot, err := a.hub.Snapshot(ctx)
+	if err != nil {
+		log.Println(err)
+		return resp, err
+	}
+
+	now := time.Now()
+
+	resp = dto.HubStateResponse{
+		Connections: []dto.HubConnectionResponse{},
+		Rooms:       snapshot.Rooms,
+		Users:       snapshot.Users,
+	}
+	for _, conn := range snapshot.Connections {
+		resp.Connections = append(resp.Connections, dto.HubConnectionResponse{
+			ConnectionID:   conn.ID,
+			UserID:         conn.UserID,
+			RoomID:         conn.RoomID,
+			RemoteAddr:     conn.RemoteAddr,
+			Transport:      conn.Transport,
+			Protocol:       conn.Protocol,
+			ConnectedAt:    conn.ConnectedAt,
+			AgeSeconds:     int64(now.Sub(conn.ConnectedAt).Seconds()),
+			QueueDepth:     conn.Queue.Depth,
+			QueueHighWater: conn.Queue.HighWater,
+			Dropped:        conn.Queue.Dropped,
+			Coalesced:      conn.Queue.Coalesced,
+		})
+	}
+
+	return resp, nil
+}
+
+func (a *AdminServiceImpl) KickConnections(ctx context.Context, req dto.KickConnectionRequest) (resp dto.KickConnectionResponse, err error) {
+	kicked, err := a.hub.Kick(ctx, req.ConnectionID, req.UserID)
+	if err != nil {
+		log.Println(err)
+		return resp, err
+	}
+
+	log.Printf("Admin kicked %d connection(s), connection_id=%q user_id=%q", kicked, req.ConnectionID, req.UserID)
+
+	resp = dto.KickConnectionResponse{Kicked: kicked}
+	return resp, nil
+}
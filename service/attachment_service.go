@@ -0,0 +1,718 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go-chat/constant"
+	"go-chat/dto"
+	"go-chat/model"
+	"go-chat/pkg/imaging"
+	"go-chat/pkg/storage"
+	"go-chat/pkg/websocket"
+	"go-chat/repository"
+	"image"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	// sniffLength is how many bytes http.DetectContentType looks at.
+	sniffLength = 512
+
+	previewQueueSize     = 100
+	previewSweepInterval = time.Minute
+	// maxPreviewPixels bounds the images the preview worker decodes.
+	maxPreviewPixels = 50_000_000
+	// blurHashSize is the size of the thumbnail placeholders are computed
+	// from, they only keep a few colors anyway.
+	blurHashSize = 32
+)
+
+// thumbnailSizes are the longest edges, in pixels, of the thumbnails made for
+// each image. Sizes the image isn't larger than are skipped.
+var thumbnailSizes = []int{160, 480, 1280}
+
+// attachmentTypes are the content types uploads may have, as sniffed from
+// their content. Types browsers could run as a page, such as HTML or SVG,
+// are left out.
+var attachmentTypes = map[string]bool{
+	"image/jpeg":      true,
+	"image/png":       true,
+	"image/gif":       true,
+	"image/webp":      true,
+	"image/bmp":       true,
+	"application/pdf": true,
+	"application/zip": true,
+	"application/ogg": true,
+	"text/plain":      true,
+	"audio/mpeg":      true,
+	"audio/wave":      true,
+	"video/mp4":       true,
+	"video/webm":      true,
+}
+
+type AttachmentService interface {
+	UploadAttachment(ctx context.Context, data dto.UploadAttachmentRequest) (resp dto.AttachmentResponse, err error)
+	DownloadAttachment(ctx context.Context, data dto.DownloadAttachmentRequest) (resp dto.AttachmentResponse, content io.ReadCloser, err error)
+	ReleaseAttachments(ctx context.Context, attachmentIDs []string)
+	RunPreviewWorker(ctx context.Context)
+}
+
+type AttachmentConfig struct {
+	// MaxSize caps the size of every upload.
+	MaxSize int64
+	// MaxImageSize caps image uploads, which clients display inline, zero
+	// leaves them to MaxSize.
+	MaxImageSize int64
+}
+
+type AttachmentServiceImpl struct {
+	attachmentRepository repository.AttachmentRepository
+	chatRepository       repository.ChatRepository
+	scheduleRepository   repository.ScheduleRepository
+	blobStore            storage.BlobStore
+	hub                  *websocket.Hub
+	config               AttachmentConfig
+	previews             chan primitive.ObjectID
+}
+
+func NewAttachmentService(attachmentRepository repository.AttachmentRepository, chatRepository repository.ChatRepository, scheduleRepository repository.ScheduleRepository, blobStore storage.BlobStore, hub *websocket.Hub, config AttachmentConfig) AttachmentService {
+	return &AttachmentServiceImpl{
+		attachmentRepository: attachmentRepository,
+		chatRepository:       chatRepository,
+		scheduleRepository:   scheduleRepository,
+		blobStore:            blobStore,
+		hub:                  hub,
+		config:               config,
+		previews:             make(chan primitive.ObjectID, previewQueueSize),
+	}
+}
+
+func (a *AttachmentServiceImpl) UploadAttachment(ctx context.Context, data dto.UploadAttachmentRequest) (resp dto.AttachmentResponse, err error) {
+	err = checkRoomMember(ctx, a.chatRepository, data.ChatRoomID, data.UploaderID)
+	if err != nil {
+		log.Println(err)
+		return resp, err
+	}
+
+	// the content is spooled to a temporary file because the blob is named
+	// after its hash, which is only known once all of it has been read
+	tmp, err := os.CreateTemp("", "attachment-*")
+	if err != nil {
+		log.Println(err)
+		return resp, err
+	}
+	defer func() {
+		tmp.Close()
+		os.Remove(tmp.Name())
+	}()
+
+	size, err := io.Copy(tmp, io.LimitReader(data.Content, a.config.MaxSize+1))
+	if err != nil {
+		log.Println(err)
+		return resp, err
+	}
+
+	if size == 0 {
+		err = errors.New(constant.ERROR_ATTACHMENT_EMPTY)
+		log.Println(err)
+		return resp, err
+	}
+
+	if size > a.config.MaxSize {
+		err = errors.New(constant.ERROR_ATTACHMENT_TOO_LARGE)
+		log.Println(err)
+		return resp, err
+	}
+
+	head := make([]byte, sniffLength)
+	n, err := tmp.ReadAt(head, 0)
+	if err != nil && err != io.EOF {
+		log.Println(err)
+		return resp, err
+	}
+
+	// the type the client claims is ignored, only the content decides
+	contentType, _, err := mime.ParseMediaType(http.DetectContentType(head[:n]))
+	if err != nil || !attachmentTypes[contentType] {
+		err = errors.New(constant.ERROR_ATTACHMENT_TYPE)
+		log.Println(err)
+		return resp, err
+	}
+
+	if strings.HasPrefix(contentType, "image/") && a.config.MaxImageSize > 0 && size > a.config.MaxImageSize {
+		err = errors.New(constant.ERROR_ATTACHMENT_TOO_LARGE)
+		log.Println(err)
+		return resp, err
+	}
+
+	if _, err = tmp.Seek(0, io.SeekStart); err != nil {
+		log.Println(err)
+		return resp, err
+	}
+
+	var content io.ReadSeeker = tmp
+	var preview *model.ImagePreview
+	if strings.HasPrefix(contentType, "image/") {
+		var image []byte
+		image, preview, err = prepareImage(tmp, contentType)
+		if err != nil {
+			log.Println(err)
+			return resp, err
+		}
+		content = bytes.NewReader(image)
+		size = int64(len(image))
+	}
+
+	key, err := hashContent(content)
+	if err != nil {
+		log.Println(err)
+		return resp, err
+	}
+
+	attachment := model.Attachment{
+		AttachmentID: primitive.NewObjectID(),
+		ChatRoomID:   data.ChatRoomID,
+		UploaderID:   data.UploaderID,
+		FileName:     attachmentFileName(data.FileName),
+		ContentType:  contentType,
+		Size:         size,
+		SHA256:       key,
+		CreatedAt:    time.Now(),
+		Preview:      preview,
+	}
+
+	// the attachment is saved before its blob is looked for, so a release of
+	// the same content either counts it and keeps the blob, or holds the
+	// release mark already and the blob is stored again once it's gone
+	err = a.attachmentRepository.SaveAttachment(ctx, attachment)
+	if err != nil {
+		log.Println(err)
+		return resp, err
+	}
+
+	err = a.storeContent(ctx, &attachment, content)
+	if err != nil {
+		if err := a.attachmentRepository.DeleteAttachment(ctx, attachment.AttachmentID); err != nil {
+			log.Println(err)
+		}
+		return resp, err
+	}
+
+	if attachment.Preview != nil && attachment.Preview.Status == constant.PREVIEW_PENDING {
+		a.queuePreview(attachment.AttachmentID)
+	}
+
+	return toAttachmentResponse(attachment), nil
+}
+
+// storeContent puts the blob of a saved attachment unless it's stored already,
+// in which case an image reuses the preview made for the same content before.
+func (a *AttachmentServiceImpl) storeContent(ctx context.Context, attachment *model.Attachment, content io.ReadSeeker) error {
+	if err := a.waitForContentRelease(ctx, attachment.SHA256); err != nil {
+		log.Println(err)
+		return err
+	}
+
+	exists, err := a.blobStore.Exists(ctx, attachment.SHA256)
+	if err != nil {
+		log.Println(err)
+		return err
+	}
+
+	if !exists {
+		err = a.blobStore.Put(ctx, attachment.SHA256, content, attachment.Size, attachment.ContentType)
+		if err != nil {
+			log.Println("Failed to store attachment: ", err)
+			return err
+		}
+		return nil
+	}
+
+	if attachment.Preview == nil {
+		return nil
+	}
+
+	processed, err := a.attachmentRepository.GetProcessedImage(ctx, attachment.SHA256)
+	if err != nil {
+		log.Println(err)
+		return nil
+	}
+	if processed.Preview != nil {
+		if err := a.attachmentRepository.SetPreview(ctx, attachment.AttachmentID, *processed.Preview); err != nil {
+			log.Println(err)
+			return nil
+		}
+		attachment.Preview = processed.Preview
+	}
+	return nil
+}
+
+// waitForContentRelease returns once no release is deleting the blobs stored
+// under key.
+func (a *AttachmentServiceImpl) waitForContentRelease(ctx context.Context, key string) error {
+	for {
+		releasing, err := a.attachmentRepository.IsContentReleasing(ctx, key)
+		if err != nil || !releasing {
+			return err
+		}
+
+		select {
+		case <-time.After(100 * time.Millisecond):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// prepareImage strips the metadata of an uploaded image and reads its
+// dimensions, its placeholder and thumbnails are left to the preview worker.
+// Images are small enough to be handled in memory, see MaxImageSize.
+func prepareImage(r io.Reader, contentType string) (image []byte, preview *model.ImagePreview, err error) {
+	image, err = io.ReadAll(r)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	image, err = imaging.StripMetadata(image, contentType)
+	if err != nil {
+		return nil, nil, errors.New(constant.ERROR_INVALID_IMAGE)
+	}
+
+	width, height, err := imaging.Dimensions(image)
+	if err != nil {
+		return nil, nil, errors.New(constant.ERROR_INVALID_IMAGE)
+	}
+
+	return image, &model.ImagePreview{
+		Status: constant.PREVIEW_PENDING,
+		Width:  width,
+		Height: height,
+	}, nil
+}
+
+// hashContent returns the hex SHA256 of content, which names its blob, and
+// rewinds it.
+func hashContent(content io.ReadSeeker) (string, error) {
+	hash := sha256.New()
+	if _, err := io.Copy(hash, content); err != nil {
+		return "", err
+	}
+
+	if _, err := content.Seek(0, io.SeekStart); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// queuePreview hands an image to the preview worker. When the queue is full
+// the image stays pending until the worker's next sweep.
+func (a *AttachmentServiceImpl) queuePreview(attachmentID primitive.ObjectID) {
+	select {
+	case a.previews <- attachmentID:
+	default:
+		log.Printf("Preview queue full, attachment %s left to the next sweep", attachmentID.Hex())
+	}
+}
+
+// RunPreviewWorker generates the previews of uploaded images, one at a time,
+// until ctx is done. It also sweeps for images still pending after a while,
+// because the queue was full or the server stopped before getting to them.
+func (a *AttachmentServiceImpl) RunPreviewWorker(ctx context.Context) {
+	ticker := time.NewTicker(previewSweepInterval)
+	defer ticker.Stop()
+
+	a.sweepPreviews(ctx)
+
+	for {
+		select {
+		case attachmentID := <-a.previews:
+			a.generatePreview(ctx, attachmentID)
+		case <-ticker.C:
+			a.sweepPreviews(ctx)
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (a *AttachmentServiceImpl) sweepPreviews(ctx context.Context) {
+	attachments, err := a.attachmentRepository.GetPendingPreviews(ctx, time.Now().Add(-previewSweepInterval), previewQueueSize)
+	if err != nil {
+		log.Println(err)
+		return
+	}
+
+	for _, attachment := range attachments {
+		if ctx.Err() != nil {
+			return
+		}
+		a.generatePreview(ctx, attachment.AttachmentID)
+	}
+}
+
+// generatePreview stores an image's preview, on the attachment and on the
+// messages already referencing it, and tells its room. A failed preview keeps
+// the image's dimensions so clients can still lay it out.
+func (a *AttachmentServiceImpl) generatePreview(ctx context.Context, attachmentID primitive.ObjectID) {
+	attachment, err := a.attachmentRepository.GetAttachment(ctx, attachmentID)
+	if err != nil {
+		log.Println(err)
+		return
+	}
+
+	if attachment.Preview == nil || attachment.Preview.Status != constant.PREVIEW_PENDING {
+		return
+	}
+
+	preview, err := a.buildPreview(ctx, attachment)
+	if err != nil {
+		if ctx.Err() != nil {
+			// stopped by a shutdown, the next sweep picks it up again
+			return
+		}
+		log.Printf("Failed to generate preview of attachment %s: %v", attachmentID.Hex(), err)
+		preview = *attachment.Preview
+		preview.Status = constant.PREVIEW_FAILED
+	}
+
+	err = a.attachmentRepository.SetPreview(ctx, attachmentID, preview)
+	if err != nil {
+		log.Println(err)
+		return
+	}
+
+	err = a.chatRepository.SetAttachmentPreview(ctx, attachmentID.Hex(), preview)
+	if err != nil {
+		log.Println(err)
+	}
+
+	payload, err := json.Marshal(map[string]interface{}{
+		"action":        "attachment_preview",
+		"attachment_id": attachmentID.Hex(),
+		"chat_room_id":  attachment.ChatRoomID,
+		"preview":       toImagePreview(&preview),
+	})
+	if err != nil {
+		log.Println(err)
+		return
+	}
+
+	err = a.hub.Publish(ctx, websocket.RoomEvent{RoomID: attachment.ChatRoomID, Data: payload})
+	if err != nil {
+		log.Println("Failed to publish attachment preview: ", err)
+	}
+}
+
+func (a *AttachmentServiceImpl) buildPreview(ctx context.Context, attachment model.Attachment) (preview model.ImagePreview, err error) {
+	preview = *attachment.Preview
+
+	// the dimensions come from the header, decoding a small file may still
+	// take far more memory than its size suggests
+	if preview.Width*preview.Height > maxPreviewPixels {
+		return preview, fmt.Errorf("%dx%d pixels is too large to preview", preview.Width, preview.Height)
+	}
+
+	content, err := a.blobStore.Get(ctx, attachment.SHA256)
+	if err != nil {
+		return preview, err
+	}
+	data, err := io.ReadAll(content)
+	content.Close()
+	if err != nil {
+		return preview, err
+	}
+
+	img, err := imaging.Decode(data)
+	if err != nil {
+		return preview, err
+	}
+	orientation := imaging.Orientation(data)
+
+	preview.Thumbnails = nil
+	for _, size := range thumbnailSizes {
+		if size >= max(preview.Width, preview.Height) {
+			break
+		}
+
+		thumbnail, err := a.storeThumbnail(ctx, attachment.SHA256, img, size, orientation)
+		if err != nil {
+			return preview, err
+		}
+		preview.Thumbnails = append(preview.Thumbnails, thumbnail)
+	}
+
+	xComponents, yComponents := 4, 3
+	if preview.Height > preview.Width {
+		xComponents, yComponents = 3, 4
+	}
+	preview.BlurHash = imaging.BlurHash(imaging.Thumbnail(img, blurHashSize, orientation), xComponents, yComponents)
+	preview.Status = constant.PREVIEW_READY
+
+	return preview, nil
+}
+
+func (a *AttachmentServiceImpl) storeThumbnail(ctx context.Context, sha256 string, img image.Image, size int, orientation int) (thumbnail model.Thumbnail, err error) {
+	scaled := imaging.Thumbnail(img, size, orientation)
+
+	var buf bytes.Buffer
+	contentType, err := imaging.Encode(&buf, scaled)
+	if err != nil {
+		return thumbnail, err
+	}
+
+	thumbnail = model.Thumbnail{
+		Size:        size,
+		Width:       scaled.Bounds().Dx(),
+		Height:      scaled.Bounds().Dy(),
+		ContentType: contentType,
+		Length:      int64(buf.Len()),
+		BlobKey:     fmt.Sprintf("%s-%d", sha256, size),
+	}
+
+	err = a.blobStore.Put(ctx, thumbnail.BlobKey, &buf, thumbnail.Length, contentType)
+	return thumbnail, err
+}
+
+// DownloadAttachment opens the content of an attachment for a member of the
+// chat room it was uploaded to. The caller closes content.
+func (a *AttachmentServiceImpl) DownloadAttachment(ctx context.Context, data dto.DownloadAttachmentRequest) (resp dto.AttachmentResponse, content io.ReadCloser, err error) {
+	attachmentID, err := primitive.ObjectIDFromHex(data.AttachmentID)
+	if err != nil {
+		err = errors.New(constant.ERROR_ATTACHMENT_NOT_EXIST)
+		log.Println(err)
+		return resp, nil, err
+	}
+
+	attachment, err := a.attachmentRepository.GetAttachment(ctx, attachmentID)
+	if err != nil {
+		log.Println(err)
+		return resp, nil, err
+	}
+
+	if attachment.AttachmentID == primitive.NilObjectID {
+		err = errors.New(constant.ERROR_ATTACHMENT_NOT_EXIST)
+		log.Println(err)
+		return resp, nil, err
+	}
+
+	err = checkRoomMember(ctx, a.chatRepository, attachment.ChatRoomID, data.UserID)
+	if err != nil {
+		log.Println(err)
+		return resp, nil, err
+	}
+
+	// files go with the messages carrying them, once those are deleted or
+	// expired they're gone for the room too. Uploads that weren't sent yet
+	// are only for their uploader.
+	visible, err := a.chatRepository.HasVisibleAttachment(ctx, attachment.AttachmentID.Hex(), data.UserID)
+	if err != nil {
+		log.Println(err)
+		return resp, nil, err
+	}
+
+	if !visible {
+		referenced := true
+		if attachment.UploaderID == data.UserID {
+			referenced, err = a.chatRepository.IsAttachmentReferenced(ctx, attachment.AttachmentID.Hex())
+			if err != nil {
+				log.Println(err)
+				return resp, nil, err
+			}
+		}
+
+		if referenced {
+			err = errors.New(constant.ERROR_ATTACHMENT_NOT_EXIST)
+			log.Println(err)
+			return resp, nil, err
+		}
+	}
+
+	resp = toAttachmentResponse(attachment)
+	key := attachment.SHA256
+
+	// resp describes the thumbnail when one is asked for
+	if data.ThumbnailSize > 0 {
+		thumbnail, ok := findThumbnail(attachment.Preview, data.ThumbnailSize)
+		if !ok {
+			err = errors.New(constant.ERROR_THUMBNAIL_NOT_EXIST)
+			log.Println(err)
+			return resp, nil, err
+		}
+		key = thumbnail.BlobKey
+		resp.ContentType = thumbnail.ContentType
+		resp.Size = thumbnail.Length
+	}
+
+	content, err = a.blobStore.Get(ctx, key)
+	if err != nil {
+		log.Println("Failed to open attachment: ", err)
+		return resp, nil, err
+	}
+
+	return resp, content, nil
+}
+
+// ReleaseAttachments deletes the attachments no message carries anymore, nor
+// any scheduled message, after the messages carrying them were deleted. Their
+// blobs and thumbnails go with the last attachment sharing their content.
+func (a *AttachmentServiceImpl) ReleaseAttachments(ctx context.Context, attachmentIDs []string) {
+	for _, id := range attachmentIDs {
+		if err := a.releaseAttachment(ctx, id); err != nil {
+			log.Printf("Failed to release attachment %s: %v", id, err)
+		}
+	}
+}
+
+func (a *AttachmentServiceImpl) releaseAttachment(ctx context.Context, id string) error {
+	referenced, err := a.chatRepository.IsAttachmentReferenced(ctx, id)
+	if err != nil || referenced {
+		return err
+	}
+
+	scheduled, err := a.scheduleRepository.IsAttachmentScheduled(ctx, id)
+	if err != nil || scheduled {
+		return err
+	}
+
+	attachmentID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return err
+	}
+
+	attachment, err := a.attachmentRepository.GetAttachment(ctx, attachmentID)
+	if err != nil || attachment.AttachmentID == primitive.NilObjectID {
+		return err
+	}
+
+	err = a.attachmentRepository.DeleteAttachment(ctx, attachmentID)
+	if err != nil {
+		return err
+	}
+
+	shared, err := a.attachmentRepository.CountAttachmentsWithContent(ctx, attachment.SHA256)
+	if err != nil || shared > 0 {
+		return err
+	}
+
+	locked, err := a.attachmentRepository.LockContentRelease(ctx, attachment.SHA256)
+	if err != nil || !locked {
+		// another release is deleting the same blobs
+		return err
+	}
+	defer func() {
+		if err := a.attachmentRepository.UnlockContentRelease(ctx, attachment.SHA256); err != nil {
+			log.Printf("Failed to unlock release of %s: %v", attachment.SHA256, err)
+		}
+	}()
+
+	// an upload of the same content may have saved its attachment since, it
+	// waits for the mark to go before storing the blob
+	shared, err = a.attachmentRepository.CountAttachmentsWithContent(ctx, attachment.SHA256)
+	if err != nil || shared > 0 {
+		return err
+	}
+
+	keys := []string{attachment.SHA256}
+	if attachment.Preview != nil {
+		for _, thumbnail := range attachment.Preview.Thumbnails {
+			keys = append(keys, thumbnail.BlobKey)
+		}
+	}
+
+	for _, key := range keys {
+		if err := a.blobStore.Delete(ctx, key); err != nil {
+			log.Printf("Failed to delete blob %s: %v", key, err)
+		}
+	}
+	return nil
+}
+
+func findThumbnail(preview *model.ImagePreview, size int) (model.Thumbnail, bool) {
+	if preview == nil {
+		return model.Thumbnail{}, false
+	}
+
+	for _, thumbnail := range preview.Thumbnails {
+		if thumbnail.Size == size {
+			return thumbnail, true
+		}
+	}
+	return model.Thumbnail{}, false
+}
+
+// checkRoomMember fails with ERROR_NOT_ROOM_MEMBER unless userID is one of the
+// users of the chat room roomID.
+func checkRoomMember(ctx context.Context, chatRepository repository.ChatRepository, roomID string, userID string) error {
+	chatRoomID, err := primitive.ObjectIDFromHex(roomID)
+	if err != nil {
+		return errors.New(constant.ERROR_NOT_ROOM_MEMBER)
+	}
+
+	chatRoom, err := chatRepository.GetChatRoomByID(ctx, chatRoomID)
+	if err != nil {
+		return err
+	}
+
+	if !contains(chatRoom.UserIDs, userID) {
+		return errors.New(constant.ERROR_NOT_ROOM_MEMBER)
+	}
+	return nil
+}
+
+// attachmentFileName keeps the base name of the file the client uploaded.
+func attachmentFileName(name string) string {
+	name = strings.TrimSpace(name[strings.LastIndexAny(name, `/\`)+1:])
+	if name == "" || name == "." || name == ".." {
+		return "attachment"
+	}
+	return name
+}
+
+func toAttachmentResponse(attachment model.Attachment) dto.AttachmentResponse {
+	return dto.AttachmentResponse{
+		AttachmentID: attachment.AttachmentID.Hex(),
+		ChatRoomID:   attachment.ChatRoomID,
+		UploaderID:   attachment.UploaderID,
+		FileName:     attachment.FileName,
+		ContentType:  attachment.ContentType,
+		Size:         attachment.Size,
+		SHA256:       attachment.SHA256,
+		CreatedAt:    attachment.CreatedAt,
+		Preview:      toImagePreview(attachment.Preview),
+	}
+}
+
+func toImagePreview(preview *model.ImagePreview) *dto.ImagePreview {
+	if preview == nil {
+		return nil
+	}
+
+	resp := &dto.ImagePreview{
+		Status:   preview.Status,
+		Width:    preview.Width,
+		Height:   preview.Height,
+		BlurHash: preview.BlurHash,
+	}
+	for _, thumbnail := range preview.Thumbnails {
+		resp.Thumbnails = append(resp.Thumbnails, dto.Thumbnail{
+			Size:        thumbnail.Size,
+			Width:       thumbnail.Width,
+			Height:      thumbnail.Height,
+			ContentType: thumbnail.ContentType,
+		})
+	}
+	return resp
+}
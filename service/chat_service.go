@@ -2,47 +2,352 @@ package service
 
 import (
 	"context"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go-chat/constant"
 	"go-chat/dto"
+	"go-chat/model"
+	"go-chat/pkg/util"
+	"go-chat/pkg/websocket"
 	"go-chat/repository"
 	"log"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
 
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
+const (
+	defaultMessageLimit = 20
+	maxMessageLimit     = 100
+
+	// mentionExcerptLength is how many characters of a message its mention
+	// notifications quote.
+	mentionExcerptLength = 100
+
+	maxMessageAttachments = 10
+
+	maxSearchQueryLength = 256
+	// searchSnippetLength is how many characters of a message a search
+	// result quotes around the words it matched.
+	searchSnippetLength = 160
+
+	minDisappearAfter = 5
+	maxDisappearAfter = 365 * 24 * 60 * 60
+	// expirySweepInterval is how often disappearing messages past their
+	// expiry are removed, readers leave them out in the meantime.
+	expirySweepInterval = 5 * time.Second
+	expirySweepBatch    = 100
+)
+
 type ChatService interface {
-	GetMessages(ctx context.Context, data dto.GetMessagesRequest) (resp []dto.GetMessagesResponse, err error)
+	GetMessages(ctx context.Context, data dto.GetMessagesRequest) (resp dto.GetMessagesPageResponse, err error)
+	GetThread(ctx context.Context, data dto.GetThreadRequest) (resp dto.GetThreadResponse, err error)
+	GetMentions(ctx context.Context, data dto.GetMentionsRequest) (resp []dto.GetMessagesResponse, err error)
+	SearchMessages(ctx context.Context, data dto.SearchMessagesRequest) (resp []dto.SearchMessagesResponse, err error)
 	GetorCreateChatRoom(ctx context.Context, userID1 string, userID2 string) (resp dto.GetorCreateChatRoomResponse, err error)
+	SendMessage(ctx context.Context, data dto.SendMessageRequest) (resp dto.SendMessageResponse, err error)
+	EditMessage(ctx context.Context, data dto.EditMessageRequest) (resp dto.EditMessageResponse, err error)
+	DeleteMessage(ctx context.Context, data dto.DeleteMessageRequest) (resp dto.DeleteMessageResponse, err error)
+	AddReaction(ctx context.Context, data dto.ReactionRequest) (resp dto.ReactionResponse, err error)
+	RemoveReaction(ctx context.Context, data dto.ReactionRequest) (resp dto.ReactionResponse, err error)
+	SetDisappearingTimer(ctx context.Context, data dto.SetDisappearingTimerRequest) (resp dto.DisappearingTimerResponse, err error)
+	MarkRead(ctx context.Context, data dto.MarkReadRequest) (resp dto.MarkReadResponse, err error)
+	Typing(ctx context.Context, data dto.TypingRequest) (err error)
+	RunExpirySweeper(ctx context.Context)
+}
+
+type ChatConfig struct {
+	// EditWindow is how long after sending a message its sender may still
+	// edit it, zero allows edits at any time.
+	EditWindow time.Duration
+	// DeleteWindow is how long after sending a message its sender may still
+	// delete it for everyone, zero allows it at any time.
+	DeleteWindow time.Duration
+	// HideWindow is how long after a message was sent its sender or receiver
+	// may still delete it for themselves, zero allows it at any time.
+	HideWindow time.Duration
+	// Moderators may delete any message, for themselves or for everyone, at
+	// any time.
+	Moderators []string
 }
 
 type ChatServiceImpl struct {
-	chatRepository repository.ChatRepository
+	chatRepository       repository.ChatRepository
+	attachmentRepository repository.AttachmentRepository
+	attachmentService    AttachmentService
+	notificationService  NotificationService
+	hub                  *websocket.Hub
+	config               ChatConfig
 }
 
-func NewChatService(chatRepository repository.ChatRepository) ChatService {
-	return &ChatServiceImpl{chatRepository: chatRepository}
+func NewChatService(chatRepository repository.ChatRepository, attachmentRepository repository.AttachmentRepository, attachmentService AttachmentService, notificationService NotificationService, hub *websocket.Hub, config ChatConfig) ChatService {
+	return &ChatServiceImpl{
+		chatRepository:       chatRepository,
+		attachmentRepository: attachmentRepository,
+		attachmentService:    attachmentService,
+		notificationService:  notificationService,
+		hub:                  hub,
+		config:               config,
+	}
 }
 
-func (c *ChatServiceImpl) GetMessages(ctx context.Context, data dto.GetMessagesRequest) (resp []dto.GetMessagesResponse, err error) {
-	messages, err := c.chatRepository.GetMessages(ctx, data.RoomID, data.Limit, data.Offset)
+// GetMessages returns a page of a room's history. Each page is fetched one
+// more message than asked for, which tells whether there are more past it.
+func (c *ChatServiceImpl) GetMessages(ctx context.Context, data dto.GetMessagesRequest) (resp dto.GetMessagesPageResponse, err error) {
+	seqRange := data.FromSeq != 0 || data.ToSeq != 0
+
+	anchors := 0
+	for _, anchor := range []string{data.Before, data.After, data.Around} {
+		if anchor != "" {
+			anchors++
+		}
+	}
+	if seqRange {
+		anchors++
+	}
+	if anchors > 1 {
+		err = errors.New(constant.ERROR_PAGE_ANCHORS)
+		log.Println(err)
+		return resp, err
+	}
+
+	limit := pageLimit(data.Limit)
+
+	switch {
+	case seqRange:
+		resp, err = c.getMessagesBySeq(ctx, data)
+	case data.Around != "":
+		resp, err = c.getMessagesAround(ctx, data, limit)
+	case data.After != "":
+		resp, err = c.getMessagesAfter(ctx, data, limit)
+	default:
+		resp, err = c.getMessagesBefore(ctx, data, limit)
+	}
 	if err != nil {
 		log.Println(err)
-		return []dto.GetMessagesResponse{}, err
+		return dto.GetMessagesPageResponse{}, err
 	}
 
-	for _, message := range messages {
-		resp = append(resp, dto.GetMessagesResponse{
-			MessageID:   message.MessageID.Hex(),
-			SenderID:    message.SenderID,
-			ReceiverID:  message.ReceiverID,
-			MessageText: message.MessageText,
-			Timestamp:   message.Timestamp,
-			ChatRoomID:  message.ChatRoomID,
-		})
+	// read after the page, so it covers every message in it
+	resp.LastSeq, err = c.chatRepository.GetLastSeq(ctx, data.RoomID)
+	if err != nil {
+		log.Println(err)
+		return dto.GetMessagesPageResponse{}, err
+	}
+	if seqRange {
+		resp.HasMoreAfter = data.ToSeq < resp.LastSeq
+	}
+
+	return resp, nil
+}
+
+func (c *ChatServiceImpl) getMessagesBefore(ctx context.Context, data dto.GetMessagesRequest, limit int64) (resp dto.GetMessagesPageResponse, err error) {
+	var cursor *model.MessageCursor
+	if data.Before != "" {
+		before, err := decodeMessageCursor(data.Before)
+		if err != nil {
+			return resp, err
+		}
+		cursor = &before
+	}
+
+	messages, err := c.chatRepository.GetMessagesBefore(ctx, data.RoomID, data.ViewerID, data.CollapseThreads, cursor, limit+1)
+	if err != nil {
+		return resp, err
+	}
+
+	hasMore := int64(len(messages)) > limit
+	if hasMore {
+		messages = messages[:limit]
+	}
+
+	resp = toMessagePage(messages, data.Before)
+	resp.HasMoreBefore = hasMore
+	// the cursor came from a newer page
+	resp.HasMoreAfter = cursor != nil
+
+	return resp, nil
+}
+
+func (c *ChatServiceImpl) getMessagesAfter(ctx context.Context, data dto.GetMessagesRequest, limit int64) (resp dto.GetMessagesPageResponse, err error) {
+	cursor, err := decodeMessageCursor(data.After)
+	if err != nil {
+		return resp, err
+	}
+
+	messages, err := c.chatRepository.GetMessagesAfter(ctx, data.RoomID, data.ViewerID, data.CollapseThreads, cursor, limit+1)
+	if err != nil {
+		return resp, err
+	}
+
+	hasMore := int64(len(messages)) > limit
+	if hasMore {
+		messages = messages[:limit]
+	}
+	reverseMessages(messages)
+
+	resp = toMessagePage(messages, data.After)
+	resp.HasMoreBefore = true
+	resp.HasMoreAfter = hasMore
+
+	return resp, nil
+}
+
+// getMessagesBySeq returns the messages numbered data.FromSeq to data.ToSeq,
+// which can't be more than a page. Its cursors are at the edges of the range
+// whichever of its messages the viewer sees.
+func (c *ChatServiceImpl) getMessagesBySeq(ctx context.Context, data dto.GetMessagesRequest) (resp dto.GetMessagesPageResponse, err error) {
+	if data.FromSeq < 1 || data.ToSeq < data.FromSeq || data.ToSeq-data.FromSeq >= maxMessageLimit {
+		return resp, errors.New(constant.ERROR_INVALID_SEQ_RANGE)
+	}
+
+	messages, err := c.chatRepository.GetMessagesBySeq(ctx, data.RoomID, data.ViewerID, data.CollapseThreads, data.FromSeq, data.ToSeq)
+	if err != nil {
+		return resp, err
+	}
+
+	resp = toMessagePage(messages, "")
+	resp.Before = encodeMessageCursor(model.MessageCursor{Seq: data.FromSeq})
+	resp.After = encodeMessageCursor(model.MessageCursor{Seq: data.ToSeq})
+	// there may be messages saved before numbering even ahead of seq 1,
+	// GetMessages sets HasMoreAfter from the room's last number
+	resp.HasMoreBefore = true
+
+	return resp, nil
+}
+
+// getMessagesAround returns the message data.Around with the messages sent
+// just before and after it, so clients can jump to it from a link, a search
+// result or a mention.
+func (c *ChatServiceImpl) getMessagesAround(ctx context.Context, data dto.GetMessagesRequest, limit int64) (resp dto.GetMessagesPageResponse, err error) {
+	target, err := c.getMessage(ctx, data.Around)
+	if err != nil {
+		return resp, err
+	}
+
+	if target.ChatRoomID != data.RoomID {
+		return resp, errors.New(constant.ERROR_MESSAGE_NOT_EXIST)
+	}
+
+	cursor := toMessageCursor(target)
+	olderLimit := (limit - 1) / 2
+	newerLimit := limit - 1 - olderLimit
+
+	older, err := c.chatRepository.GetMessagesBefore(ctx, data.RoomID, data.ViewerID, data.CollapseThreads, &cursor, olderLimit+1)
+	if err != nil {
+		return resp, err
+	}
+
+	newer, err := c.chatRepository.GetMessagesAfter(ctx, data.RoomID, data.ViewerID, data.CollapseThreads, cursor, newerLimit+1)
+	if err != nil {
+		return resp, err
+	}
+
+	hasMoreBefore := int64(len(older)) > olderLimit
+	if hasMoreBefore {
+		older = older[:olderLimit]
 	}
+	hasMoreAfter := int64(len(newer)) > newerLimit
+	if hasMoreAfter {
+		newer = newer[:newerLimit]
+	}
+	reverseMessages(newer)
+
+	// the page stays centered on a message the viewer can't see, without
+	// showing it
+	messages := newer
+	if !contains(target.DeletedFor, data.ViewerID) && !(data.CollapseThreads && target.ThreadRootID != "") {
+		messages = append(messages, target)
+	}
+	messages = append(messages, older...)
+
+	resp = toMessagePage(messages, encodeMessageCursor(cursor))
+	resp.HasMoreBefore = hasMoreBefore
+	resp.HasMoreAfter = hasMoreAfter
 
 	return resp, nil
 }
 
+// toMessagePage builds a page out of messages, newest first. An empty page
+// keeps the cursor it was fetched from both ways.
+func toMessagePage(messages []model.Message, cursor string) dto.GetMessagesPageResponse {
+	resp := dto.GetMessagesPageResponse{
+		Messages: []dto.GetMessagesResponse{},
+		Before:   cursor,
+		After:    cursor,
+	}
+
+	for _, message := range messages {
+		resp.Messages = append(resp.Messages, toMessageResponse(message))
+	}
+
+	if len(messages) > 0 {
+		newest, oldest := messages[0], messages[len(messages)-1]
+		resp.Before = encodeMessageCursor(toMessageCursor(oldest))
+		resp.After = encodeMessageCursor(toMessageCursor(newest))
+	}
+
+	return resp
+}
+
+func reverseMessages(messages []model.Message) {
+	for i, j := 0, len(messages)-1; i < j; i, j = i+1, j-1 {
+		messages[i], messages[j] = messages[j], messages[i]
+	}
+}
+
+func toMessageCursor(message model.Message) model.MessageCursor {
+	return model.MessageCursor{Seq: message.Seq, Timestamp: message.Timestamp, MessageID: message.MessageID}
+}
+
+// encodeMessageCursor makes an opaque cursor out of a position: the sequence
+// number, or for messages without one the timestamp in milliseconds, as
+// MongoDB stores it, followed by the message ID.
+func encodeMessageCursor(cursor model.MessageCursor) string {
+	buf := make([]byte, 8, 8+len(cursor.MessageID))
+	if cursor.Seq > 0 {
+		binary.BigEndian.PutUint64(buf, uint64(cursor.Seq))
+		return base64.RawURLEncoding.EncodeToString(buf)
+	}
+
+	binary.BigEndian.PutUint64(buf, uint64(cursor.Timestamp.UnixMilli()))
+	buf = append(buf, cursor.MessageID[:]...)
+	return base64.RawURLEncoding.EncodeToString(buf)
+}
+
+func decodeMessageCursor(cursor string) (model.MessageCursor, error) {
+	buf, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return model.MessageCursor{}, errors.New(constant.ERROR_INVALID_CURSOR)
+	}
+
+	switch len(buf) {
+	case 8:
+		seq := int64(binary.BigEndian.Uint64(buf))
+		if seq < 1 {
+			return model.MessageCursor{}, errors.New(constant.ERROR_INVALID_CURSOR)
+		}
+		return model.MessageCursor{Seq: seq}, nil
+	case 8 + len(primitive.ObjectID{}):
+		var messageID primitive.ObjectID
+		copy(messageID[:], buf[8:])
+
+		return model.MessageCursor{
+			Timestamp: time.UnixMilli(int64(binary.BigEndian.Uint64(buf))),
+			MessageID: messageID,
+		}, nil
+	default:
+		return model.MessageCursor{}, errors.New(constant.ERROR_INVALID_CURSOR)
+	}
+}
+
 func (c *ChatServiceImpl) GetorCreateChatRoom(ctx context.Context, userID1 string, userID2 string) (resp dto.GetorCreateChatRoomResponse, err error) {
 	getResp, err := c.chatRepository.GetChatRoom(ctx, userID1, userID2)
 	if err != nil {
@@ -63,10 +368,1205 @@ func (c *ChatServiceImpl) GetorCreateChatRoom(ctx context.Context, userID1 strin
 		}
 	} else {
 		resp = dto.GetorCreateChatRoomResponse{
-			ChatRoomID: getResp.ChatRoomID.Hex(),
-			UserIDs:    getResp.UserIDs,
+			ChatRoomID:     getResp.ChatRoomID.Hex(),
+			UserIDs:        getResp.UserIDs,
+			DisappearAfter: getResp.DisappearAfter,
+			DisappearFrom:  getResp.DisappearFrom,
 		}
 	}
 
 	return
 }
+
+func (c *ChatServiceImpl) SendMessage(ctx context.Context, data dto.SendMessageRequest) (resp dto.SendMessageResponse, err error) {
+	message := model.Message{
+		MessageID:   primitive.NewObjectID(),
+		SenderID:    data.SenderID,
+		ReceiverID:  data.ReceiverID,
+		MessageText: data.MessageText,
+		Timestamp:   time.Now(),
+		ChatRoomID:  data.RoomID,
+	}
+	if data.MessageID != "" {
+		message.MessageID, err = primitive.ObjectIDFromHex(data.MessageID)
+		if err != nil {
+			log.Println(err)
+			return resp, err
+		}
+	}
+
+	// rooms are looked up by ID only for their timer, messages to any room
+	// are stored as before
+	if roomID, err := primitive.ObjectIDFromHex(data.RoomID); err == nil {
+		chatRoom, err := c.chatRepository.GetChatRoomByID(ctx, roomID)
+		if err != nil {
+			log.Println(err)
+			return resp, err
+		}
+		applyDisappearingTimer(&message, chatRoom)
+	}
+
+	// the first reply to a message makes it the root of a thread, its sender
+	// follows the thread from then on
+	var followers []string
+	if data.ReplyToMessageID != "" {
+		parent, err := c.getMessage(ctx, data.ReplyToMessageID)
+		if err != nil {
+			log.Println(err)
+			return resp, err
+		}
+
+		if parent.ChatRoomID != data.RoomID {
+			err = errors.New(constant.ERROR_REPLY_OTHER_ROOM)
+			log.Println(err)
+			return resp, err
+		}
+
+		if !parent.DeletedAt.IsZero() {
+			err = errors.New(constant.ERROR_MESSAGE_DELETED)
+			log.Println(err)
+			return resp, err
+		}
+
+		message.ReplyToMessageID = parent.MessageID.Hex()
+		message.ThreadRootID = parent.ThreadRootID
+		if message.ThreadRootID == "" {
+			message.ThreadRootID = parent.MessageID.Hex()
+			followers = append(followers, parent.SenderID)
+		}
+		followers = append(followers, message.SenderID)
+	}
+
+	message.Mentions, err = c.resolveMentions(ctx, message.ChatRoomID, message.SenderID, message.MessageText)
+	if err != nil {
+		log.Println(err)
+		return resp, err
+	}
+
+	message.Attachments, err = c.resolveAttachments(ctx, message.ChatRoomID, message.SenderID, data.AttachmentIDs)
+	if err != nil {
+		log.Println(err)
+		return resp, err
+	}
+
+	message.Seq, err = c.chatRepository.SaveMessage(ctx, message)
+	if err != nil {
+		log.Println(err)
+		return resp, err
+	}
+
+	resp = toSendMessageResponse(message)
+
+	err = c.publishMessage(ctx, resp)
+	if err != nil {
+		log.Println("Failed to publish message: ", err)
+		return resp, err
+	}
+
+	if message.ThreadRootID != "" {
+		c.updateThread(ctx, message, followers)
+	}
+
+	c.notifyMentions(ctx, message, message.Mentions)
+
+	return resp, nil
+}
+
+func toSendMessageResponse(message model.Message) dto.SendMessageResponse {
+	resp := dto.SendMessageResponse{
+		MessageID:        message.MessageID.Hex(),
+		SenderID:         message.SenderID,
+		ReceiverID:       message.ReceiverID,
+		MessageText:      message.MessageText,
+		Timestamp:        message.Timestamp,
+		ChatRoomID:       message.ChatRoomID,
+		Seq:              message.Seq,
+		ReplyToMessageID: message.ReplyToMessageID,
+		ThreadRootID:     message.ThreadRootID,
+		Mentions:         message.Mentions,
+		Attachments:      toAttachments(message.Attachments),
+		ExpireAfterRead:  message.ExpireAfterRead,
+		Notice:           toRoomNotice(message.Notice),
+	}
+	if !message.ExpiresAt.IsZero() {
+		expiresAt := message.ExpiresAt
+		resp.ExpiresAt = &expiresAt
+	}
+	return resp
+}
+
+// publishMessage delivers a new message to every subscriber of its room.
+func (c *ChatServiceImpl) publishMessage(ctx context.Context, resp dto.SendMessageResponse) error {
+	// same action name websocket clients use, so every subscriber handles it alike
+	event := map[string]interface{}{
+		"action":       "send_message",
+		"_id":          resp.MessageID,
+		"sender_id":    resp.SenderID,
+		"receiver_id":  resp.ReceiverID,
+		"message_text": resp.MessageText,
+		"timestamp":    resp.Timestamp,
+		"chat_room_id": resp.ChatRoomID,
+		"seq":          resp.Seq,
+	}
+	if resp.ThreadRootID != "" {
+		event["reply_to_message_id"] = resp.ReplyToMessageID
+		event["thread_root_id"] = resp.ThreadRootID
+	}
+	if len(resp.Mentions) > 0 {
+		event["mentions"] = resp.Mentions
+	}
+	if len(resp.Attachments) > 0 {
+		event["attachments"] = resp.Attachments
+	}
+	if resp.ExpiresAt != nil {
+		event["expires_at"] = resp.ExpiresAt
+	}
+	if resp.ExpireAfterRead > 0 {
+		event["expire_after_read"] = resp.ExpireAfterRead
+	}
+	if resp.Notice != nil {
+		event["notice"] = resp.Notice
+	}
+
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	return c.hub.Publish(ctx, websocket.RoomEvent{RoomID: resp.ChatRoomID, Data: payload})
+}
+
+// updateThread counts reply in its thread and tells the thread's followers,
+// on whichever connection and room they are in.
+func (c *ChatServiceImpl) updateThread(ctx context.Context, reply model.Message, followers []string) {
+	rootID, err := primitive.ObjectIDFromHex(reply.ThreadRootID)
+	if err != nil {
+		log.Println(err)
+		return
+	}
+
+	root, err := c.chatRepository.UpdateThread(ctx, rootID, reply.Timestamp, followers)
+	if err != nil {
+		log.Println("Failed to update thread: ", err)
+		return
+	}
+
+	c.publishThreadUpdate(ctx, root, map[string]interface{}{
+		"last_reply_id": reply.MessageID.Hex(),
+	})
+}
+
+// removeFromThread uncounts reply, deleted for everyone or expired, from its
+// thread and tells the thread's followers.
+func (c *ChatServiceImpl) removeFromThread(ctx context.Context, reply model.Message) {
+	if reply.ThreadRootID == "" {
+		return
+	}
+
+	rootID, err := primitive.ObjectIDFromHex(reply.ThreadRootID)
+	if err != nil {
+		log.Println(err)
+		return
+	}
+
+	root, err := c.chatRepository.RemoveThreadReply(ctx, rootID, reply)
+	if err != nil {
+		log.Println("Failed to update thread: ", err)
+		return
+	}
+
+	if root.MessageID == primitive.NilObjectID {
+		return
+	}
+
+	c.publishThreadUpdate(ctx, root, map[string]interface{}{
+		"removed_reply_id": reply.MessageID.Hex(),
+	})
+}
+
+// publishThreadUpdate sends the thread's new counts, along with the fields of
+// event, to the thread's followers.
+func (c *ChatServiceImpl) publishThreadUpdate(ctx context.Context, root model.Message, event map[string]interface{}) {
+	event["action"] = "thread_updated"
+	event["thread_root_id"] = root.MessageID.Hex()
+	event["chat_room_id"] = root.ChatRoomID
+	event["reply_count"] = root.ReplyCount
+	event["last_reply_at"] = nil
+	if !root.LastReplyAt.IsZero() {
+		event["last_reply_at"] = root.LastReplyAt
+	}
+
+	payload, err := json.Marshal(event)
+	if err != nil {
+		log.Println(err)
+		return
+	}
+
+	for _, follower := range root.ThreadFollowers {
+		err = c.hub.PublishToUser(ctx, follower, payload)
+		if err != nil {
+			log.Println("Failed to publish thread update: ", err)
+		}
+	}
+}
+
+func (c *ChatServiceImpl) GetThread(ctx context.Context, data dto.GetThreadRequest) (resp dto.GetThreadResponse, err error) {
+	root, err := c.getMessage(ctx, data.MessageID)
+	if err != nil {
+		log.Println(err)
+		return resp, err
+	}
+
+	// any message of the thread leads to its root
+	if root.ThreadRootID != "" {
+		root, err = c.getMessage(ctx, root.ThreadRootID)
+		if err != nil {
+			log.Println(err)
+			return resp, err
+		}
+	}
+
+	limit := pageLimit(data.Limit)
+
+	replies, err := c.chatRepository.GetThreadReplies(ctx, root.MessageID.Hex(), data.ViewerID, limit+1, data.Offset)
+	if err != nil {
+		log.Println(err)
+		return resp, err
+	}
+
+	resp.HasMore = int64(len(replies)) > limit
+	if resp.HasMore {
+		replies = replies[:limit]
+	}
+
+	resp.Root = toMessageResponse(root)
+	resp.Replies = []dto.GetMessagesResponse{}
+	for _, reply := range replies {
+		resp.Replies = append(resp.Replies, toMessageResponse(reply))
+	}
+
+	return resp, nil
+}
+
+func (c *ChatServiceImpl) getMessage(ctx context.Context, id string) (message model.Message, err error) {
+	messageID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return message, errors.New(constant.ERROR_MESSAGE_NOT_EXIST)
+	}
+
+	message, err = c.chatRepository.GetMessage(ctx, messageID)
+	if err != nil {
+		return message, err
+	}
+
+	if message.MessageID == primitive.NilObjectID {
+		return message, errors.New(constant.ERROR_MESSAGE_NOT_EXIST)
+	}
+
+	return message, nil
+}
+
+func (c *ChatServiceImpl) EditMessage(ctx context.Context, data dto.EditMessageRequest) (resp dto.EditMessageResponse, err error) {
+	message, err := c.getMessage(ctx, data.MessageID)
+	if err != nil {
+		log.Println(err)
+		return resp, err
+	}
+
+	if !message.DeletedAt.IsZero() {
+		err = errors.New(constant.ERROR_MESSAGE_DELETED)
+		log.Println(err)
+		return resp, err
+	}
+
+	if message.Notice != nil {
+		err = errors.New(constant.ERROR_ROOM_NOTICE)
+		log.Println(err)
+		return resp, err
+	}
+
+	if message.SenderID != data.SenderID {
+		err = errors.New(constant.ERROR_NOT_MESSAGE_SENDER)
+		log.Println(err)
+		return resp, err
+	}
+
+	editedAt := time.Now()
+	if c.config.EditWindow > 0 && editedAt.Sub(message.Timestamp) > c.config.EditWindow {
+		err = errors.New(constant.ERROR_EDIT_WINDOW_EXPIRED)
+		log.Println(err)
+		return resp, err
+	}
+
+	mentions, err := c.resolveMentions(ctx, message.ChatRoomID, message.SenderID, data.MessageText)
+	if err != nil {
+		log.Println(err)
+		return resp, err
+	}
+
+	edited, err := c.chatRepository.EditMessage(ctx, message, data.MessageText, mentions, editedAt)
+	if err != nil {
+		log.Println(err)
+		return resp, err
+	}
+
+	if !edited {
+		err = errors.New(constant.ERROR_MESSAGE_CONFLICT)
+		log.Println(err)
+		return resp, err
+	}
+
+	resp = dto.EditMessageResponse{
+		MessageID:   message.MessageID.Hex(),
+		SenderID:    message.SenderID,
+		MessageText: data.MessageText,
+		ChatRoomID:  message.ChatRoomID,
+		Mentions:    mentions,
+		EditedAt:    editedAt,
+	}
+
+	payload, err := json.Marshal(map[string]interface{}{
+		"action":       "message_edited",
+		"_id":          resp.MessageID,
+		"sender_id":    resp.SenderID,
+		"message_text": resp.MessageText,
+		"chat_room_id": resp.ChatRoomID,
+		"mentions":     resp.Mentions,
+		"edited_at":    resp.EditedAt,
+	})
+	if err != nil {
+		log.Println(err)
+		return resp, err
+	}
+
+	// a later edit of the same message supersedes this one in coalescing queues
+	err = c.hub.Publish(ctx, websocket.RoomEvent{
+		RoomID: resp.ChatRoomID,
+		Key:    "message_edited:" + resp.MessageID,
+		Data:   payload,
+	})
+	if err != nil {
+		log.Println("Failed to publish message edit: ", err)
+	}
+
+	// only users the edit mentions for the first time are notified
+	var added []string
+	for _, userID := range mentions {
+		if !contains(message.Mentions, userID) {
+			added = append(added, userID)
+		}
+	}
+	message.MessageText = data.MessageText
+	c.notifyMentions(ctx, message, added)
+
+	return resp, nil
+}
+
+func (c *ChatServiceImpl) DeleteMessage(ctx context.Context, data dto.DeleteMessageRequest) (resp dto.DeleteMessageResponse, err error) {
+	message, err := c.getMessage(ctx, data.MessageID)
+	if err != nil {
+		log.Println(err)
+		return resp, err
+	}
+
+	if message.Notice != nil {
+		err = errors.New(constant.ERROR_ROOM_NOTICE)
+		log.Println(err)
+		return resp, err
+	}
+
+	moderator := c.isModerator(data.UserID)
+	deletedAt := time.Now()
+	age := deletedAt.Sub(message.Timestamp)
+
+	resp = dto.DeleteMessageResponse{
+		MessageID:  message.MessageID.Hex(),
+		ChatRoomID: message.ChatRoomID,
+		Scope:      data.Scope,
+		DeletedAt:  deletedAt,
+	}
+
+	payload, err := json.Marshal(map[string]interface{}{
+		"action":       "message_deleted",
+		"_id":          resp.MessageID,
+		"chat_room_id": resp.ChatRoomID,
+		"scope":        resp.Scope,
+		"deleted_by":   data.UserID,
+		"deleted_at":   resp.DeletedAt,
+	})
+	if err != nil {
+		log.Println(err)
+		return resp, err
+	}
+
+	switch data.Scope {
+	case constant.DELETE_FOR_ME:
+		if !moderator && data.UserID != message.SenderID && data.UserID != message.ReceiverID {
+			err = errors.New(constant.ERROR_NOT_IN_CHAT)
+			log.Println(err)
+			return resp, err
+		}
+
+		if !moderator && c.config.HideWindow > 0 && age > c.config.HideWindow {
+			err = errors.New(constant.ERROR_DELETE_WINDOW_EXPIRED)
+			log.Println(err)
+			return resp, err
+		}
+
+		err = c.chatRepository.HideMessage(ctx, message.MessageID, data.UserID)
+		if err != nil {
+			log.Println(err)
+			return resp, err
+		}
+
+		// only the user's own connections drop the message from their view
+		err = c.hub.PublishToUser(ctx, data.UserID, payload)
+	case constant.DELETE_FOR_EVERYONE:
+		if !message.DeletedAt.IsZero() {
+			err = errors.New(constant.ERROR_MESSAGE_DELETED)
+			log.Println(err)
+			return resp, err
+		}
+
+		if !moderator && data.UserID != message.SenderID {
+			err = errors.New(constant.ERROR_NOT_MESSAGE_SENDER)
+			log.Println(err)
+			return resp, err
+		}
+
+		if !moderator && c.config.DeleteWindow > 0 && age > c.config.DeleteWindow {
+			err = errors.New(constant.ERROR_DELETE_WINDOW_EXPIRED)
+			log.Println(err)
+			return resp, err
+		}
+
+		var deleted bool
+		deleted, err = c.chatRepository.DeleteMessage(ctx, message.MessageID, data.UserID, deletedAt)
+		if err != nil {
+			log.Println(err)
+			return resp, err
+		}
+
+		if !deleted {
+			err = errors.New(constant.ERROR_MESSAGE_DELETED)
+			log.Println(err)
+			return resp, err
+		}
+
+		c.attachmentService.ReleaseAttachments(ctx, attachmentIDs(message.Attachments))
+		c.removeFromThread(ctx, message)
+
+		err = c.hub.Publish(ctx, websocket.RoomEvent{RoomID: resp.ChatRoomID, Data: payload})
+	default:
+		err = errors.New(constant.ERROR_INVALID_DELETE_SCOPE)
+		log.Println(err)
+		return resp, err
+	}
+
+	if err != nil {
+		log.Println("Failed to publish message deletion: ", err)
+	}
+
+	return resp, nil
+}
+
+func (c *ChatServiceImpl) AddReaction(ctx context.Context, data dto.ReactionRequest) (resp dto.ReactionResponse, err error) {
+	return c.react(ctx, data, true)
+}
+
+func (c *ChatServiceImpl) RemoveReaction(ctx context.Context, data dto.ReactionRequest) (resp dto.ReactionResponse, err error) {
+	return c.react(ctx, data, false)
+}
+
+// react adds or removes a reaction and broadcasts the new count for its emoji
+// when it changed anything.
+func (c *ChatServiceImpl) react(ctx context.Context, data dto.ReactionRequest, add bool) (resp dto.ReactionResponse, err error) {
+	if !validEmoji(data.Emoji) {
+		err = errors.New(constant.ERROR_INVALID_EMOJI)
+		log.Println(err)
+		return resp, err
+	}
+
+	message, err := c.getMessage(ctx, data.MessageID)
+	if err != nil {
+		log.Println(err)
+		return resp, err
+	}
+
+	if !message.DeletedAt.IsZero() {
+		err = errors.New(constant.ERROR_MESSAGE_DELETED)
+		log.Println(err)
+		return resp, err
+	}
+
+	if message.Notice != nil {
+		err = errors.New(constant.ERROR_ROOM_NOTICE)
+		log.Println(err)
+		return resp, err
+	}
+
+	if data.UserID != message.SenderID && data.UserID != message.ReceiverID {
+		err = errors.New(constant.ERROR_NOT_IN_CHAT)
+		log.Println(err)
+		return resp, err
+	}
+
+	var (
+		action  string
+		updated model.Message
+		changed bool
+	)
+	if add {
+		action = "reaction_added"
+		updated, changed, err = c.chatRepository.AddReaction(ctx, message.MessageID, data.Emoji, data.UserID)
+	} else {
+		action = "reaction_removed"
+		updated, changed, err = c.chatRepository.RemoveReaction(ctx, message.MessageID, data.Emoji, data.UserID)
+	}
+	if err != nil {
+		log.Println(err)
+		return resp, err
+	}
+
+	if changed {
+		message = updated
+	}
+
+	userIDs := message.Reactions[data.Emoji]
+	if userIDs == nil {
+		userIDs = []string{}
+	}
+
+	resp = dto.ReactionResponse{
+		MessageID:  message.MessageID.Hex(),
+		ChatRoomID: message.ChatRoomID,
+		Reaction: dto.Reaction{
+			Emoji:   data.Emoji,
+			Count:   len(userIDs),
+			UserIDs: userIDs,
+		},
+	}
+
+	if !changed {
+		return resp, nil
+	}
+
+	payload, err := json.Marshal(map[string]interface{}{
+		"action":       action,
+		"_id":          resp.MessageID,
+		"chat_room_id": resp.ChatRoomID,
+		"emoji":        resp.Emoji,
+		"user_id":      data.UserID,
+		"count":        resp.Count,
+	})
+	if err != nil {
+		log.Println(err)
+		return resp, err
+	}
+
+	err = c.hub.Publish(ctx, websocket.RoomEvent{RoomID: resp.ChatRoomID, Data: payload})
+	if err != nil {
+		log.Println("Failed to publish reaction: ", err)
+	}
+
+	return resp, nil
+}
+
+// SetDisappearingTimer changes how long the room's new messages last, those
+// already sent keep theirs. The change is announced with a notice posted in
+// the room by the member who made it.
+func (c *ChatServiceImpl) SetDisappearingTimer(ctx context.Context, data dto.SetDisappearingTimerRequest) (resp dto.DisappearingTimerResponse, err error) {
+	if data.DisappearAfter == 0 {
+		data.DisappearFrom = ""
+	} else if data.DisappearFrom == "" {
+		data.DisappearFrom = constant.DISAPPEAR_FROM_SENT
+	}
+
+	validFrom := data.DisappearFrom == constant.DISAPPEAR_FROM_SENT || data.DisappearFrom == constant.DISAPPEAR_FROM_READ
+	if data.DisappearAfter != 0 && (data.DisappearAfter < minDisappearAfter || data.DisappearAfter > maxDisappearAfter || !validFrom) {
+		err = errors.New(constant.ERROR_INVALID_TIMER)
+		log.Println(err)
+		return resp, err
+	}
+
+	roomID, err := primitive.ObjectIDFromHex(data.RoomID)
+	if err != nil {
+		err = errors.New(constant.ERROR_NOT_ROOM_MEMBER)
+		log.Println(err)
+		return resp, err
+	}
+
+	chatRoom, err := c.chatRepository.GetChatRoomByID(ctx, roomID)
+	if err != nil {
+		log.Println(err)
+		return resp, err
+	}
+
+	if !contains(chatRoom.UserIDs, data.UserID) {
+		err = errors.New(constant.ERROR_NOT_ROOM_MEMBER)
+		log.Println(err)
+		return resp, err
+	}
+
+	resp = dto.DisappearingTimerResponse{
+		ChatRoomID:     data.RoomID,
+		DisappearAfter: data.DisappearAfter,
+		DisappearFrom:  data.DisappearFrom,
+	}
+
+	if chatRoom.DisappearAfter == data.DisappearAfter && chatRoom.DisappearFrom == data.DisappearFrom {
+		return resp, nil
+	}
+
+	err = c.chatRepository.SetDisappearingTimer(ctx, roomID, data.DisappearAfter, data.DisappearFrom)
+	if err != nil {
+		log.Println(err)
+		return resp, err
+	}
+
+	// notices themselves don't disappear, so the room keeps track of its
+	// timer changes
+	notice := model.Message{
+		MessageID:   primitive.NewObjectID(),
+		SenderID:    data.UserID,
+		MessageText: describeDisappearingTimer(data.DisappearAfter, data.DisappearFrom),
+		Timestamp:   time.Now(),
+		ChatRoomID:  data.RoomID,
+		Notice: &model.RoomNotice{
+			Type:           constant.NOTICE_DISAPPEARING_TIMER,
+			DisappearAfter: data.DisappearAfter,
+			DisappearFrom:  data.DisappearFrom,
+		},
+	}
+	for _, userID := range chatRoom.UserIDs {
+		if userID != data.UserID {
+			notice.ReceiverID = userID
+			break
+		}
+	}
+
+	notice.Seq, err = c.chatRepository.SaveMessage(ctx, notice)
+	if err != nil {
+		log.Println(err)
+		return resp, err
+	}
+
+	noticeResp := toSendMessageResponse(notice)
+	resp.Notice = &noticeResp
+
+	err = c.publishMessage(ctx, noticeResp)
+	if err != nil {
+		log.Println("Failed to publish timer notice: ", err)
+	}
+
+	return resp, nil
+}
+
+// applyDisappearingTimer sets the expiry of a new message of chatRoom, or
+// for timers starting once read, how long it lasts from then.
+func applyDisappearingTimer(message *model.Message, chatRoom model.ChatRoom) {
+	if chatRoom.DisappearAfter <= 0 {
+		return
+	}
+
+	if chatRoom.DisappearFrom == constant.DISAPPEAR_FROM_READ {
+		message.ExpireAfterRead = chatRoom.DisappearAfter
+		return
+	}
+	message.ExpiresAt = message.Timestamp.Add(time.Duration(chatRoom.DisappearAfter) * time.Second)
+}
+
+// describeDisappearingTimer is the text of a timer notice, clients put the
+// name of its sender before it.
+func describeDisappearingTimer(disappearAfter int64, disappearFrom string) string {
+	if disappearAfter == 0 {
+		return "turned off disappearing messages"
+	}
+
+	units := []struct {
+		seconds int64
+		name    string
+	}{
+		{24 * 60 * 60, "day"},
+		{60 * 60, "hour"},
+		{60, "minute"},
+		{1, "second"},
+	}
+
+	var after string
+	for _, unit := range units {
+		if disappearAfter%unit.seconds == 0 {
+			after = fmt.Sprintf("%d %s", disappearAfter/unit.seconds, unit.name)
+			if disappearAfter != unit.seconds {
+				after += "s"
+			}
+			break
+		}
+	}
+
+	return fmt.Sprintf("set messages to disappear %s after they're %s", after, disappearFrom)
+}
+
+// MarkRead records that data.UserID read the room up to data.Seq, which
+// starts the timers of the messages disappearing once read, and tells the
+// room so clients can show it.
+func (c *ChatServiceImpl) MarkRead(ctx context.Context, data dto.MarkReadRequest) (resp dto.MarkReadResponse, err error) {
+	if data.Seq < 1 {
+		err = errors.New(constant.ERROR_INVALID_SEQ_RANGE)
+		log.Println(err)
+		return resp, err
+	}
+
+	err = checkRoomMember(ctx, c.chatRepository, data.RoomID, data.UserID)
+	if err != nil {
+		log.Println(err)
+		return resp, err
+	}
+
+	readAt := time.Now()
+
+	err = c.chatRepository.StartReadTimers(ctx, data.RoomID, data.UserID, data.Seq, readAt)
+	if err != nil {
+		log.Println(err)
+		return resp, err
+	}
+
+	resp = dto.MarkReadResponse{
+		ChatRoomID: data.RoomID,
+		UserID:     data.UserID,
+		Seq:        data.Seq,
+		ReadAt:     readAt,
+	}
+
+	payload, err := json.Marshal(map[string]interface{}{
+		"action":       "messages_read",
+		"chat_room_id": resp.ChatRoomID,
+		"user_id":      resp.UserID,
+		"seq":          resp.Seq,
+		"read_at":      resp.ReadAt,
+	})
+	if err != nil {
+		log.Println(err)
+		return resp, err
+	}
+
+	// a newer read of the same user supersedes this one
+	err = c.hub.Publish(ctx, websocket.RoomEvent{
+		RoomID: resp.ChatRoomID,
+		Key:    "messages_read:" + resp.UserID,
+		Data:   payload,
+	})
+	if err != nil {
+		log.Println("Failed to publish read: ", err)
+	}
+
+	return resp, nil
+}
+
+// Typing tells the room data.UserID is typing. Clients show it for a few
+// seconds, so a newer event of the same user supersedes older ones.
+func (c *ChatServiceImpl) Typing(ctx context.Context, data dto.TypingRequest) (err error) {
+	err = checkRoomMember(ctx, c.chatRepository, data.RoomID, data.UserID)
+	if err != nil {
+		log.Println(err)
+		return err
+	}
+
+	payload, err := json.Marshal(map[string]interface{}{
+		"action":       "typing",
+		"chat_room_id": data.RoomID,
+		"user_id":      data.UserID,
+	})
+	if err != nil {
+		log.Println(err)
+		return err
+	}
+
+	err = c.hub.Publish(ctx, websocket.RoomEvent{
+		RoomID: data.RoomID,
+		Key:    "typing:" + data.UserID,
+		Data:   payload,
+	})
+	if err != nil {
+		log.Println("Failed to publish typing: ", err)
+		return err
+	}
+
+	return nil
+}
+
+// RunExpirySweeper removes disappearing messages once they expire, along with
+// the attachments no other message carries, until ctx is done, and tells
+// their rooms. Every instance of the server runs it, the one that deletes a
+// message sends its event.
+func (c *ChatServiceImpl) RunExpirySweeper(ctx context.Context) {
+	ticker := time.NewTicker(expirySweepInterval)
+	defer ticker.Stop()
+
+	for {
+		c.sweepExpiredMessages(ctx)
+
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (c *ChatServiceImpl) sweepExpiredMessages(ctx context.Context) {
+	for ctx.Err() == nil {
+		now := time.Now()
+
+		messages, err := c.chatRepository.GetExpiredMessages(ctx, now, expirySweepBatch)
+		if err != nil {
+			log.Println(err)
+			return
+		}
+
+		for _, message := range messages {
+			deleted, err := c.chatRepository.DeleteExpiredMessage(ctx, message.MessageID, now)
+			if err != nil {
+				log.Println("Failed to remove expired message: ", err)
+				return
+			}
+
+			if deleted {
+				// tombstones were already uncounted when deleted
+				if message.DeletedAt.IsZero() {
+					c.removeFromThread(ctx, message)
+				}
+				c.attachmentService.ReleaseAttachments(ctx, attachmentIDs(message.Attachments))
+				c.publishExpired(ctx, message)
+			}
+		}
+
+		if len(messages) < expirySweepBatch {
+			return
+		}
+	}
+}
+
+func (c *ChatServiceImpl) publishExpired(ctx context.Context, message model.Message) {
+	payload, err := json.Marshal(map[string]interface{}{
+		"action":       "message_expired",
+		"_id":          message.MessageID.Hex(),
+		"chat_room_id": message.ChatRoomID,
+		"seq":          message.Seq,
+	})
+	if err != nil {
+		log.Println(err)
+		return
+	}
+
+	err = c.hub.Publish(ctx, websocket.RoomEvent{RoomID: message.ChatRoomID, Data: payload})
+	if err != nil {
+		log.Println("Failed to publish expired message: ", err)
+	}
+}
+
+func (c *ChatServiceImpl) GetMentions(ctx context.Context, data dto.GetMentionsRequest) (resp []dto.GetMessagesResponse, err error) {
+	messages, err := c.chatRepository.GetMentions(ctx, data.UserID, pageLimit(data.Limit), data.Offset)
+	if err != nil {
+		log.Println(err)
+		return []dto.GetMessagesResponse{}, err
+	}
+
+	resp = []dto.GetMessagesResponse{}
+	for _, message := range messages {
+		resp = append(resp, toMessageResponse(message))
+	}
+
+	return resp, nil
+}
+
+// resolveMentions keeps the "@user_id" mentions in text that name a member of
+// the room other than the sender.
+func (c *ChatServiceImpl) resolveMentions(ctx context.Context, roomID string, senderID string, text string) (mentions []string, err error) {
+	candidates := util.ParseMentions(text)
+	if len(candidates) == 0 {
+		return nil, nil
+	}
+
+	chatRoomID, err := primitive.ObjectIDFromHex(roomID)
+	if err != nil {
+		return nil, nil
+	}
+
+	chatRoom, err := c.chatRepository.GetChatRoomByID(ctx, chatRoomID)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, userID := range candidates {
+		if userID != senderID && contains(chatRoom.UserIDs, userID) {
+			mentions = append(mentions, userID)
+		}
+	}
+	return mentions, nil
+}
+
+// resolveAttachments looks up the attachments a new message references, in
+// the order given. They must have been uploaded to the message's room by its
+// sender.
+func (c *ChatServiceImpl) resolveAttachments(ctx context.Context, roomID string, senderID string, ids []string) (attachments []model.MessageAttachment, err error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+
+	if len(ids) > maxMessageAttachments {
+		return nil, errors.New(constant.ERROR_TOO_MANY_ATTACHMENTS)
+	}
+
+	var attachmentIDs []primitive.ObjectID
+	for _, id := range ids {
+		attachmentID, err := primitive.ObjectIDFromHex(id)
+		if err != nil {
+			return nil, errors.New(constant.ERROR_ATTACHMENT_NOT_EXIST)
+		}
+		attachmentIDs = append(attachmentIDs, attachmentID)
+	}
+
+	found, err := c.attachmentRepository.GetAttachments(ctx, attachmentIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	byID := make(map[primitive.ObjectID]model.Attachment)
+	for _, attachment := range found {
+		byID[attachment.AttachmentID] = attachment
+	}
+
+	added := make(map[primitive.ObjectID]bool)
+	for _, attachmentID := range attachmentIDs {
+		attachment, ok := byID[attachmentID]
+		if !ok {
+			return nil, errors.New(constant.ERROR_ATTACHMENT_NOT_EXIST)
+		}
+
+		if attachment.ChatRoomID != roomID || attachment.UploaderID != senderID {
+			return nil, errors.New(constant.ERROR_ATTACHMENT_OTHER_ROOM)
+		}
+
+		if added[attachmentID] {
+			continue
+		}
+		added[attachmentID] = true
+
+		attachments = append(attachments, model.MessageAttachment{
+			AttachmentID: attachmentID.Hex(),
+			FileName:     attachment.FileName,
+			ContentType:  attachment.ContentType,
+			Size:         attachment.Size,
+			Preview:      attachment.Preview,
+		})
+	}
+	return attachments, nil
+}
+
+// notifyMentions notifies the mentioned users. Mentions notify whatever the
+// room's settings, a failed notification is only logged.
+func (c *ChatServiceImpl) notifyMentions(ctx context.Context, message model.Message, mentions []string) {
+	excerpt := []rune(message.MessageText)
+	if len(excerpt) > mentionExcerptLength {
+		excerpt = excerpt[:mentionExcerptLength]
+	}
+
+	for _, userID := range mentions {
+		_, err := c.notificationService.Notify(ctx, userID, constant.NOTIFICATION_MENTION,
+			fmt.Sprintf("%s mentioned you", message.SenderID),
+			model.MentionPayload{
+				MessageID:  message.MessageID.Hex(),
+				ChatRoomID: message.ChatRoomID,
+				SenderID:   message.SenderID,
+				Excerpt:    string(excerpt),
+			})
+		if err != nil {
+			log.Println(err)
+		}
+	}
+}
+
+func (c *ChatServiceImpl) isModerator(userID string) bool {
+	return contains(c.config.Moderators, userID)
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// SearchMessages searches the messages of the rooms data.UserID is a member
+// of, leaving out those they deleted for themselves.
+func (c *ChatServiceImpl) SearchMessages(ctx context.Context, data dto.SearchMessagesRequest) (resp []dto.SearchMessagesResponse, err error) {
+	query := strings.TrimSpace(data.Query)
+	if utf8.RuneCountInString(query) > maxSearchQueryLength {
+		err = errors.New(constant.ERROR_SEARCH_QUERY_TOO_LONG)
+		log.Println(err)
+		return []dto.SearchMessagesResponse{}, err
+	}
+
+	roomIDs, err := c.chatRepository.GetUserChatRoomIDs(ctx, data.UserID)
+	if err != nil {
+		log.Println(err)
+		return []dto.SearchMessagesResponse{}, err
+	}
+
+	if data.ChatRoomID != "" {
+		if !contains(roomIDs, data.ChatRoomID) {
+			err = errors.New(constant.ERROR_NOT_ROOM_MEMBER)
+			log.Println(err)
+			return []dto.SearchMessagesResponse{}, err
+		}
+		roomIDs = []string{data.ChatRoomID}
+	}
+
+	resp = []dto.SearchMessagesResponse{}
+	if len(roomIDs) == 0 {
+		return resp, nil
+	}
+
+	messages, err := c.chatRepository.SearchMessages(ctx, query, roomIDs, data.SenderID, data.From, data.To, data.UserID, pageLimit(data.Limit), data.Offset)
+	if err != nil {
+		log.Println(err)
+		return []dto.SearchMessagesResponse{}, err
+	}
+
+	terms := util.SearchTerms(query)
+	for _, message := range messages {
+		resp = append(resp, dto.SearchMessagesResponse{
+			GetMessagesResponse: toMessageResponse(message),
+			Snippet:             util.HighlightSnippet(message.MessageText, terms, searchSnippetLength),
+		})
+	}
+
+	return resp, nil
+}
+
+func pageLimit(limit int64) int64 {
+	if limit <= 0 {
+		return defaultMessageLimit
+	}
+	if limit > maxMessageLimit {
+		return maxMessageLimit
+	}
+	return limit
+}
+
+func toMessageResponse(message model.Message) dto.GetMessagesResponse {
+	resp := dto.GetMessagesResponse{
+		MessageID:        message.MessageID.Hex(),
+		SenderID:         message.SenderID,
+		ReceiverID:       message.ReceiverID,
+		MessageText:      message.MessageText,
+		Timestamp:        message.Timestamp,
+		ChatRoomID:       message.ChatRoomID,
+		Seq:              message.Seq,
+		Reactions:        toReactions(message.Reactions),
+		ReplyToMessageID: message.ReplyToMessageID,
+		ThreadRootID:     message.ThreadRootID,
+		ReplyCount:       message.ReplyCount,
+		Mentions:         message.Mentions,
+		Attachments:      toAttachments(message.Attachments),
+	}
+	if !message.EditedAt.IsZero() {
+		editedAt := message.EditedAt
+		resp.EditedAt = &editedAt
+	}
+	if !message.DeletedAt.IsZero() {
+		deletedAt := message.DeletedAt
+		resp.Deleted = true
+		resp.DeletedAt = &deletedAt
+	}
+	if !message.LastReplyAt.IsZero() {
+		lastReplyAt := message.LastReplyAt
+		resp.LastReplyAt = &lastReplyAt
+	}
+	if !message.ExpiresAt.IsZero() {
+		expiresAt := message.ExpiresAt
+		resp.ExpiresAt = &expiresAt
+	} else {
+		resp.ExpireAfterRead = message.ExpireAfterRead
+	}
+	resp.Notice = toRoomNotice(message.Notice)
+	return resp
+}
+
+func toRoomNotice(notice *model.RoomNotice) *dto.RoomNotice {
+	if notice == nil {
+		return nil
+	}
+	return &dto.RoomNotice{
+		Type:           notice.Type,
+		DisappearAfter: notice.DisappearAfter,
+		DisappearFrom:  notice.DisappearFrom,
+	}
+}
+
+// toReactions aggregates reactions per emoji, the most used first.
+func toReactions(reactions map[string][]string) []dto.Reaction {
+	var resp []dto.Reaction
+	for emoji, userIDs := range reactions {
+		if len(userIDs) == 0 {
+			continue
+		}
+		resp = append(resp, dto.Reaction{
+			Emoji:   emoji,
+			Count:   len(userIDs),
+			UserIDs: userIDs,
+		})
+	}
+
+	sort.Slice(resp, func(i, j int) bool {
+		if resp[i].Count != resp[j].Count {
+			return resp[i].Count > resp[j].Count
+		}
+		return resp[i].Emoji < resp[j].Emoji
+	})
+	return resp
+}
+
+func attachmentIDs(attachments []model.MessageAttachment) []string {
+	var ids []string
+	for _, attachment := range attachments {
+		ids = append(ids, attachment.AttachmentID)
+	}
+	return ids
+}
+
+func toAttachments(attachments []model.MessageAttachment) []dto.Attachment {
+	var resp []dto.Attachment
+	for _, attachment := range attachments {
+		resp = append(resp, dto.Attachment{
+			AttachmentID: attachment.AttachmentID,
+			FileName:     attachment.FileName,
+			ContentType:  attachment.ContentType,
+			Size:         attachment.Size,
+			Preview:      toImagePreview(attachment.Preview),
+		})
+	}
+	return resp
+}
+
+// validEmoji accepts a short string that contains an emoji and nothing that
+// would break the Mongo field path it's stored under.
+func validEmoji(emoji string) bool {
+	if emoji == "" || len(emoji) > 32 || !utf8.ValidString(emoji) || strings.ContainsAny(emoji, ".$") {
+		return false
+	}
+
+	nonASCII := false
+	for _, r := range emoji {
+		if unicode.IsSpace(r) || unicode.IsControl(r) {
+			return false
+		}
+		if r > unicode.MaxASCII {
+			nonASCII = true
+		}
+	}
+	return nonASCII
+}
@@ -0,0 +1,291 @@
+package service
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"go-chat/constant"
+	"go-chat/dto"
+	"go-chat/model"
+	"go-chat/pkg/websocket"
+	"go-chat/repository"
+	"log"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+const (
+	defaultNotificationLimit = 20
+	maxNotificationLimit     = 100
+)
+
+type NotificationService interface {
+	Notify(ctx context.Context, userID string, notificationType string, content string, payload interface{}) (resp dto.NotificationResponse, err error)
+	GetNotifications(ctx context.Context, req dto.GetNotificationsRequest) (resp dto.GetNotificationsResponse, err error)
+	CountUnread(ctx context.Context, userID string) (resp dto.UnreadCountResponse, err error)
+	MarkAsRead(ctx context.Context, userID string, notificationID string) (err error)
+	MarkAllAsRead(ctx context.Context, userID string) (resp dto.MarkAllReadResponse, err error)
+	DeleteNotification(ctx context.Context, userID string, notificationID string) (err error)
+}
+
+type NotificationServiceImpl struct {
+	notificationRepository repository.NotificationRepository
+	hub                    *websocket.Hub
+}
+
+func NewNotificationService(notificationRepository repository.NotificationRepository, hub *websocket.Hub) NotificationService {
+	return &NotificationServiceImpl{
+		notificationRepository: notificationRepository,
+		hub:                    hub,
+	}
+}
+
+// Notify stores a notification for userID, so it is there when they come back
+// online, and pushes it to the connections they have open right now. payload
+// is the model payload type matching notificationType.
+func (n *NotificationServiceImpl) Notify(ctx context.Context, userID string, notificationType string, content string, payload interface{}) (resp dto.NotificationResponse, err error) {
+	notification, err := n.notificationRepository.CreateNotification(ctx, model.Notification{
+		UserID:           userID,
+		NotificationType: notificationType,
+		Content:          content,
+		Timestamp:        time.Now(),
+		Read:             false,
+	}, payload)
+	if err != nil {
+		log.Println(err)
+		return resp, err
+	}
+
+	resp = toNotificationResponse(notification)
+
+	data, err := json.Marshal(map[string]interface{}{
+		"action": "notification",
+		"data":   resp,
+	})
+	if err != nil {
+		log.Println(err)
+		return resp, err
+	}
+
+	// the notification is already stored, a failed push only delays it
+	if err := n.hub.PublishToUser(ctx, userID, data); err != nil {
+		log.Println("Failed to push notification: ", err)
+	}
+
+	return resp, nil
+}
+
+func (n *NotificationServiceImpl) GetNotifications(ctx context.Context, req dto.GetNotificationsRequest) (resp dto.GetNotificationsResponse, err error) {
+	before := primitive.NilObjectID
+	if req.Cursor != "" {
+		before, err = decodeNotificationCursor(req.Cursor)
+		if err != nil {
+			err = errors.New(constant.ERROR_INVALID_CURSOR)
+			log.Println(err)
+			return resp, err
+		}
+	}
+
+	limit := req.Limit
+	if limit <= 0 {
+		limit = defaultNotificationLimit
+	}
+	if limit > maxNotificationLimit {
+		limit = maxNotificationLimit
+	}
+
+	// one extra tells whether another page follows
+	notifications, err := n.notificationRepository.GetNotifications(ctx, req.UserID, before, limit+1, req.UnreadOnly)
+	if err != nil {
+		log.Println(err)
+		return resp, err
+	}
+
+	resp.HasMore = int64(len(notifications)) > limit
+	if resp.HasMore {
+		notifications = notifications[:limit]
+	}
+
+	resp.Notifications = []dto.NotificationResponse{}
+	for _, notification := range notifications {
+		resp.Notifications = append(resp.Notifications, toNotificationResponse(notification))
+	}
+
+	if resp.HasMore {
+		resp.NextCursor = encodeNotificationCursor(notifications[len(notifications)-1].NotificationID)
+	}
+
+	return resp, nil
+}
+
+func (n *NotificationServiceImpl) CountUnread(ctx context.Context, userID string) (resp dto.UnreadCountResponse, err error) {
+	count, err := n.notificationRepository.CountUnread(ctx, userID)
+	if err != nil {
+		log.Println(err)
+		return resp, err
+	}
+
+	resp = dto.UnreadCountResponse{
+		UserID: userID,
+		Unread: count,
+	}
+	return resp, nil
+}
+
+func (n *NotificationServiceImpl) MarkAsRead(ctx context.Context, userID string, notificationID string) (err error) {
+	objectID, err := primitive.ObjectIDFromHex(notificationID)
+	if err != nil {
+		err = errors.New(constant.ERROR_NOTIFICATION_NOT_EXIST)
+		log.Println(err)
+		return err
+	}
+
+	err = n.notificationRepository.MarkAsRead(ctx, userID, objectID)
+	if err == mongo.ErrNoDocuments {
+		err = errors.New(constant.ERROR_NOTIFICATION_NOT_EXIST)
+		log.Println(err)
+		return err
+	} else if err != nil {
+		log.Println(err)
+		return err
+	}
+
+	return nil
+}
+
+func (n *NotificationServiceImpl) MarkAllAsRead(ctx context.Context, userID string) (resp dto.MarkAllReadResponse, err error) {
+	updated, err := n.notificationRepository.MarkAllAsRead(ctx, userID)
+	if err != nil {
+		log.Println(err)
+		return resp, err
+	}
+
+	resp = dto.MarkAllReadResponse{
+		UserID:  userID,
+		Updated: updated,
+	}
+	return resp, nil
+}
+
+func (n *NotificationServiceImpl) DeleteNotification(ctx context.Context, userID string, notificationID string) (err error) {
+	objectID, err := primitive.ObjectIDFromHex(notificationID)
+	if err != nil {
+		err = errors.New(constant.ERROR_NOTIFICATION_NOT_EXIST)
+		log.Println(err)
+		return err
+	}
+
+	err = n.notificationRepository.DeleteNotification(ctx, userID, objectID)
+	if err == mongo.ErrNoDocuments {
+		err = errors.New(constant.ERROR_NOTIFICATION_NOT_EXIST)
+		log.Println(err)
+		return err
+	} else if err != nil {
+		log.Println(err)
+		return err
+	}
+
+	return nil
+}
+
+func toNotificationResponse(notification model.Notification) dto.NotificationResponse {
+	return dto.NotificationResponse{
+		NotificationID:   notification.NotificationID.Hex(),
+		UserID:           notification.UserID,
+		NotificationType: notification.NotificationType,
+		Content:          notification.Content,
+		Payload:          toNotificationPayload(notification),
+		Timestamp:        notification.Timestamp,
+		Read:             notification.Read,
+	}
+}
+
+// toNotificationPayload decodes the stored payload into the dto type that
+// belongs to the notification's type.
+func toNotificationPayload(notification model.Notification) interface{} {
+	if len(notification.Payload) == 0 {
+		return nil
+	}
+
+	switch notification.NotificationType {
+	case constant.NOTIFICATION_FRIEND_REQUEST:
+		var payload model.FriendRequestPayload
+		if err := bson.Unmarshal(notification.Payload, &payload); err != nil {
+			log.Println(err)
+			return nil
+		}
+		return dto.FriendRequestPayload{
+			RequestID: payload.RequestID,
+			SenderID:  payload.SenderID,
+		}
+	case constant.NOTIFICATION_FRIEND_REQUEST_ACCEPTED, constant.NOTIFICATION_FRIEND_REQUEST_DENIED:
+		var payload model.FriendRequestResponsePayload
+		if err := bson.Unmarshal(notification.Payload, &payload); err != nil {
+			log.Println(err)
+			return nil
+		}
+		return dto.FriendRequestResponsePayload{
+			RequestID:   payload.RequestID,
+			ResponderID: payload.ResponderID,
+		}
+	case constant.NOTIFICATION_MENTION:
+		var payload model.MentionPayload
+		if err := bson.Unmarshal(notification.Payload, &payload); err != nil {
+			log.Println(err)
+			return nil
+		}
+		return dto.MentionPayload{
+			MessageID:  payload.MessageID,
+			ChatRoomID: payload.ChatRoomID,
+			SenderID:   payload.SenderID,
+			Excerpt:    payload.Excerpt,
+		}
+	case constant.NOTIFICATION_ROOM_INVITE:
+		var payload model.RoomInvitePayload
+		if err := bson.Unmarshal(notification.Payload, &payload); err != nil {
+			log.Println(err)
+			return nil
+		}
+		return dto.RoomInvitePayload{
+			ChatRoomID: payload.ChatRoomID,
+			InviterID:  payload.InviterID,
+		}
+	case constant.NOTIFICATION_SCHEDULED_FAILED:
+		var payload model.ScheduledFailedPayload
+		if err := bson.Unmarshal(notification.Payload, &payload); err != nil {
+			log.Println(err)
+			return nil
+		}
+		return dto.ScheduledFailedPayload{
+			ScheduledMessageID: payload.ScheduledMessageID,
+			ChatRoomID:         payload.ChatRoomID,
+			Reason:             payload.Reason,
+		}
+	}
+
+	return nil
+}
+
+// cursors are opaque to clients, today they wrap the last notification ID
+func encodeNotificationCursor(id primitive.ObjectID) string {
+	return base64.RawURLEncoding.EncodeToString(id[:])
+}
+
+func decodeNotificationCursor(cursor string) (primitive.ObjectID, error) {
+	var id primitive.ObjectID
+
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return id, err
+	}
+	if len(raw) != len(id) {
+		return id, errors.New(constant.ERROR_INVALID_CURSOR)
+	}
+
+	copy(id[:], raw)
+	return id, nil
+}
@@ -0,0 +1,376 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"go-chat/constant"
+	"go-chat/dto"
+	"go-chat/model"
+	"go-chat/repository"
+	"log"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	// schedulerInterval is how often the scheduler looks for due messages,
+	// so how late after their time they may be sent.
+	schedulerInterval = 5 * time.Second
+	// scheduleLease is how long an instance has to send a message it claimed
+	// before another one may take it over.
+	scheduleLease        = time.Minute
+	maxScheduleAttempts  = 5
+	maxScheduleAhead     = 365 * 24 * time.Hour
+	maxScheduledMessages = 100
+)
+
+// undeliverableErrors are the errors sending a scheduled message again won't
+// fix, the message fails at once instead of being retried.
+var undeliverableErrors = map[string]bool{
+	constant.ERROR_NOT_ROOM_MEMBER:       true,
+	constant.ERROR_MESSAGE_NOT_EXIST:     true,
+	constant.ERROR_MESSAGE_DELETED:       true,
+	constant.ERROR_REPLY_OTHER_ROOM:      true,
+	constant.ERROR_ATTACHMENT_NOT_EXIST:  true,
+	constant.ERROR_ATTACHMENT_OTHER_ROOM: true,
+	constant.ERROR_TOO_MANY_ATTACHMENTS:  true,
+}
+
+type ScheduleService interface {
+	ScheduleMessage(ctx context.Context, data dto.ScheduleMessageRequest) (resp dto.ScheduledMessageResponse, err error)
+	GetScheduledMessages(ctx context.Context, data dto.GetScheduledMessagesRequest) (resp []dto.ScheduledMessageResponse, err error)
+	EditScheduledMessage(ctx context.Context, data dto.EditScheduledMessageRequest) (resp dto.ScheduledMessageResponse, err error)
+	CancelScheduledMessage(ctx context.Context, data dto.CancelScheduledMessageRequest) (err error)
+	RunScheduler(ctx context.Context)
+}
+
+type ScheduleServiceImpl struct {
+	scheduleRepository  repository.ScheduleRepository
+	chatRepository      repository.ChatRepository
+	chatService         ChatService
+	attachmentService   AttachmentService
+	notificationService NotificationService
+}
+
+func NewScheduleService(scheduleRepository repository.ScheduleRepository, chatRepository repository.ChatRepository, chatService ChatService, attachmentService AttachmentService, notificationService NotificationService) ScheduleService {
+	return &ScheduleServiceImpl{
+		scheduleRepository:  scheduleRepository,
+		chatRepository:      chatRepository,
+		chatService:         chatService,
+		attachmentService:   attachmentService,
+		notificationService: notificationService,
+	}
+}
+
+func (s *ScheduleServiceImpl) ScheduleMessage(ctx context.Context, data dto.ScheduleMessageRequest) (resp dto.ScheduledMessageResponse, err error) {
+	now := time.Now()
+
+	message := model.ScheduledMessage{
+		ScheduledMessageID: primitive.NewObjectID(),
+		ChatRoomID:         data.RoomID,
+		SenderID:           data.SenderID,
+		ReceiverID:         data.ReceiverID,
+		MessageText:        data.MessageText,
+		ReplyToMessageID:   data.ReplyToMessageID,
+		AttachmentIDs:      data.AttachmentIDs,
+		SendAt:             data.SendAt,
+		Status:             constant.SCHEDULED_PENDING,
+		CreatedAt:          now,
+		UpdatedAt:          now,
+	}
+
+	err = checkScheduledMessage(message, now)
+	if err != nil {
+		log.Println(err)
+		return resp, err
+	}
+
+	err = checkRoomMember(ctx, s.chatRepository, data.RoomID, data.SenderID)
+	if err != nil {
+		log.Println(err)
+		return resp, err
+	}
+
+	count, err := s.scheduleRepository.CountScheduledMessages(ctx, data.SenderID)
+	if err != nil {
+		log.Println(err)
+		return resp, err
+	}
+
+	if count >= maxScheduledMessages {
+		err = errors.New(constant.ERROR_TOO_MANY_SCHEDULED)
+		log.Println(err)
+		return resp, err
+	}
+
+	err = s.scheduleRepository.SaveScheduledMessage(ctx, message)
+	if err != nil {
+		log.Println(err)
+		return resp, err
+	}
+
+	return toScheduledMessageResponse(message), nil
+}
+
+func (s *ScheduleServiceImpl) GetScheduledMessages(ctx context.Context, data dto.GetScheduledMessagesRequest) (resp []dto.ScheduledMessageResponse, err error) {
+	messages, err := s.scheduleRepository.GetScheduledMessages(ctx, data.UserID, data.RoomID)
+	if err != nil {
+		log.Println(err)
+		return resp, err
+	}
+
+	resp = []dto.ScheduledMessageResponse{}
+	for _, message := range messages {
+		resp = append(resp, toScheduledMessageResponse(message))
+	}
+
+	return resp, nil
+}
+
+// EditScheduledMessage changes a message until the scheduler claims it to
+// send it.
+func (s *ScheduleServiceImpl) EditScheduledMessage(ctx context.Context, data dto.EditScheduledMessageRequest) (resp dto.ScheduledMessageResponse, err error) {
+	message, err := s.getScheduledMessage(ctx, data.ScheduledMessageID, data.SenderID)
+	if err != nil {
+		log.Println(err)
+		return resp, err
+	}
+
+	if message.Status == constant.SCHEDULED_SENDING {
+		err = errors.New(constant.ERROR_SCHEDULED_MESSAGE_SENDING)
+		log.Println(err)
+		return resp, err
+	}
+
+	previousAttachmentIDs := message.AttachmentIDs
+
+	if data.MessageText != nil {
+		message.MessageText = *data.MessageText
+	}
+	if data.AttachmentIDs != nil {
+		message.AttachmentIDs = data.AttachmentIDs
+	}
+	if data.SendAt != nil {
+		message.SendAt = *data.SendAt
+	}
+
+	now := time.Now()
+
+	err = checkScheduledMessage(message, now)
+	if err != nil {
+		log.Println(err)
+		return resp, err
+	}
+
+	message.Status = constant.SCHEDULED_PENDING
+	message.Attempts = 0
+	message.Error = ""
+	message.LockedUntil = time.Time{}
+	message.UpdatedAt = now
+
+	updated, err := s.scheduleRepository.UpdateScheduledMessage(ctx, message)
+	if err != nil {
+		log.Println(err)
+		return resp, err
+	}
+
+	if !updated {
+		err = errors.New(constant.ERROR_SCHEDULED_MESSAGE_SENDING)
+		log.Println(err)
+		return resp, err
+	}
+
+	var dropped []string
+	for _, attachmentID := range previousAttachmentIDs {
+		if !contains(message.AttachmentIDs, attachmentID) {
+			dropped = append(dropped, attachmentID)
+		}
+	}
+	s.attachmentService.ReleaseAttachments(ctx, dropped)
+
+	return toScheduledMessageResponse(message), nil
+}
+
+func (s *ScheduleServiceImpl) CancelScheduledMessage(ctx context.Context, data dto.CancelScheduledMessageRequest) (err error) {
+	message, err := s.getScheduledMessage(ctx, data.ScheduledMessageID, data.UserID)
+	if err != nil {
+		log.Println(err)
+		return err
+	}
+
+	cancelled, err := s.scheduleRepository.CancelScheduledMessage(ctx, message.ScheduledMessageID)
+	if err != nil {
+		log.Println(err)
+		return err
+	}
+
+	if !cancelled {
+		err = errors.New(constant.ERROR_SCHEDULED_MESSAGE_SENDING)
+		log.Println(err)
+		return err
+	}
+
+	s.attachmentService.ReleaseAttachments(ctx, message.AttachmentIDs)
+
+	return nil
+}
+
+// RunScheduler sends the scheduled messages that are due until ctx is done.
+// Every instance of the server runs it, each message is claimed by one of
+// them at a time.
+func (s *ScheduleServiceImpl) RunScheduler(ctx context.Context) {
+	ticker := time.NewTicker(schedulerInterval)
+	defer ticker.Stop()
+
+	for {
+		s.sendDueMessages(ctx)
+
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (s *ScheduleServiceImpl) sendDueMessages(ctx context.Context) {
+	for ctx.Err() == nil {
+		now := time.Now()
+
+		message, claimed, err := s.scheduleRepository.ClaimDueMessage(ctx, now, now.Add(scheduleLease))
+		if err != nil {
+			log.Println(err)
+			return
+		}
+
+		if !claimed {
+			return
+		}
+
+		s.sendScheduledMessage(ctx, message)
+	}
+}
+
+// sendScheduledMessage sends message the way clients send theirs, so it's
+// stored and broadcast to the room alike. Messages that failed for a reason
+// that may pass are left claimed, and retried once the claim runs out.
+func (s *ScheduleServiceImpl) sendScheduledMessage(ctx context.Context, message model.ScheduledMessage) {
+	// a send already started is finished even when the server stops
+	ctx = context.WithoutCancel(ctx)
+
+	// an earlier attempt may have stored it before its instance stopped
+	sent, err := s.chatRepository.GetMessage(ctx, message.ScheduledMessageID)
+	if err != nil {
+		log.Println(err)
+		return
+	}
+
+	if sent.MessageID.IsZero() {
+		err = checkRoomMember(ctx, s.chatRepository, message.ChatRoomID, message.SenderID)
+		if err == nil {
+			_, err = s.chatService.SendMessage(ctx, dto.SendMessageRequest{
+				RoomID:           message.ChatRoomID,
+				SenderID:         message.SenderID,
+				ReceiverID:       message.ReceiverID,
+				MessageText:      message.MessageText,
+				ReplyToMessageID: message.ReplyToMessageID,
+				AttachmentIDs:    message.AttachmentIDs,
+				MessageID:        message.ScheduledMessageID.Hex(),
+			})
+		}
+		if err != nil {
+			log.Println("Failed to send scheduled message: ", err)
+			s.failScheduledMessage(ctx, message, err)
+			return
+		}
+	}
+
+	if err := s.scheduleRepository.DeleteScheduledMessage(ctx, message.ScheduledMessageID); err != nil {
+		log.Println(err)
+	}
+}
+
+// failScheduledMessage gives up on message when err won't pass or it was
+// tried too many times, and tells its sender.
+func (s *ScheduleServiceImpl) failScheduledMessage(ctx context.Context, message model.ScheduledMessage, err error) {
+	reason := err.Error()
+	if !undeliverableErrors[reason] {
+		if message.Attempts < maxScheduleAttempts {
+			return
+		}
+		reason = "message couldn't be sent"
+	}
+
+	if err := s.scheduleRepository.FailScheduledMessage(ctx, message.ScheduledMessageID, reason); err != nil {
+		log.Println(err)
+		return
+	}
+
+	_, err = s.notificationService.Notify(ctx, message.SenderID, constant.NOTIFICATION_SCHEDULED_FAILED,
+		"Your scheduled message couldn't be sent", model.ScheduledFailedPayload{
+			ScheduledMessageID: message.ScheduledMessageID.Hex(),
+			ChatRoomID:         message.ChatRoomID,
+			Reason:             reason,
+		})
+	if err != nil {
+		log.Println("Failed to notify scheduled message failure: ", err)
+	}
+}
+
+// getScheduledMessage returns a message userID scheduled. Those of other
+// users don't exist as far as userID is concerned.
+func (s *ScheduleServiceImpl) getScheduledMessage(ctx context.Context, scheduledMessageID string, userID string) (message model.ScheduledMessage, err error) {
+	id, err := primitive.ObjectIDFromHex(scheduledMessageID)
+	if err != nil {
+		return message, errors.New(constant.ERROR_SCHEDULED_MESSAGE_NOT_EXIST)
+	}
+
+	message, err = s.scheduleRepository.GetScheduledMessage(ctx, id)
+	if err != nil {
+		return message, err
+	}
+
+	if message.ScheduledMessageID.IsZero() || message.SenderID != userID {
+		return message, errors.New(constant.ERROR_SCHEDULED_MESSAGE_NOT_EXIST)
+	}
+
+	return message, nil
+}
+
+func checkScheduledMessage(message model.ScheduledMessage, now time.Time) error {
+	if message.MessageText == "" && len(message.AttachmentIDs) == 0 {
+		return errors.New(constant.ERROR_SCHEDULED_MESSAGE_EMPTY)
+	}
+
+	if len(message.AttachmentIDs) > maxMessageAttachments {
+		return errors.New(constant.ERROR_TOO_MANY_ATTACHMENTS)
+	}
+
+	if !message.SendAt.After(now) {
+		return errors.New(constant.ERROR_SEND_AT_PAST)
+	}
+
+	if message.SendAt.After(now.Add(maxScheduleAhead)) {
+		return errors.New(constant.ERROR_SEND_AT_TOO_FAR)
+	}
+
+	return nil
+}
+
+func toScheduledMessageResponse(message model.ScheduledMessage) dto.ScheduledMessageResponse {
+	return dto.ScheduledMessageResponse{
+		ScheduledMessageID: message.ScheduledMessageID.Hex(),
+		ChatRoomID:         message.ChatRoomID,
+		SenderID:           message.SenderID,
+		ReceiverID:         message.ReceiverID,
+		MessageText:        message.MessageText,
+		ReplyToMessageID:   message.ReplyToMessageID,
+		AttachmentIDs:      message.AttachmentIDs,
+		SendAt:             message.SendAt,
+		Status:             message.Status,
+		Error:              message.Error,
+		CreatedAt:          message.CreatedAt,
+		UpdatedAt:          message.UpdatedAt,
+	}
+}
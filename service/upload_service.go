@@ -0,0 +1,426 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"go-chat/constant"
+	"go-chat/dto"
+	"go-chat/model"
+	"go-chat/pkg/storage"
+	"go-chat/repository"
+	"io"
+	"log"
+	"os"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	uploadCleanupInterval = 10 * time.Minute
+	uploadCleanupBatch    = 100
+	// maxUploadChunks bounds the blobs an upload is split into, each request
+	// adds one however little it carries.
+	maxUploadChunks = 1000
+)
+
+type UploadService interface {
+	CreateUpload(ctx context.Context, data dto.CreateUploadRequest) (resp dto.UploadResponse, err error)
+	GetUpload(ctx context.Context, data dto.UploadRequest) (resp dto.UploadResponse, err error)
+	PatchUpload(ctx context.Context, data dto.PatchUploadRequest) (resp dto.UploadResponse, err error)
+	DeleteUpload(ctx context.Context, data dto.UploadRequest) (err error)
+	MaxSize() int64
+	RunCleanup(ctx context.Context)
+}
+
+type UploadConfig struct {
+	// MaxSize caps the length of an upload, it should be the attachments'
+	// MaxSize.
+	MaxSize int64
+	// UserQuota caps the total length of a user's unfinished uploads, zero
+	// leaves it unlimited.
+	UserQuota int64
+	// Expiration is how long an upload is kept after the last chunk it
+	// received.
+	Expiration time.Duration
+}
+
+type UploadServiceImpl struct {
+	uploadRepository  repository.UploadRepository
+	chatRepository    repository.ChatRepository
+	attachmentService AttachmentService
+	blobStore         storage.BlobStore
+	config            UploadConfig
+}
+
+func NewUploadService(uploadRepository repository.UploadRepository, chatRepository repository.ChatRepository, attachmentService AttachmentService, blobStore storage.BlobStore, config UploadConfig) UploadService {
+	return &UploadServiceImpl{
+		uploadRepository:  uploadRepository,
+		chatRepository:    chatRepository,
+		attachmentService: attachmentService,
+		blobStore:         blobStore,
+		config:            config,
+	}
+}
+
+func (u *UploadServiceImpl) CreateUpload(ctx context.Context, data dto.CreateUploadRequest) (resp dto.UploadResponse, err error) {
+	if data.Length <= 0 {
+		err = errors.New(constant.ERROR_ATTACHMENT_EMPTY)
+		log.Println(err)
+		return resp, err
+	}
+
+	if data.Length > u.config.MaxSize {
+		err = errors.New(constant.ERROR_ATTACHMENT_TOO_LARGE)
+		log.Println(err)
+		return resp, err
+	}
+
+	err = checkRoomMember(ctx, u.chatRepository, data.ChatRoomID, data.UploaderID)
+	if err != nil {
+		log.Println(err)
+		return resp, err
+	}
+
+	now := time.Now()
+
+	if u.config.UserQuota > 0 {
+		uploads, err := u.uploadRepository.GetActiveUploads(ctx, data.UploaderID, now)
+		if err != nil {
+			log.Println(err)
+			return resp, err
+		}
+
+		reserved := data.Length
+		for _, upload := range uploads {
+			reserved += upload.Length
+		}
+
+		if reserved > u.config.UserQuota {
+			err = errors.New(constant.ERROR_UPLOAD_QUOTA)
+			log.Println(err)
+			return resp, err
+		}
+	}
+
+	upload := model.Upload{
+		UploadID:   primitive.NewObjectID(),
+		ChatRoomID: data.ChatRoomID,
+		UploaderID: data.UploaderID,
+		FileName:   attachmentFileName(data.FileName),
+		Length:     data.Length,
+		CreatedAt:  now,
+		ExpiresAt:  now.Add(u.config.Expiration),
+	}
+
+	err = u.uploadRepository.SaveUpload(ctx, upload)
+	if err != nil {
+		log.Println(err)
+		return resp, err
+	}
+
+	return toUploadResponse(upload), nil
+}
+
+func (u *UploadServiceImpl) GetUpload(ctx context.Context, data dto.UploadRequest) (resp dto.UploadResponse, err error) {
+	upload, err := u.getUpload(ctx, data.UploadID, data.UserID)
+	if err != nil {
+		log.Println(err)
+		return resp, err
+	}
+
+	return toUploadResponse(upload), nil
+}
+
+// PatchUpload stores the content of a request at data.Offset, which must be
+// the upload's offset, and turns the upload into an attachment once it has
+// all of it.
+func (u *UploadServiceImpl) PatchUpload(ctx context.Context, data dto.PatchUploadRequest) (resp dto.UploadResponse, err error) {
+	upload, err := u.getUpload(ctx, data.UploadID, data.UserID)
+	if err != nil {
+		log.Println(err)
+		return resp, err
+	}
+
+	if data.Offset != upload.Offset {
+		err = errors.New(constant.ERROR_UPLOAD_OFFSET)
+		log.Println(err)
+		return resp, err
+	}
+
+	if upload.Offset < upload.Length {
+		upload, err = u.appendChunk(ctx, upload, data.Content)
+		if err != nil {
+			log.Println(err)
+			return resp, err
+		}
+	}
+
+	// a request that got the last bytes but failed to make the attachment
+	// is retried by sending nothing at the final offset
+	if upload.Offset == upload.Length && upload.AttachmentID == "" {
+		upload, err = u.finishUpload(ctx, upload)
+		if err != nil {
+			log.Println(err)
+			return resp, err
+		}
+	}
+
+	return toUploadResponse(upload), nil
+}
+
+func (u *UploadServiceImpl) DeleteUpload(ctx context.Context, data dto.UploadRequest) (err error) {
+	upload, err := u.getUpload(ctx, data.UploadID, data.UserID)
+	if err != nil {
+		log.Println(err)
+		return err
+	}
+
+	return u.removeUpload(ctx, upload)
+}
+
+func (u *UploadServiceImpl) MaxSize() int64 {
+	return u.config.MaxSize
+}
+
+// RunCleanup deletes expired uploads, and the chunks of those that were
+// abandoned, until ctx is done.
+func (u *UploadServiceImpl) RunCleanup(ctx context.Context) {
+	ticker := time.NewTicker(uploadCleanupInterval)
+	defer ticker.Stop()
+
+	for {
+		u.cleanupUploads(ctx)
+
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (u *UploadServiceImpl) cleanupUploads(ctx context.Context) {
+	for {
+		uploads, err := u.uploadRepository.GetExpiredUploads(ctx, time.Now(), uploadCleanupBatch)
+		if err != nil {
+			log.Println(err)
+			return
+		}
+
+		for _, upload := range uploads {
+			if err := u.removeUpload(ctx, upload); err != nil {
+				log.Println("Failed to remove expired upload: ", err)
+				return
+			}
+		}
+
+		if len(uploads) < uploadCleanupBatch {
+			return
+		}
+	}
+}
+
+func (u *UploadServiceImpl) getUpload(ctx context.Context, uploadID string, userID string) (upload model.Upload, err error) {
+	id, err := primitive.ObjectIDFromHex(uploadID)
+	if err != nil {
+		return upload, errors.New(constant.ERROR_UPLOAD_NOT_EXIST)
+	}
+
+	upload, err = u.uploadRepository.GetUpload(ctx, id)
+	if err != nil {
+		return upload, err
+	}
+
+	// other users' uploads are hidden rather than forbidden
+	if upload.UploadID == primitive.NilObjectID || upload.UploaderID != userID {
+		return upload, errors.New(constant.ERROR_UPLOAD_NOT_EXIST)
+	}
+
+	if time.Now().After(upload.ExpiresAt) {
+		return upload, errors.New(constant.ERROR_UPLOAD_EXPIRED)
+	}
+
+	return upload, nil
+}
+
+// appendChunk stores content as the upload's next chunk. Content cut short,
+// as when the connection drops, is kept so the client can resume after it.
+func (u *UploadServiceImpl) appendChunk(ctx context.Context, upload model.Upload, content io.Reader) (model.Upload, error) {
+	if len(upload.Chunks) >= maxUploadChunks {
+		return upload, errors.New(constant.ERROR_UPLOAD_TOO_MANY_CHUNKS)
+	}
+
+	tmp, err := os.CreateTemp("", "upload-*")
+	if err != nil {
+		return upload, err
+	}
+	defer func() {
+		tmp.Close()
+		os.Remove(tmp.Name())
+	}()
+
+	remaining := upload.Length - upload.Offset
+	size, readErr := io.Copy(tmp, io.LimitReader(content, remaining+1))
+	if size > remaining {
+		return upload, errors.New(constant.ERROR_UPLOAD_LENGTH)
+	}
+
+	if size == 0 {
+		return upload, readErr
+	}
+
+	// a dropped connection cancels the request, what it sent is kept anyway
+	ctx = context.WithoutCancel(ctx)
+
+	if _, err = tmp.Seek(0, io.SeekStart); err != nil {
+		return upload, err
+	}
+
+	// chunks are named apart from their offset, two requests racing for the
+	// same offset each store their own and only one is kept
+	chunk := model.UploadChunk{
+		BlobKey: "upload-" + primitive.NewObjectID().Hex(),
+		Size:    size,
+	}
+
+	err = u.blobStore.Put(ctx, chunk.BlobKey, tmp, size, "application/octet-stream")
+	if err != nil {
+		return upload, err
+	}
+
+	expiresAt := time.Now().Add(u.config.Expiration)
+	appended, err := u.uploadRepository.AppendChunk(ctx, upload.UploadID, upload.Offset, chunk, expiresAt)
+	if err != nil || !appended {
+		u.deleteChunks(ctx, []model.UploadChunk{chunk})
+		if err == nil {
+			err = errors.New(constant.ERROR_UPLOAD_OFFSET)
+		}
+		return upload, err
+	}
+
+	upload.Chunks = append(upload.Chunks, chunk)
+	upload.Offset += size
+	upload.ExpiresAt = expiresAt
+
+	return upload, readErr
+}
+
+// finishUpload makes an attachment out of the upload's chunks, going through
+// the same checks as any other attachment. Content they refuse ends the
+// upload, since sending it again won't change the outcome.
+func (u *UploadServiceImpl) finishUpload(ctx context.Context, upload model.Upload) (model.Upload, error) {
+	content := &chunkReader{ctx: ctx, blobStore: u.blobStore, chunks: upload.Chunks}
+	defer content.Close()
+
+	attachment, err := u.attachmentService.UploadAttachment(ctx, dto.UploadAttachmentRequest{
+		ChatRoomID: upload.ChatRoomID,
+		UploaderID: upload.UploaderID,
+		FileName:   upload.FileName,
+		Content:    content,
+	})
+	if err != nil {
+		switch err.Error() {
+		case constant.ERROR_ATTACHMENT_EMPTY,
+			constant.ERROR_ATTACHMENT_TOO_LARGE,
+			constant.ERROR_ATTACHMENT_TYPE,
+			constant.ERROR_INVALID_IMAGE,
+			constant.ERROR_NOT_ROOM_MEMBER:
+			if err := u.removeUpload(ctx, upload); err != nil {
+				log.Println("Failed to remove refused upload: ", err)
+			}
+		}
+		return upload, err
+	}
+
+	completed, err := u.uploadRepository.CompleteUpload(ctx, upload.UploadID, attachment.AttachmentID)
+	if err != nil {
+		return upload, err
+	}
+
+	if !completed {
+		// another request finished it first and owns the chunks, the
+		// attachment made here is nobody's
+		u.attachmentService.ReleaseAttachments(ctx, []string{attachment.AttachmentID})
+		return u.getUpload(ctx, upload.UploadID.Hex(), upload.UploaderID)
+	}
+
+	u.deleteChunks(ctx, upload.Chunks)
+
+	upload.Chunks = nil
+	upload.AttachmentID = attachment.AttachmentID
+
+	return upload, nil
+}
+
+func (u *UploadServiceImpl) removeUpload(ctx context.Context, upload model.Upload) error {
+	u.deleteChunks(ctx, upload.Chunks)
+	return u.uploadRepository.DeleteUpload(ctx, upload.UploadID)
+}
+
+// deleteChunks only logs failures, a chunk left behind takes up space but
+// isn't referenced anymore.
+func (u *UploadServiceImpl) deleteChunks(ctx context.Context, chunks []model.UploadChunk) {
+	for _, chunk := range chunks {
+		if err := u.blobStore.Delete(ctx, chunk.BlobKey); err != nil {
+			log.Printf("Failed to delete upload chunk %s: %v", chunk.BlobKey, err)
+		}
+	}
+}
+
+// chunkReader reads an upload's chunks one after the other, opening each one
+// only once the previous one is read.
+type chunkReader struct {
+	ctx       context.Context
+	blobStore storage.BlobStore
+	chunks    []model.UploadChunk
+	current   io.ReadCloser
+}
+
+func (c *chunkReader) Read(p []byte) (int, error) {
+	for {
+		if c.current == nil {
+			if len(c.chunks) == 0 {
+				return 0, io.EOF
+			}
+
+			content, err := c.blobStore.Get(c.ctx, c.chunks[0].BlobKey)
+			if err != nil {
+				return 0, err
+			}
+			c.current = content
+			c.chunks = c.chunks[1:]
+		}
+
+		n, err := c.current.Read(p)
+		if err == io.EOF {
+			c.current.Close()
+			c.current = nil
+			if n == 0 {
+				continue
+			}
+			err = nil
+		}
+		return n, err
+	}
+}
+
+func (c *chunkReader) Close() error {
+	if c.current == nil {
+		return nil
+	}
+	return c.current.Close()
+}
+
+func toUploadResponse(upload model.Upload) dto.UploadResponse {
+	return dto.UploadResponse{
+		UploadID:     upload.UploadID.Hex(),
+		ChatRoomID:   upload.ChatRoomID,
+		UploaderID:   upload.UploaderID,
+		FileName:     upload.FileName,
+		Length:       upload.Length,
+		Offset:       upload.Offset,
+		AttachmentID: upload.AttachmentID,
+		ExpiresAt:    upload.ExpiresAt,
+	}
+}
@@ -3,6 +3,7 @@ package service
 import (
 	"context"
 	"errors"
+	"fmt"
 	"go-chat/constant"
 	"go-chat/dto"
 	"go-chat/model"
@@ -18,14 +19,16 @@ type UserService interface {
 }
 
 type UserServiceImpl struct {
-	AuthRepository repository.AuthRepository
-	UserRepository repository.UserRepository
+	AuthRepository      repository.AuthRepository
+	UserRepository      repository.UserRepository
+	NotificationService NotificationService
 }
 
-func NewUserService(a repository.AuthRepository, u repository.UserRepository) UserService {
+func NewUserService(a repository.AuthRepository, u repository.UserRepository, n NotificationService) UserService {
 	return &UserServiceImpl{
-		AuthRepository: a,
-		UserRepository: u,
+		AuthRepository:      a,
+		UserRepository:      u,
+		NotificationService: n,
 	}
 }
 
@@ -57,6 +60,18 @@ func (u *UserServiceImpl) AddFriend(ctx context.Context, req dto.FriendRequestPa
 		CreatedAt:  friendRequest.CreatedAt,
 		UpdatedAt:  friendRequest.UpdatedAt,
 	}
+
+	// the request itself went through, a failed notification is only logged
+	_, err = u.NotificationService.Notify(ctx, friendRequest.ReceiverID, constant.NOTIFICATION_FRIEND_REQUEST,
+		fmt.Sprintf("%s sent you a friend request", friendRequest.SenderID),
+		model.FriendRequestPayload{
+			RequestID: resp.RequestID,
+			SenderID:  friendRequest.SenderID,
+		})
+	if err != nil {
+		log.Println(err)
+	}
+
 	return resp, nil
 }
 
@@ -107,6 +122,23 @@ func (u *UserServiceImpl) UpdateFriendRequest(ctx context.Context, req dto.Updat
 		CreatedAt:  updatedRequest.CreatedAt,
 		UpdatedAt:  updatedRequest.UpdatedAt,
 	}
+
+	notificationType := constant.NOTIFICATION_FRIEND_REQUEST_DENIED
+	content := fmt.Sprintf("%s declined your friend request", updatedRequest.ReceiverID)
+	if updatedRequest.Status == constant.REQUEST_ACCEPTED_STATUS {
+		notificationType = constant.NOTIFICATION_FRIEND_REQUEST_ACCEPTED
+		content = fmt.Sprintf("%s accepted your friend request", updatedRequest.ReceiverID)
+	}
+
+	_, err = u.NotificationService.Notify(ctx, updatedRequest.SenderID, notificationType, content,
+		model.FriendRequestResponsePayload{
+			RequestID:   resp.RequestID,
+			ResponderID: updatedRequest.ReceiverID,
+		})
+	if err != nil {
+		log.Println(err)
+	}
+
 	return resp, nil
 
 }